@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lyp256/tianmen/pkg/agent"
+)
+
+func main() {
+	configPath := flag.String("config", "/etc/tianmen/agent.json", "配置文件路径")
+	flag.Parse()
+
+	err := run(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string) error {
+	c, err := agent.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	a, err := agent.New(c)
+	if err != nil {
+		return err
+	}
+	a.OnStateChange = logState
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return a.Run(ctx)
+}
+
+// logState 输出连接状态变化
+func logState(c agent.StateChange) {
+	switch c.State {
+	case agent.StateConnected:
+		fmt.Fprintf(os.Stderr, "connected to %s\n", c.Server)
+	case agent.StateDisconnected:
+		fmt.Fprintf(os.Stderr, "disconnected from %s: %v, retry in %s\n", c.Server, c.Err, c.Retry.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lyp256/tianmen/pkg/controller"
+)
+
+func main() {
+	configPath := flag.String("config", "/etc/tianmen/server.json", "配置文件路径")
+	flag.Parse()
+
+	err := run(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string) error {
+	c, err := controller.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	s, err := controller.New(c)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return s.Run(ctx)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+)
+
+func agents(args []string) error {
+	fs := flag.NewFlagSet("agents", flag.ContinueOnError)
+	server := &serverFlags{}
+	server.register(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	conn, err := server.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	res, err := controller.NewRegistryClient(conn).ListAgents(context.Background(), &controller.ListAgentsRequest{})
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tHOSTNAME\tPLATFORM\tVERSION\tTRANSPORT\tREMOTE\tCONNECTED")
+	for _, a := range res.GetAgents() {
+		m := a.GetMeta()
+		connected := time.Unix(a.GetConnectedAt(), 0).Format(time.DateTime)
+		fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s\t%s\t%s\t%s\n", a.GetId(), m.GetHostname(), m.GetOS(), m.GetArch(),
+			m.GetVersion(), a.GetTransport(), a.GetRemoteAddr(), connected)
+	}
+	return w.Flush()
+}
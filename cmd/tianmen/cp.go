@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/lyp256/tianmen/pkg/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+)
+
+// splitAgentPath 解析 <agent>:<path>, 不包含 : 或 : 之前包含路径分隔符时为本地路径
+func splitAgentPath(arg string) (agentID, p string, remote bool) {
+	agentID, p, ok := strings.Cut(arg, ":")
+	if !ok || agentID == "" || strings.ContainsAny(agentID, `/\`) {
+		return "", arg, false
+	}
+	return agentID, p, true
+}
+
+func cp(args []string) error {
+	fs := flag.NewFlagSet("cp", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "usage: tianmen cp [-c] [-p] <src> <dst>\n"+
+			"src 和 dst 其中一个为 agent 上的文件, 写作 <agent>:<absolute_path>, 以 / 结尾时复制到该目录下\n")
+		fs.PrintDefaults()
+	}
+	server := &serverFlags{}
+	server.register(fs)
+	resume := fs.Bool("c", false, "断点续传, 保留上次中断时已传输的数据")
+	owner := fs.Bool("p", false, "保留文件属主, 通常需要 root 权限")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	srcAgent, src, srcRemote := splitAgentPath(fs.Arg(0))
+	dstAgent, dst, dstRemote := splitAgentPath(fs.Arg(1))
+	if srcRemote == dstRemote {
+		return errors.New("exactly one of src and dst must be <agent>:<path>")
+	}
+
+	conn, err := server.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opt := serviceCore.FileOptions{Resume: *resume, Owner: *owner}
+	var info *core.FileInfo
+	if dstRemote {
+		if strings.HasSuffix(dst, "/") {
+			dst = path.Join(dst, filepath.Base(src))
+		}
+		cli := core.NewFileTransferClient(controller.AgentClientConn(conn, dstAgent))
+		info, err = serviceCore.UploadFile(ctx, cli, src, dst, opt)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s -> %s:%s, %d bytes\n", src, dstAgent, dst, info.GetSize())
+		return nil
+	}
+	if fi, err := os.Stat(dst); (err == nil && fi.IsDir()) || strings.HasSuffix(dst, string(filepath.Separator)) {
+		dst = filepath.Join(dst, path.Base(src))
+	}
+	cli := core.NewFileTransferClient(controller.AgentClientConn(conn, srcAgent))
+	info, err = serviceCore.DownloadFile(ctx, cli, src, dst, opt)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s:%s -> %s, %d bytes\n", srcAgent, src, dst, info.GetSize())
+	return nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lyp256/tianmen/pkg/controller"
+	apiController "github.com/lyp256/tianmen/pkg/rpc/api/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+)
+
+// stringsFlag 可以重复指定的参数
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// forwardSpec -L 或 -R 参数, 在 listen 接收连接并转发到 target.
+// -L 在本地监听, 由 agent 连接 target; -R 在 agent 上监听, 由控制端连接 target
+type forwardSpec struct {
+	remote    bool
+	listenNet string
+	listen    string
+	targetNet string
+	target    string
+}
+
+// parseForwardSpec 解析 [bind_address:]port:host:hostport, bind_address 默认为 127.0.0.1.
+// 监听地址和目标都可以是 unix socket 的绝对路径, 如 /tmp/a.sock:host:hostport, port:/var/run/docker.sock
+func parseForwardSpec(spec string, remote bool) (forwardSpec, error) {
+	f := forwardSpec{remote: remote, listenNet: "tcp", targetNet: "tcp"}
+	invalid := fmt.Errorf("invalid forward %q, want [bind_address:]port:host:hostport", spec)
+	var listen []string
+	target := spec
+	switch {
+	case strings.HasPrefix(spec, "/"):
+		var ok bool
+		f.listenNet = "unix"
+		f.listen, target, ok = strings.Cut(spec, ":")
+		if !ok {
+			return forwardSpec{}, invalid
+		}
+	case strings.Contains(spec, ":/"):
+		i := strings.Index(spec, ":/")
+		listen, target = splitForwardSpec(spec[:i]), spec[i+1:]
+	default:
+		parts := splitForwardSpec(spec)
+		if len(parts) < 3 {
+			return forwardSpec{}, invalid
+		}
+		listen, target = parts[:len(parts)-2], net.JoinHostPort(parts[len(parts)-2], parts[len(parts)-1])
+	}
+
+	if strings.HasPrefix(target, "/") {
+		f.targetNet, f.target = "unix", target
+	} else if f.listenNet == "unix" {
+		parts := splitForwardSpec(target)
+		if len(parts) != 2 {
+			return forwardSpec{}, invalid
+		}
+		f.target = net.JoinHostPort(parts[0], parts[1])
+	} else {
+		f.target = target
+	}
+	if f.listenNet == "tcp" {
+		switch len(listen) {
+		case 1:
+			f.listen = net.JoinHostPort("127.0.0.1", listen[0])
+		case 2:
+			f.listen = net.JoinHostPort(listen[0], listen[1])
+		default:
+			return forwardSpec{}, invalid
+		}
+	}
+	return f, nil
+}
+
+// splitForwardSpec 按 : 分割, 方括号中的 IPv6 地址不分割
+func splitForwardSpec(spec string) []string {
+	var parts []string
+	for spec != "" {
+		var part string
+		if strings.HasPrefix(spec, "[") {
+			end := strings.Index(spec, "]")
+			if end < 0 {
+				return nil
+			}
+			part, spec = spec[1:end], spec[end+1:]
+			if spec != "" && !strings.HasPrefix(spec, ":") {
+				return nil
+			}
+			spec = strings.TrimPrefix(spec, ":")
+		} else {
+			part, spec, _ = strings.Cut(spec, ":")
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func forward(args []string) error {
+	fs := flag.NewFlagSet("forward", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "usage: tianmen forward [-L [bind_address:]port:host:hostport] [-R [bind_address:]port:host:hostport] <agent>\n"+
+			"port 和 host:hostport 都可以替换为 unix socket 的绝对路径, 如 -L 8080:/var/run/docker.sock\n"+
+			"-R 由控制端连接 host:hostport, tianmen 退出时 agent 停止监听\n")
+		fs.PrintDefaults()
+	}
+	server := &serverFlags{}
+	server.register(fs)
+	var locals, remotes stringsFlag
+	fs.Var(&locals, "L", "在本地监听 port, 经 agent 连接 host:hostport, 可以重复指定")
+	fs.Var(&remotes, "R", "在 agent 上监听 port, 由控制端连接 host:hostport, 可以重复指定")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || len(locals)+len(remotes) == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	agentID := fs.Arg(0)
+	var specs []forwardSpec
+	for _, spec := range locals {
+		f, err := parseForwardSpec(spec, false)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, f)
+	}
+	for _, spec := range remotes {
+		f, err := parseForwardSpec(spec, true)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, f)
+	}
+
+	conn, err := server.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	cli := core.NewForwardClient(conn)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	remoteCli := apiController.NewRemoteForwardClient(conn)
+	errCh := make(chan error, len(specs))
+	for _, f := range specs {
+		if f.remote {
+			err = remoteForward(ctx, remoteCli, agentID, f, errCh)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		targetNet, target := f.targetNet, f.target
+		l, err := listenLocal(f.listenNet, f.listen)
+		if err != nil {
+			return err
+		}
+		defer l.Close()
+		fmt.Fprintf(os.Stderr, "forwarding %s -> %s via %s\n", l.Addr(), target, agentID)
+		dial := func(ctx context.Context) (net.Conn, error) {
+			return serviceCore.DialForward(controller.AgentContext(ctx, agentID), cli, targetNet, target)
+		}
+		go func() {
+			errCh <- serviceCore.ServeForward(l, dial, func(err error) {
+				fmt.Fprintf(os.Stderr, "forward %s: %v\n", target, err)
+			})
+		}()
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case err = <-errCh:
+		if err == nil {
+			err = errors.New("listener closed")
+		}
+		return err
+	}
+}
+
+// listenLocal 在本地监听, 删除上次运行遗留的 unix socket 文件, 关闭时删除本次创建的 socket 文件
+func listenLocal(network, addr string) (net.Listener, error) {
+	if network == "unix" {
+		if fi, err := os.Lstat(addr); err == nil && fi.Mode().Type() == os.ModeSocket {
+			_ = os.Remove(addr)
+		}
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if ul, ok := l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(true)
+	}
+	return l, nil
+}
+
+// remoteForward 请求控制端在 agent 上监听 f.listen 并连接 f.target, ctx 结束时 agent 停止监听.
+// 监听结束时将错误发送到 errCh
+func remoteForward(ctx context.Context, cli apiController.RemoteForwardClient, agentID string, f forwardSpec, errCh chan<- error) error {
+	stream, err := cli.Forward(ctx, &apiController.RemoteForwardRequest{
+		Agent:         agentID,
+		ListenNetwork: f.listenNet,
+		ListenAddress: f.listen,
+		TargetNetwork: f.targetNet,
+		TargetAddress: f.target,
+	})
+	if err != nil {
+		return err
+	}
+	event, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "forwarding %s on %s -> %s\n", event.GetAddress(), agentID, f.target)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				errCh <- err
+				return
+			}
+			fmt.Fprintf(os.Stderr, "forward %s: %s\n", f.target, event.GetError())
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// command tianmen 的子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{name: "agents", usage: "列出在线的 agent", run: agents},
+	{name: "forward", usage: "经 agent 转发端口, -L 本地转发, -R 远程转发", run: forward},
+	{name: "socks", usage: "启动经 agent 连接目标的 SOCKS5 代理", run: socksProxy},
+	{name: "cp", usage: "在本地和 agent 之间复制文件, agent 上的路径写作 <agent>:<path>", run: cp},
+	{name: "sync", usage: "将本地目录同步到一个或多个 agent, 只传输变化的部分", run: syncDir},
+	{name: "replay", usage: "回放 asciinema v2 格式的会话录像", run: replay},
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, "usage: tianmen <command> [flags] [args]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.usage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name != os.Args[1] {
+			continue
+		}
+		err := c.run(os.Args[2:])
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+	usage()
+	os.Exit(2)
+}
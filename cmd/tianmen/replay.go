@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+
+	"github.com/lyp256/tianmen/pkg/record"
+)
+
+func replay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	speed := fs.Float64("speed", 1, "回放速度倍数")
+	idleLimit := fs.Duration("idle-limit", 0, "事件之间的最长等待时间, 0 表示不限制")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: tianmen replay [flags] FILE")
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := record.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return record.Play(ctx, r, os.Stdout, record.PlayOptions{
+		Speed:     *speed,
+		IdleLimit: *idleLimit,
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/lyp256/tianmen/pkg/config"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+// defaultServer 控制端运维接口的默认地址
+const defaultServer = "unix:///run/tianmen/operator.sock"
+
+// serverFlags 连接控制端运维接口的参数
+type serverFlags struct {
+	addr string
+	tls  config.TLS
+}
+
+func (f *serverFlags) register(fs *flag.FlagSet) {
+	addr := os.Getenv("TIANMEN_SERVER")
+	if addr == "" {
+		addr = defaultServer
+	}
+	fs.StringVar(&f.addr, "server", addr, "控制端运维接口地址, 默认读取环境变量 TIANMEN_SERVER")
+	fs.StringVar(&f.tls.CA, "ca", "", "校验控制端证书的 CA, 为空时不使用 TLS")
+	fs.StringVar(&f.tls.Cert, "cert", "", "客户端证书")
+	fs.StringVar(&f.tls.Key, "key", "", "客户端私钥")
+}
+
+// dial 连接控制端运维接口
+func (f *serverFlags) dial() (*grpc.ClientConn, error) {
+	opt := mux.InsecureClient()
+	if f.tls.CA != "" {
+		conf, err := f.tls.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		conf.NextProtos = []string{"h2"}
+		opt = grpc.WithTransportCredentials(credentials.NewTLS(conf))
+	}
+	return grpc.NewClient(f.addr, opt)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lyp256/tianmen/pkg/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+	"github.com/lyp256/tianmen/pkg/socks"
+)
+
+// defaultSocksListen socks 命令的默认监听地址
+const defaultSocksListen = "127.0.0.1:1080"
+
+func socksProxy(args []string) error {
+	fs := flag.NewFlagSet("socks", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "usage: tianmen socks -agent <agent> [listen_address]\n")
+		fs.PrintDefaults()
+	}
+	server := &serverFlags{}
+	server.register(fs)
+	agentID := fs.String("agent", "", "经该 agent 连接目标")
+	auth := fs.String("auth", "", "user:password, 不为空时要求客户端认证")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *agentID == "" || fs.NArg() > 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	listen := defaultSocksListen
+	if fs.NArg() == 1 {
+		listen = fs.Arg(0)
+	}
+
+	conn, err := server.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	cli := core.NewForwardClient(controller.AgentClientConn(conn, *agentID))
+	s := &socks.Server{
+		Dialer:    serviceCore.ForwardDialer{Client: cli},
+		UDPDialer: serviceCore.ForwardDialer{Client: cli, Network: "udp"},
+		ErrorLog: func(remote net.Addr, err error) {
+			fmt.Fprintf(os.Stderr, "socks %s: %v\n", remote, err)
+		},
+	}
+	if *auth != "" {
+		user, password, ok := strings.Cut(*auth, ":")
+		if !ok {
+			return fmt.Errorf("invalid auth %q, want user:password", *auth)
+		}
+		s.Auth = func(u, p string) bool {
+			return subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1 &&
+				subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+		}
+	}
+
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "socks5 proxy on %s via %s\n", l.Addr(), *agentID)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	return s.Serve(l)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lyp256/tianmen/pkg/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+)
+
+// syncActionName SyncAction 的简短名称
+func syncActionName(a core.SyncAction) string {
+	return strings.ToLower(strings.TrimPrefix(a.String(), "SYNC_ACTION_"))
+}
+
+func syncDir(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "usage: tianmen sync [-delete] [-p] [-v] <local_dir> <agent>:<absolute_dir>...\n")
+		fs.PrintDefaults()
+	}
+	server := &serverFlags{}
+	server.register(fs)
+	del := fs.Bool("delete", false, "删除 agent 目录中本地不存在的文件和目录")
+	owner := fs.Bool("p", false, "保留文件属主, 通常需要 root 权限")
+	verbose := fs.Bool("v", false, "同时列出未变化的路径")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	src := fs.Arg(0)
+	type target struct{ agentID, dir string }
+	var targets []target
+	for _, arg := range fs.Args()[1:] {
+		agentID, dir, ok := splitAgentPath(arg)
+		if !ok {
+			return fmt.Errorf("invalid destination %q, want <agent>:<path>", arg)
+		}
+		targets = append(targets, target{agentID: agentID, dir: dir})
+	}
+
+	conn, err := server.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opt := serviceCore.SyncOptions{Delete: *del, Owner: *owner}
+	failed := 0
+	for _, t := range targets {
+		cli := core.NewFileTransferClient(controller.AgentClientConn(conn, t.agentID))
+		results, err := serviceCore.SyncDir(ctx, cli, src, t.dir, opt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%s: %v\n", t.agentID, t.dir, err)
+			failed++
+			continue
+		}
+		counts := make(map[core.SyncAction]int)
+		var literal, matched int64
+		for _, res := range results {
+			counts[res.GetAction()]++
+			literal += res.GetLiteral()
+			matched += res.GetMatched()
+			switch {
+			case res.GetAction() == core.SyncAction_SYNC_ACTION_FAILED:
+				fmt.Printf("%s:%s  %-9s %s: %s\n", t.agentID, t.dir, syncActionName(res.GetAction()), res.GetPath(), res.GetError())
+			case res.GetAction() != core.SyncAction_SYNC_ACTION_UNCHANGED || *verbose:
+				fmt.Printf("%s:%s  %-9s %s\n", t.agentID, t.dir, syncActionName(res.GetAction()), res.GetPath())
+			}
+		}
+		fmt.Printf("%s:%s  %d created, %d updated, %d attrs, %d deleted, %d unchanged, %d failed, sent %d bytes, matched %d bytes\n",
+			t.agentID, t.dir,
+			counts[core.SyncAction_SYNC_ACTION_CREATED], counts[core.SyncAction_SYNC_ACTION_UPDATED],
+			counts[core.SyncAction_SYNC_ACTION_ATTRS], counts[core.SyncAction_SYNC_ACTION_DELETED],
+			counts[core.SyncAction_SYNC_ACTION_UNCHANGED], counts[core.SyncAction_SYNC_ACTION_FAILED],
+			literal, matched)
+		if counts[core.SyncAction_SYNC_ACTION_FAILED] > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d destinations failed", failed, len(targets))
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ require (
 	github.com/quic-go/quic-go v0.53.0
 	github.com/stretchr/testify v1.10.0
 	github.com/xtaci/smux v1.5.34
+	golang.org/x/net v0.41.0
+	golang.org/x/sys v0.34.0
 	golang.org/x/term v0.33.0
 	google.golang.org/grpc v1.73.0
 	google.golang.org/protobuf v1.36.6
@@ -21,9 +23,7 @@ require (
 	go.uber.org/mock v0.5.0 // indirect
 	golang.org/x/crypto v0.40.0 // indirect
 	golang.org/x/mod v0.25.0 // indirect
-	golang.org/x/net v0.41.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
-	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/text v0.27.0 // indirect
 	golang.org/x/tools v0.34.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
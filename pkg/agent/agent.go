@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+)
+
+// Agent 主动连接控制端, 并在该连接上提供 gRPC 服务
+type Agent struct {
+	// Servers 控制端地址, 连接失败时依次尝试下一个
+	Servers []string
+	// Transport 传输协议, TransportTLS、TransportQUIC 或 TransportWebSocket
+	Transport string
+	// Dialer 使用 TransportTLS 和 TransportWebSocket 时建立 TCP 连接, 为空时直接连接.
+	// 使用 mux.ProxyDialer 或 mux.EnvironmentDialer 经代理连接控制端, QUIC 不支持代理
+	Dialer    mux.Dialer
+	TLSConfig *tls.Config
+	// ShutdownTimeout 退出时等待 RPC 结束的最长时间, 超时后强制关闭
+	ShutdownTimeout time.Duration
+	// Labels 注册时上报给控制端的标签
+	Labels map[string]string
+	Shell  *serviceCore.Server
+	// Forward 不为空时提供端口转发服务
+	Forward *serviceCore.ForwardServer
+	// Files 不为空时提供文件传输服务
+	Files *serviceCore.FileServer
+	// Backoff 连接断开或失败后的重连间隔
+	Backoff Backoff
+	// OnStateChange 不为空时在连接状态变化时调用
+	OnStateChange func(StateChange)
+	// KeepAlive 检测控制端是否存活, 心跳失败时断开并重连
+	KeepAlive mux.KeepAlive
+	// SMuxOptions 使用 TransportTLS 和 TransportWebSocket 时的 smux 会话参数
+	SMuxOptions []mux.SMuxOption
+	// QUIC 使用 TransportQUIC 时的连接参数, 启用 0-RTT 时重连只需要一个往返,
+	// 未设置 SessionCache 时使用 agent 自己的会话缓存
+	QUIC mux.QUICOptions
+
+	instanceOnce sync.Once
+	instanceID   string
+	sessionOnce  sync.Once
+	sessionCache tls.ClientSessionCache
+	statsMu      sync.Mutex
+	stats        ConnStats
+	tunnel       mux.Tunnel
+	cc           *grpc.ClientConn
+}
+
+// registerTimeout 等待 Register 返回的最长时间
+const registerTimeout = 10 * time.Second
+
+// serve 连接 server 并提供服务, 直到 ctx 结束或连接断开, ctx 结束时返回 nil
+func (a *Agent) serve(ctx context.Context, server string) error {
+	tunnel, err := a.dial(ctx, server)
+	if err != nil {
+		return err
+	}
+	a.setTunnel(tunnel)
+	defer a.setTunnel(nil)
+	session := mux.NewSession(tunnel)
+	gs := a.newServer()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- gs.Serve(session)
+	}()
+	cc, err := a.clientConn(session)
+	if err == nil {
+		defer cc.Close()
+		err = a.register(ctx, cc, gs)
+	}
+	if err != nil {
+		gs.Stop()
+		<-serveErr
+		return err
+	}
+	a.setConn(cc)
+	defer a.setConn(nil)
+	a.setState(StateConnected, server, nil)
+
+	heartbeatErr := make(chan error, 1)
+	if a.KeepAlive.Enabled() {
+		hbCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			heartbeatErr <- a.KeepAlive.Heartbeat(hbCtx, cc)
+		}()
+	}
+	select {
+	case err = <-serveErr:
+		if err == nil {
+			err = errors.New("connection closed")
+		}
+		return err
+	case err = <-heartbeatErr:
+		if err == nil {
+			// ctx 已结束, 与下面的分支相同
+			a.shutdown(gs)
+			<-serveErr
+			return nil
+		}
+		gs.Stop()
+		<-serveErr
+		return err
+	case <-ctx.Done():
+		a.shutdown(gs)
+		<-serveErr
+		return nil
+	}
+}
+
+// dial 连接控制端, 返回该连接上的 Tunnel
+func (a *Agent) dial(ctx context.Context, server string) (mux.Tunnel, error) {
+	switch a.Transport {
+	case TransportQUIC:
+		opts := a.QUIC.WithKeepAlive(a.KeepAlive)
+		if opts.SessionCache == nil && (a.TLSConfig == nil || a.TLSConfig.ClientSessionCache == nil) {
+			opts.SessionCache = a.quicSessionCache()
+		}
+		conn, err := mux.DialQUIC(ctx, server, a.TLSConfig, opts)
+		if err != nil {
+			return nil, err
+		}
+		return mux.NewQUICTunnel(conn), nil
+	case TransportTLS, "":
+		conn, err := a.dialTLS(ctx, server)
+		if err != nil {
+			return nil, err
+		}
+		t, err := mux.NewSMuxTunnel(conn, true, a.SMuxOptions...)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return t, nil
+	case TransportWebSocket:
+		return mux.DialWebSocketTunnel(ctx, server, a.TLSConfig, a.Dialer, a.SMuxOptions...)
+	default:
+		return nil, errors.New("unsupported transport: " + a.Transport)
+	}
+}
+
+// quicSessionCache 返回 agent 重连时复用的 QUIC 会话缓存
+func (a *Agent) quicSessionCache() tls.ClientSessionCache {
+	a.sessionOnce.Do(func() {
+		a.sessionCache = tls.NewLRUClientSessionCache(0)
+	})
+	return a.sessionCache
+}
+
+// dialTLS 使用 Dialer 连接 server 并完成 TLS 握手
+func (a *Agent) dialTLS(ctx context.Context, server string) (net.Conn, error) {
+	var dialer mux.Dialer = &net.Dialer{}
+	if a.Dialer != nil {
+		dialer = a.Dialer
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	conf := &tls.Config{}
+	if a.TLSConfig != nil {
+		conf = a.TLSConfig.Clone()
+	}
+	if conf.ServerName == "" {
+		host, _, err := net.SplitHostPort(server)
+		if err != nil {
+			host = server
+		}
+		conf.ServerName = host
+	}
+	tlsConn := tls.Client(conn, conf)
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// clientConn 在会话上创建调用控制端服务的 gRPC 连接
+func (a *Agent) clientConn(session mux.Session) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{mux.InsecureClient()}
+	if a.KeepAlive.Enabled() {
+		opts = append(opts, a.KeepAlive.DialOption())
+	}
+	return mux.NewClientConn(session, opts...)
+}
+
+// register 调用控制端的 Register
+func (a *Agent) register(ctx context.Context, cc *grpc.ClientConn, gs *grpc.Server) error {
+	ctx, cancel := context.WithTimeout(ctx, registerTimeout)
+	defer cancel()
+	_, err := controller.NewAgentClient(cc).Register(ctx, &controller.RegisterRequest{Meta: a.meta(gs)})
+	if err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+	return nil
+}
+
+func (a *Agent) newServer() *grpc.Server {
+	var opts []grpc.ServerOption
+	if a.KeepAlive.Enabled() {
+		opts = a.KeepAlive.ServerOptions()
+	}
+	gs := grpc.NewServer(opts...)
+	if a.Shell != nil {
+		core.RegisterShellServer(gs, a.Shell)
+	}
+	if a.Forward != nil {
+		core.RegisterForwardServer(gs, a.Forward)
+	}
+	if a.Files != nil {
+		core.RegisterFileTransferServer(gs, a.Files)
+	}
+	healthpb.RegisterHealthServer(gs, health.NewServer())
+	return gs
+}
+
+// shutdown 等待正在处理的 RPC 结束, 超过 ShutdownTimeout 后强制关闭
+func (a *Agent) shutdown(gs *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+	timer := time.NewTimer(a.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		gs.Stop()
+	}
+}
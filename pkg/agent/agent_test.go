@@ -0,0 +1,297 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+func runEcho(t *testing.T, conn *grpc.ClientConn) {
+	stream, err := core.NewShellClient(conn).Shell(context.Background())
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "echo hello"},
+			DisablePty: true,
+		}},
+	})
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	status, err := serviceCore.StreamOutput(stream, out, out)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, status.GetCode())
+	require.Equal(t, "hello\n", out.String())
+}
+
+// registerServer 记录 agent 注册信息的 controller.Agent 服务
+type registerServer struct {
+	controller.UnimplementedAgentServer
+	meta chan *controller.AgentMeta
+}
+
+func (s *registerServer) Register(_ context.Context, req *controller.RegisterRequest) (*controller.RegisterResponse, error) {
+	s.meta <- req.GetMeta()
+	return &controller.RegisterResponse{Id: "agent"}, nil
+}
+
+// acceptAgent 在会话上提供 controller.Agent 服务, 等待 agent 注册后返回到 agent 的连接
+func acceptAgent(t *testing.T, session mux.Session) (*grpc.ClientConn, *controller.AgentMeta) {
+	reg := &registerServer{meta: make(chan *controller.AgentMeta, 1)}
+	gs := grpc.NewServer()
+	controller.RegisterAgentServer(gs, reg)
+	go func() { _ = gs.Serve(session) }()
+	t.Cleanup(gs.Stop)
+
+	var meta *controller.AgentMeta
+	select {
+	case meta = <-reg.meta:
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not register")
+	}
+	cliConn, err := mux.NewClientConn(session, mux.InsecureClient())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cliConn.Close() })
+	return cliConn, meta
+}
+
+func startAgent(t *testing.T, a *Agent) (context.CancelFunc, <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.Run(ctx)
+	}()
+	t.Cleanup(cancel)
+	return cancel, runErr
+}
+
+func TestAgentTLS(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+
+	a := &Agent{
+		Servers:         []string{addr.String()},
+		Transport:       TransportTLS,
+		TLSConfig:       cConf,
+		ShutdownTimeout: time.Second,
+		Labels:          map[string]string{"env": "test"},
+		Shell:           &serviceCore.Server{},
+		Backoff:         Backoff{Initial: 10 * time.Millisecond},
+	}
+	cancel, runErr := startAgent(t, a)
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	tunnel, err := mux.NewSMuxTunnel(conn, false)
+	require.NoError(t, err)
+	cliConn, meta := acceptAgent(t, mux.NewSession(tunnel))
+	require.Equal(t, runtime.GOOS, meta.GetOS())
+	require.Equal(t, Version, meta.GetVersion())
+	require.Equal(t, "test", meta.GetLabels()["env"])
+	require.Contains(t, meta.GetServices(), core.Shell_ServiceDesc.ServiceName)
+	runEcho(t, cliConn)
+
+	// 连接断开后使用相同的 InstanceId 重连
+	require.NoError(t, conn.Close())
+	conn, err = l.Accept()
+	require.NoError(t, err)
+	tunnel, err = mux.NewSMuxTunnel(conn, false)
+	require.NoError(t, err)
+	cliConn, reconnected := acceptAgent(t, mux.NewSession(tunnel))
+	require.Equal(t, meta.GetInstanceId(), reconnected.GetInstanceId())
+	runEcho(t, cliConn)
+	stats := a.Stats()
+	require.Equal(t, StateConnected, stats.State)
+	require.EqualValues(t, 2, stats.Connects)
+	require.Error(t, stats.LastError)
+	// Shell 调用使用控制端打开的 stream
+	require.NotZero(t, stats.Tunnel.AcceptedStreams)
+	require.NotZero(t, stats.Tunnel.BytesReceived)
+
+	cancel()
+	select {
+	case err = <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not exit")
+	}
+	require.Equal(t, StateStopped, a.Stats().State)
+}
+
+func TestAgentRotateServers(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	// 没有监听的地址
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+
+	changes := make(chan StateChange, 16)
+	startAgent(t, &Agent{
+		Servers:         []string{closed.Addr().String(), addr.String()},
+		Transport:       TransportTLS,
+		TLSConfig:       cConf,
+		ShutdownTimeout: time.Second,
+		Backoff:         Backoff{Initial: 10 * time.Millisecond},
+		OnStateChange: func(c StateChange) {
+			changes <- c
+		},
+	})
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	tunnel, err := mux.NewSMuxTunnel(conn, false)
+	require.NoError(t, err)
+	acceptAgent(t, mux.NewSession(tunnel))
+
+	var states []ConnState
+	for c := range changes {
+		states = append(states, c.State)
+		if c.State == StateDisconnected {
+			require.Equal(t, closed.Addr().String(), c.Server)
+			require.Error(t, c.Err)
+		}
+		if c.State == StateConnected {
+			require.Equal(t, addr.String(), c.Server)
+			break
+		}
+	}
+	require.Equal(t, []ConnState{StateConnecting, StateDisconnected, StateConnecting, StateConnected}, states)
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Jitter: 0.1}
+	for i := 0; i < 100; i++ {
+		d := b.Delay(0)
+		require.GreaterOrEqual(t, d, 90*time.Millisecond)
+		require.LessOrEqual(t, d, 110*time.Millisecond)
+		d = b.Delay(2)
+		require.GreaterOrEqual(t, d, 360*time.Millisecond)
+		require.LessOrEqual(t, d, 440*time.Millisecond)
+		require.LessOrEqual(t, b.Delay(10), time.Second)
+		require.GreaterOrEqual(t, b.Delay(10), 900*time.Millisecond)
+	}
+}
+
+func TestBackoffReset(t *testing.T) {
+	a := &Agent{Backoff: Backoff{Reset: time.Hour}}
+	a.setState(StateConnecting, "a", nil)
+	require.Equal(t, 0, a.disconnected("a", nil))
+	// 连接成功后很快断开, 失败次数继续累加
+	a.setState(StateConnected, "a", nil)
+	require.Equal(t, 1, a.disconnected("a", nil))
+	require.Equal(t, 2, a.Stats().Failures)
+
+	a.Backoff.Reset = time.Millisecond
+	a.setState(StateConnected, "a", nil)
+	time.Sleep(2 * time.Millisecond)
+	require.Equal(t, 0, a.disconnected("a", nil))
+	require.Equal(t, 1, a.Stats().Failures)
+}
+
+func TestAgentQUICShutdown(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr, err := testutil.RandomLocalListenQUIC()
+	require.NoError(t, err)
+	defer l.Close()
+
+	cancel, runErr := startAgent(t, &Agent{
+		Servers:         []string{addr.String()},
+		Transport:       TransportQUIC,
+		TLSConfig:       cConf,
+		ShutdownTimeout: time.Second,
+		Shell:           &serviceCore.Server{},
+	})
+	conn, err := l.Accept(context.Background())
+	require.NoError(t, err)
+	cliConn, _ := acceptAgent(t, mux.NewSession(mux.NewQUICTunnel(conn)))
+	runEcho(t, cliConn)
+
+	cancel()
+	select {
+	case err = <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not exit")
+	}
+}
+
+func TestAgentHeartbeat(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+
+	a := &Agent{
+		Servers:         []string{addr.String()},
+		Transport:       TransportTLS,
+		TLSConfig:       cConf,
+		ShutdownTimeout: time.Second,
+		Backoff:         Backoff{Initial: 10 * time.Millisecond},
+		KeepAlive:       mux.KeepAlive{Interval: 20 * time.Millisecond, Timeout: 20 * time.Millisecond, MaxFailures: 2},
+	}
+	startAgent(t, a)
+	// acceptAgent 没有提供 health 服务, 心跳失败后 agent 重连
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	tunnel, err := mux.NewSMuxTunnel(conn, false)
+	require.NoError(t, err)
+	acceptAgent(t, mux.NewSession(tunnel))
+
+	conn, err = l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	require.ErrorContains(t, a.Stats().LastError, "heartbeat failed")
+}
+
+// countDialer 记录连接的地址
+type countDialer struct {
+	net.Dialer
+	addrs chan string
+}
+
+func (d *countDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.addrs <- addr
+	return d.Dialer.DialContext(ctx, network, addr)
+}
+
+func TestAgentDialer(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+
+	dialer := &countDialer{addrs: make(chan string, 1)}
+	a := &Agent{
+		Servers:         []string{addr.String()},
+		Transport:       TransportTLS,
+		Dialer:          dialer,
+		TLSConfig:       cConf,
+		ShutdownTimeout: time.Second,
+		Shell:           &serviceCore.Server{},
+	}
+	startAgent(t, a)
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	tunnel, err := mux.NewSMuxTunnel(conn, false)
+	require.NoError(t, err)
+	cliConn, _ := acceptAgent(t, mux.NewSession(tunnel))
+	runEcho(t, cliConn)
+	require.Equal(t, addr.String(), <-dialer.addrs)
+}
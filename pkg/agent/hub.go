@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+)
+
+// ErrNotConnected 未连接到控制端
+var ErrNotConnected = errors.New("not connected to controller")
+
+// setConn 记录调用控制端服务的连接, 断开时设置为 nil
+func (a *Agent) setConn(cc *grpc.ClientConn) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	a.cc = cc
+}
+
+// Conn 返回当前调用控制端服务的 gRPC 连接, 未连接时返回 ErrNotConnected
+func (a *Agent) Conn() (*grpc.ClientConn, error) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	if a.cc == nil {
+		return nil, ErrNotConnected
+	}
+	return a.cc, nil
+}
+
+// PullConfig 拉取控制端下发的配置, version 为当前配置的版本, 未变化时 NotModified 为 true
+func (a *Agent) PullConfig(ctx context.Context, version string) (*controller.PullConfigResponse, error) {
+	cc, err := a.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return controller.NewConfigClient(cc).Pull(ctx, &controller.PullConfigRequest{Version: version})
+}
+
+// DownloadArtifact 下载制品到 path. 数据先写入 path.part, 已存在时从其末尾继续下载,
+// path.part 比制品长时重新下载, 校验 SHA-256 后重命名为 path
+func (a *Agent) DownloadArtifact(ctx context.Context, name, path string) error {
+	cc, err := a.Conn()
+	if err != nil {
+		return err
+	}
+	part := path + ".part"
+	f, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	offset, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	client := controller.NewArtifactsClient(cc)
+	download := func(offset int64) (grpc.ServerStreamingClient[controller.ArtifactChunk], *controller.ArtifactChunk, error) {
+		stream, err := client.Download(ctx, &controller.DownloadArtifactRequest{
+			Name:   name,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		first, err := stream.Recv()
+		return stream, first, err
+	}
+	stream, first, err := download(offset)
+	if status.Code(err) == codes.OutOfRange && offset > 0 {
+		// 制品已被替换为更小的文件, 丢弃已下载的部分
+		err = f.Truncate(0)
+		if err != nil {
+			return err
+		}
+		_, err = f.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		h.Reset()
+		stream, first, err = download(0)
+	}
+	if err != nil {
+		return err
+	}
+	chunk := first
+	for {
+		_, err = f.Write(chunk.GetData())
+		if err != nil {
+			return err
+		}
+		_, _ = h.Write(chunk.GetData())
+		chunk, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if sum != first.GetSha256() {
+		_ = os.Remove(part)
+		return fmt.Errorf("artifact %s: sha256 mismatch: got %s, want %s", name, sum, first.GetSha256())
+	}
+	err = f.Chmod(os.FileMode(first.GetMode()).Perm())
+	if err != nil {
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(part, path)
+}
+
+// UploadLogs 上传日志到控制端
+func (a *Agent) UploadLogs(ctx context.Context, records ...*controller.LogRecord) error {
+	cc, err := a.Conn()
+	if err != nil {
+		return err
+	}
+	stream, err := controller.NewLogsClient(cc).Upload(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		err = stream.Send(r)
+		if err != nil {
+			break
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if res.GetCount() != int64(len(records)) {
+		return fmt.Errorf("upload logs: controller received %d of %d records", res.GetCount(), len(records))
+	}
+	return nil
+}
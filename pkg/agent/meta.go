@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+)
+
+// Version agent 版本, 编译时通过 -ldflags "-X github.com/lyp256/tianmen/pkg/agent.Version=v1.0.0" 设置
+var Version = "dev"
+
+const bootIDPath = "/proc/sys/kernel/random/boot_id"
+
+// meta 收集注册时上报的信息, 获取失败的字段留空
+func (a *Agent) meta(gs *grpc.Server) *controller.AgentMeta {
+	m := &controller.AgentMeta{
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Version: Version,
+		Labels:  a.Labels,
+		// 重连时上报相同的 InstanceId
+		InstanceId: a.InstanceID(),
+	}
+	m.Hostname, _ = os.Hostname()
+	var uts unix.Utsname
+	if unix.Uname(&uts) == nil {
+		m.Kernel = unix.ByteSliceToString(uts.Release[:])
+	}
+	if b, err := os.ReadFile(bootIDPath); err == nil {
+		m.BootId = strings.TrimSpace(string(b))
+	}
+	for name := range gs.GetServiceInfo() {
+		m.Services = append(m.Services, name)
+	}
+	sort.Strings(m.Services)
+	return m
+}
@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	mathrand "math/rand/v2"
+	"time"
+
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+// ConnState agent 与控制端的连接状态
+type ConnState int
+
+const (
+	// StateConnecting 正在连接
+	StateConnecting ConnState = iota
+	// StateConnected 已连接并完成注册
+	StateConnected
+	// StateDisconnected 连接失败或断开, 等待重连
+	StateDisconnected
+	// StateStopped Run 已退出
+	StateStopped
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChange 连接状态变化
+type StateChange struct {
+	State  ConnState
+	Server string
+	// Err 连接失败或断开的原因
+	Err error
+	// Retry 状态为 StateDisconnected 时距离下次重连的时间
+	Retry time.Duration
+}
+
+// ConnStats 连接统计
+type ConnStats struct {
+	State  ConnState
+	Server string
+	// Since 进入当前状态的时间
+	Since time.Time
+	// Attempts 累计连接次数
+	Attempts int64
+	// Connects 累计成功连接次数
+	Connects int64
+	// Failures 连续失败次数, 连接保持 Backoff.Reset 以上后断开时清零
+	Failures  int
+	LastError error
+	// Tunnel 当前连接的 stream 和流量统计, 未连接时为空
+	Tunnel mux.TunnelStats
+}
+
+// Backoff 重连间隔, 按指数增长到 Max, 并加入随机抖动
+type Backoff struct {
+	// Initial 第一次重连前的等待时间, 默认 1s
+	Initial time.Duration
+	// Max 最长等待时间, 默认 1m
+	Max time.Duration
+	// Multiplier 每次失败后的增长倍数, 默认 2
+	Multiplier float64
+	// Jitter 随机抖动的比例, 取值 0~1, 默认 0.2
+	Jitter float64
+	// Reset 连接保持多久后断开时重新从 Initial 开始等待, 默认 1m.
+	// 连接成功后很快断开时继续增长, 避免控制端异常时 agent 频繁重连
+	Reset time.Duration
+}
+
+// stable 连接保持 uptime 后断开时是否重置失败次数
+func (b Backoff) stable(uptime time.Duration) bool {
+	reset := b.Reset
+	if reset <= 0 {
+		reset = time.Minute
+	}
+	return uptime >= reset
+}
+
+// Delay 返回连续失败 failures 次后的等待时间
+func (b Backoff) Delay(failures int) time.Duration {
+	initial, maxDelay, multiplier, jitter := b.Initial, b.Max, b.Multiplier, b.Jitter
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if maxDelay <= 0 {
+		maxDelay = time.Minute
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	if jitter <= 0 || jitter > 1 {
+		jitter = 0.2
+	}
+	d := float64(initial) * math.Pow(multiplier, float64(failures))
+	d = math.Min(d, float64(maxDelay))
+	d += d * jitter * (2*mathrand.Float64() - 1)
+	return time.Duration(math.Min(d, float64(maxDelay)))
+}
+
+// Run 连接控制端并提供服务, 连接失败或断开后按 Backoff 重连,
+// 依次尝试 Servers 中的地址, 直到 ctx 结束
+func (a *Agent) Run(ctx context.Context) error {
+	if len(a.Servers) == 0 {
+		return errors.New("no server")
+	}
+	defer a.setState(StateStopped, "", nil)
+	i := 0
+	for {
+		server := a.Servers[i]
+		a.setState(StateConnecting, server, nil)
+		err := a.serve(ctx, server)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// 未能建立连接时切换到下一个地址, 已连接过的地址断开后优先重连
+		if a.Stats().State != StateConnected {
+			i = (i + 1) % len(a.Servers)
+		}
+		retry := a.Backoff.Delay(a.disconnected(server, err))
+		a.notify(StateChange{State: StateDisconnected, Server: server, Err: err, Retry: retry})
+
+		timer := time.NewTimer(retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// InstanceID agent 进程的标识, 重连时保持不变, 控制端据此区分重连和新的进程
+func (a *Agent) InstanceID() string {
+	a.instanceOnce.Do(func() {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		a.instanceID = hex.EncodeToString(b)
+	})
+	return a.instanceID
+}
+
+// Stats 返回连接统计
+func (a *Agent) Stats() ConnStats {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	stats := a.stats
+	if a.tunnel != nil {
+		stats.Tunnel = a.tunnel.Stats()
+	}
+	return stats
+}
+
+// setTunnel 记录当前连接, 用于 Stats
+func (a *Agent) setTunnel(t mux.Tunnel) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	a.tunnel = t
+}
+
+func (a *Agent) setState(state ConnState, server string, err error) {
+	a.statsMu.Lock()
+	a.stats.State = state
+	a.stats.Server = server
+	a.stats.Since = time.Now()
+	switch state {
+	case StateConnecting:
+		a.stats.Attempts++
+	case StateConnected:
+		a.stats.Connects++
+	}
+	a.statsMu.Unlock()
+	a.notify(StateChange{State: state, Server: server, Err: err})
+}
+
+// disconnected 记录连接失败或断开, 返回连续失败次数
+func (a *Agent) disconnected(server string, err error) int {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	if a.stats.State == StateConnected && a.Backoff.stable(time.Since(a.stats.Since)) {
+		a.stats.Failures = 0
+	}
+	failures := a.stats.Failures
+	a.stats.State = StateDisconnected
+	a.stats.Server = server
+	a.stats.Since = time.Now()
+	a.stats.LastError = err
+	a.stats.Failures++
+	return failures
+}
+
+func (a *Agent) notify(c StateChange) {
+	if a.OnStateChange != nil {
+		a.OnStateChange(c)
+	}
+}
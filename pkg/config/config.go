@@ -0,0 +1,146 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// NextProto agent 与控制端之间 TLS/QUIC 使用的 ALPN 协议
+const NextProto = "tianmen"
+
+// Load 读取 JSON 格式的配置文件
+func Load(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Duration 支持 "10s"、"1m30s" 格式的时长
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// KeepAlive 心跳配置
+type KeepAlive struct {
+	// Interval 心跳间隔, 默认 30s, 为负数时不启用
+	Interval Duration `json:"interval"`
+	// Timeout 等待心跳响应的最长时间, 默认 10s
+	Timeout Duration `json:"timeout"`
+	// MaxFailures 连续失败多少次后断开连接, 默认 3
+	MaxFailures int `json:"max_failures"`
+}
+
+// SMux smux 会话参数, 为 0 的字段使用默认值
+type SMux struct {
+	MaxReceiveBuffer  int      `json:"max_receive_buffer"`
+	MaxStreamBuffer   int      `json:"max_stream_buffer"`
+	MaxFrameSize      int      `json:"max_frame_size"`
+	KeepAliveInterval Duration `json:"keepalive_interval"`
+	KeepAliveTimeout  Duration `json:"keepalive_timeout"`
+}
+
+// QUIC QUIC 连接参数, 为 0 的字段使用默认值, 见 mux.QUICOptions
+type QUIC struct {
+	HandshakeIdleTimeout           Duration `json:"handshake_idle_timeout"`
+	MaxIdleTimeout                 Duration `json:"max_idle_timeout"`
+	KeepAlivePeriod                Duration `json:"keepalive_period"`
+	MaxIncomingStreams             int64    `json:"max_incoming_streams"`
+	InitialStreamReceiveWindow     uint64   `json:"initial_stream_receive_window"`
+	MaxStreamReceiveWindow         uint64   `json:"max_stream_receive_window"`
+	InitialConnectionReceiveWindow uint64   `json:"initial_connection_receive_window"`
+	MaxConnectionReceiveWindow     uint64   `json:"max_connection_receive_window"`
+	EnableDatagrams                bool     `json:"enable_datagrams"`
+	Enable0RTT                     bool     `json:"enable_0rtt"`
+}
+
+// TLS 证书配置, 文件均为 PEM 格式
+type TLS struct {
+	// CA 校验对端证书的 CA, 为空时客户端使用系统证书
+	CA   string `json:"ca"`
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	// ServerName 客户端校验服务端证书使用的名称, 为空时使用连接地址
+	ServerName string `json:"server_name"`
+}
+
+func (t TLS) load() (*tls.Config, error) {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		NextProtos: []string{NextProto},
+		ServerName: t.ServerName,
+	}
+	if t.Cert != "" || t.Key != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, err
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	if t.CA != "" {
+		b, err := os.ReadFile(t.CA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificate found in %s", t.CA)
+		}
+		conf.RootCAs = pool
+		conf.ClientCAs = pool
+	}
+	return conf, nil
+}
+
+// ClientConfig 客户端 TLS 配置
+func (t TLS) ClientConfig() (*tls.Config, error) {
+	conf, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	conf.ClientCAs = nil
+	return conf, nil
+}
+
+// ServerConfig 服务端 TLS 配置, 要求客户端提供由 CA 签发的证书
+func (t TLS) ServerConfig() (*tls.Config, error) {
+	if t.CA == "" {
+		return nil, errors.New("tls: ca is required to verify client certificates")
+	}
+	if len(t.Cert) == 0 {
+		return nil, errors.New("tls: cert is required")
+	}
+	conf, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	conf.RootCAs = nil
+	conf.ClientAuth = tls.RequireAndVerifyClientCert
+	return conf, nil
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+)
+
+// agentServer 在单个 agent 连接上提供的 controller.Agent 服务
+type agentServer struct {
+	controller.UnimplementedAgentServer
+	agent    *AgentConn
+	registry *Registry
+	once     *sync.Once
+	done     chan struct{}
+}
+
+// Register 记录 agent 信息并加入 Registry, 重复调用时更新信息
+func (s *agentServer) Register(_ context.Context, req *controller.RegisterRequest) (*controller.RegisterResponse, error) {
+	if req.GetMeta() == nil {
+		return nil, status.Error(codes.InvalidArgument, "meta is required")
+	}
+	s.agent.setMeta(req.GetMeta())
+	s.once.Do(func() {
+		s.registry.add(s.agent)
+		close(s.done)
+	})
+	return &controller.RegisterResponse{Id: s.agent.ID}, nil
+}
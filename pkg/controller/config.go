@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lyp256/tianmen/pkg/config"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+// Config 控制端配置文件
+type Config struct {
+	// ListenTLS 接收 agent TLS 连接的地址, 为空时不监听
+	ListenTLS string `json:"listen_tls"`
+	// ListenQUIC 接收 agent QUIC 连接的地址, 为空时不监听
+	ListenQUIC string `json:"listen_quic"`
+	// ListenWebSocket 接收 agent WebSocket 连接的 HTTPS 地址, 为空时不监听
+	ListenWebSocket string `json:"listen_websocket"`
+	// WebSocketPath WebSocket 的 HTTP 路径, 默认 /tianmen
+	WebSocketPath string         `json:"websocket_path"`
+	TLS           config.TLS     `json:"tls"`
+	Operator      OperatorConfig `json:"operator"`
+	// KeepAlive agent 心跳, 心跳失败的 agent 被移出列表
+	KeepAlive config.KeepAlive `json:"keepalive"`
+	// SMux TLS 和 WebSocket 连接上的 smux 会话参数
+	SMux config.SMux `json:"smux"`
+	// QUIC 监听 QUIC 连接的参数
+	QUIC config.QUIC `json:"quic"`
+	// Hub 提供给 agent 的配置下发、制品下载和日志上传服务
+	Hub HubConfig `json:"hub"`
+}
+
+// HubConfig 见 Hub, 目录为空时不提供对应的服务
+type HubConfig struct {
+	ConfigDir   string `json:"config_dir"`
+	ArtifactDir string `json:"artifact_dir"`
+	LogDir      string `json:"log_dir"`
+}
+
+// OperatorConfig 运维接口配置
+type OperatorConfig struct {
+	// Listen 监听地址, host:port 或 unix:///path/to/socket.
+	// 运维接口可以操作所有 agent, 监听 host:port 时必须设置 TLS
+	Listen string `json:"listen"`
+	// TLS 不为空时运维接口使用 TLS, 并要求客户端证书
+	TLS *config.TLS `json:"tls"`
+}
+
+// LoadConfig 读取控制端配置文件
+func LoadConfig(path string) (*Config, error) {
+	c := &Config{}
+	err := config.Load(path, c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// New 根据配置创建 Server
+func New(c *Config) (*Server, error) {
+	if c.ListenTLS == "" && c.ListenQUIC == "" && c.ListenWebSocket == "" {
+		return nil, errors.New("listen_tls, listen_quic or listen_websocket is required")
+	}
+	if c.Operator.Listen == "" {
+		return nil, errors.New("operator.listen is required")
+	}
+	if !strings.HasPrefix(c.Operator.Listen, "unix://") && c.Operator.TLS == nil {
+		return nil, errors.New("operator.tls is required when operator.listen is not a unix socket")
+	}
+	tlsConf, err := c.TLS.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	smuxOpts, err := smuxOptions(c.SMux)
+	if err != nil {
+		return nil, err
+	}
+	quicOpts, err := quicOptions(c.QUIC)
+	if err != nil {
+		return nil, err
+	}
+	var operatorTLS *tls.Config
+	if c.Operator.TLS != nil {
+		operatorTLS, err = c.Operator.TLS.ServerConfig()
+		if err != nil {
+			return nil, err
+		}
+		operatorTLS.NextProtos = []string{"h2"}
+	}
+	var hub *Hub
+	if c.Hub != (HubConfig{}) {
+		hub = &Hub{
+			ConfigDir:   c.Hub.ConfigDir,
+			ArtifactDir: c.Hub.ArtifactDir,
+			LogDir:      c.Hub.LogDir,
+		}
+	}
+	return &Server{
+		Controller: &Controller{
+			Registry:    &Registry{},
+			KeepAlive:   keepAlive(c.KeepAlive),
+			SMuxOptions: smuxOpts,
+			QUIC:        quicOpts,
+			Hub:         hub,
+		},
+		ListenTLS:       c.ListenTLS,
+		ListenQUIC:      c.ListenQUIC,
+		ListenWebSocket: c.ListenWebSocket,
+		WebSocketPath:   c.WebSocketPath,
+		TLSConfig:       tlsConf,
+		OperatorListen:  c.Operator.Listen,
+		OperatorTLS:     operatorTLS,
+	}, nil
+}
+
+// keepAlive 转换为 mux.KeepAlive, 未设置的字段使用默认值
+func keepAlive(k config.KeepAlive) mux.KeepAlive {
+	if k.Interval < 0 {
+		return mux.KeepAlive{}
+	}
+	m := mux.KeepAlive{
+		Interval:    time.Duration(k.Interval),
+		Timeout:     time.Duration(k.Timeout),
+		MaxFailures: k.MaxFailures,
+	}
+	if m.Interval == 0 {
+		m.Interval = 30 * time.Second
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = 10 * time.Second
+	}
+	return m
+}
+
+// smuxOptions 转换为 mux.SMuxOption 并校验
+func smuxOptions(s config.SMux) ([]mux.SMuxOption, error) {
+	var opts []mux.SMuxOption
+	if s.MaxReceiveBuffer != 0 {
+		opts = append(opts, mux.WithMaxReceiveBuffer(s.MaxReceiveBuffer))
+	}
+	if s.MaxStreamBuffer != 0 {
+		opts = append(opts, mux.WithMaxStreamBuffer(s.MaxStreamBuffer))
+	}
+	if s.MaxFrameSize != 0 {
+		opts = append(opts, mux.WithMaxFrameSize(s.MaxFrameSize))
+	}
+	if s.KeepAliveInterval != 0 || s.KeepAliveTimeout != 0 {
+		interval, timeout := time.Duration(s.KeepAliveInterval), time.Duration(s.KeepAliveTimeout)
+		if interval == 0 {
+			interval = 10 * time.Second
+		}
+		if timeout == 0 {
+			timeout = max(30*time.Second, interval)
+		}
+		opts = append(opts, mux.WithKeepAlive(interval, timeout))
+	}
+	err := mux.VerifySMuxOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// quicOptions 转换为 mux.QUICOptions 并校验
+func quicOptions(q config.QUIC) (mux.QUICOptions, error) {
+	o := mux.QUICOptions{
+		HandshakeIdleTimeout:           time.Duration(q.HandshakeIdleTimeout),
+		MaxIdleTimeout:                 time.Duration(q.MaxIdleTimeout),
+		KeepAlivePeriod:                time.Duration(q.KeepAlivePeriod),
+		MaxIncomingStreams:             q.MaxIncomingStreams,
+		InitialStreamReceiveWindow:     q.InitialStreamReceiveWindow,
+		MaxStreamReceiveWindow:         q.MaxStreamReceiveWindow,
+		InitialConnectionReceiveWindow: q.InitialConnectionReceiveWindow,
+		MaxConnectionReceiveWindow:     q.MaxConnectionReceiveWindow,
+		EnableDatagrams:                q.EnableDatagrams,
+		Enable0RTT:                     q.Enable0RTT,
+	}
+	return o, o.Validate()
+}
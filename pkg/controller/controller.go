@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+// 连接控制端使用的传输协议
+const (
+	TransportTLS       = "tls"
+	TransportQUIC      = "quic"
+	TransportWebSocket = "websocket"
+)
+
+const handshakeTimeout = 10 * time.Second
+
+// defaultRegisterTimeout 默认的 RegisterTimeout
+const defaultRegisterTimeout = 10 * time.Second
+
+// Controller 接收 agent 的反向连接, 并维护在线 agent 列表
+type Controller struct {
+	Registry *Registry
+	// DialOptions 创建到 agent 的 gRPC 连接时附加的选项
+	DialOptions []grpc.DialOption
+	// RegisterTimeout agent 建立连接后必须在该时间内调用 Register, 默认 10s
+	RegisterTimeout time.Duration
+	// ErrorLog 不为空时接收单个连接处理失败的错误
+	ErrorLog func(remote net.Addr, err error)
+	// KeepAlive 检测 agent 是否存活, 心跳失败的 agent 被断开并移出 Registry
+	KeepAlive mux.KeepAlive
+	// OnEvict 不为空时在 agent 因心跳失败被移除前调用
+	OnEvict func(a *AgentConn, err error)
+	// SMuxOptions TLS 和 WebSocket 连接上 smux 会话的参数
+	SMuxOptions []mux.SMuxOption
+	// QUIC 监听 QUIC 连接的参数
+	QUIC mux.QUICOptions
+	// Hub 不为空时在 agent 连接上提供配置下发、制品下载和日志上传服务
+	Hub *Hub
+}
+
+// ServeTLS 在 TLS listener 上接收 agent 连接, 直到 listener 关闭
+func (c *Controller) ServeTLS(l net.Listener) error {
+	return c.serve(l, c.handleTLS)
+}
+
+// ServeWebSocket 在 mux.WebSocketListener 上接收 agent 连接, 直到 listener 关闭
+func (c *Controller) ServeWebSocket(l net.Listener) error {
+	return c.serve(l, c.handleWebSocket)
+}
+
+func (c *Controller) serve(l net.Listener, handle func(net.Conn) error) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			err := handle(conn)
+			if err != nil {
+				_ = conn.Close()
+				c.logError(conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeQUIC 在 QUIC listener 上接收 agent 连接, 直到 listener 关闭
+func (c *Controller) ServeQUIC(l mux.QUICListener) error {
+	for {
+		conn, err := l.Accept(context.Background())
+		if err != nil {
+			if errors.Is(err, quic.ErrServerClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			err := c.handleQUIC(conn)
+			if err != nil {
+				_ = conn.CloseWithError(0, err.Error())
+				c.logError(conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+func (c *Controller) handleTLS(conn net.Conn) error {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return errors.New("not a tls connection")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	err := tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := agentID(tlsConn.ConnectionState().PeerCertificates)
+	if err != nil {
+		return err
+	}
+	tunnel, err := mux.NewSMuxTunnel(conn, false, c.SMuxOptions...)
+	if err != nil {
+		return err
+	}
+	return c.register(id, TransportTLS, tunnel)
+}
+
+// handleWebSocket 使用 WebSocket 所在 HTTPS 连接的客户端证书识别 agent
+func (c *Controller) handleWebSocket(conn net.Conn) error {
+	ws, ok := conn.(interface{ ConnectionState() tls.ConnectionState })
+	if !ok {
+		return errors.New("not a websocket connection")
+	}
+	id, err := agentID(ws.ConnectionState().PeerCertificates)
+	if err != nil {
+		return err
+	}
+	tunnel, err := mux.NewSMuxTunnel(conn, false, c.SMuxOptions...)
+	if err != nil {
+		return err
+	}
+	return c.register(id, TransportWebSocket, tunnel)
+}
+
+func (c *Controller) handleQUIC(conn *quic.Conn) error {
+	// 0-RTT 连接在握手完成前返回, 此时还没有对端证书
+	timer := time.NewTimer(handshakeTimeout)
+	defer timer.Stop()
+	select {
+	case <-conn.HandshakeComplete():
+	case <-conn.Context().Done():
+		return context.Cause(conn.Context())
+	case <-timer.C:
+		return errors.New("handshake timeout")
+	}
+	id, err := agentID(conn.ConnectionState().TLS.PeerCertificates)
+	if err != nil {
+		return err
+	}
+	return c.register(id, TransportQUIC, mux.NewQUICTunnel(conn))
+}
+
+// register 在 tunnel 上提供 controller.Agent 服务, agent 调用 Register 后加入 Registry
+func (c *Controller) register(id string, transport string, tunnel mux.Tunnel) error {
+	remote := tunnel.RemoteAddr()
+	session := mux.NewSession(tunnel)
+	opts := append([]grpc.DialOption{mux.InsecureClient()}, c.DialOptions...)
+	var serverOpts []grpc.ServerOption
+	if c.KeepAlive.Enabled() {
+		opts = append(opts, c.KeepAlive.DialOption())
+		serverOpts = c.KeepAlive.ServerOptions()
+	}
+	cc, err := mux.NewClientConn(session, opts...)
+	if err != nil {
+		_ = tunnel.Close()
+		return err
+	}
+	a := &AgentConn{
+		ID:          id,
+		RemoteAddr:  remote,
+		Transport:   transport,
+		ConnectedAt: time.Now(),
+		Conn:        cc,
+		tunnel:      tunnel,
+	}
+	registered := make(chan struct{})
+	gs := grpc.NewServer(serverOpts...)
+	controller.RegisterAgentServer(gs, &agentServer{
+		agent:    a,
+		registry: c.Registry,
+		once:     &sync.Once{},
+		done:     registered,
+	})
+	if c.Hub != nil {
+		c.Hub.register(gs, a)
+	}
+	healthpb.RegisterHealthServer(gs, health.NewServer())
+	go func() {
+		_ = gs.Serve(session)
+	}()
+	go func() {
+		timeout := c.RegisterTimeout
+		if timeout <= 0 {
+			timeout = defaultRegisterTimeout
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-registered:
+			if c.KeepAlive.Enabled() {
+				go c.heartbeat(a)
+			}
+		case <-timer.C:
+			c.logError(remote, fmt.Errorf("agent %s did not register in %s", id, timeout))
+			_ = a.Close()
+		case <-a.Done():
+		}
+		<-a.Done()
+		gs.Stop()
+		_ = cc.Close()
+	}()
+	return nil
+}
+
+// heartbeat 定期检查 agent, 连续失败时断开连接
+func (c *Controller) heartbeat(a *AgentConn) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-a.Done()
+		cancel()
+	}()
+	err := c.KeepAlive.Heartbeat(ctx, a.Conn)
+	if err == nil {
+		return
+	}
+	err = fmt.Errorf("evict agent %s: %w", a.ID, err)
+	c.logError(a.RemoteAddr, err)
+	if c.OnEvict != nil {
+		c.OnEvict(a, err)
+	}
+	_ = a.Close()
+}
+
+func (c *Controller) logError(remote net.Addr, err error) {
+	if c.ErrorLog != nil {
+		c.ErrorLog(remote, err)
+	}
+}
+
+// agentID 使用客户端证书的 CN 作为 agent ID
+func agentID(certs []*x509.Certificate) (string, error) {
+	if len(certs) == 0 {
+		return "", errors.New("no client certificate")
+	}
+	cn := certs[0].Subject.CommonName
+	if cn == "" {
+		return "", fmt.Errorf("client certificate %s has no common name", certs[0].SerialNumber)
+	}
+	return cn, nil
+}
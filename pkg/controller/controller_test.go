@@ -0,0 +1,444 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/agent"
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+const testAgentID = "ED25519 Client CA"
+
+// startOperator 启动运维接口并返回连接
+func startOperator(t *testing.T, c *Controller) *grpc.ClientConn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	gs := c.OperatorServer()
+	go func() { _ = gs.Serve(l) }()
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient(l.Addr().String(), mux.InsecureClient())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func startAgent(t *testing.T, addr, transport string, opts ...func(*agent.Agent)) context.CancelFunc {
+	_, cConf := testutil.GetTLCConfig()
+	a := &agent.Agent{
+		Servers:         []string{addr},
+		Transport:       transport,
+		TLSConfig:       cConf,
+		ShutdownTimeout: time.Second,
+		Shell:           &serviceCore.Server{},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = a.Run(ctx) }()
+	t.Cleanup(cancel)
+	return cancel
+}
+
+func waitAgents(t *testing.T, conn *grpc.ClientConn, n int) []*controller.AgentInfo {
+	cli := controller.NewRegistryClient(conn)
+	var agents []*controller.AgentInfo
+	require.Eventually(t, func() bool {
+		res, err := cli.ListAgents(context.Background(), &controller.ListAgentsRequest{})
+		if err != nil {
+			return false
+		}
+		agents = res.GetAgents()
+		return len(agents) == n
+	}, 5*time.Second, 10*time.Millisecond)
+	return agents
+}
+
+func runEcho(t *testing.T, conn *grpc.ClientConn, id string) {
+	ctx := AgentContext(context.Background(), id)
+	stream, err := core.NewShellClient(conn).Shell(ctx)
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "echo hello"},
+			DisablePty: true,
+		}},
+	})
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	exit, err := serviceCore.StreamOutput(stream, out, out)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, exit.GetCode())
+	require.Equal(t, "hello\n", out.String())
+}
+
+func TestControllerTLS(t *testing.T) {
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	c := &Controller{Registry: &Registry{}}
+	go func() { _ = c.ServeTLS(l) }()
+	conn := startOperator(t, c)
+
+	cancel := startAgent(t, addr.String(), agent.TransportTLS)
+	agents := waitAgents(t, conn, 1)
+	require.Equal(t, testAgentID, agents[0].GetId())
+	require.Equal(t, TransportTLS, agents[0].GetTransport())
+	require.Equal(t, agent.Version, agents[0].GetMeta().GetVersion())
+	require.Contains(t, agents[0].GetMeta().GetServices(), core.Shell_ServiceDesc.ServiceName)
+	runEcho(t, conn, testAgentID)
+
+	// 断开后从列表移除
+	cancel()
+	waitAgents(t, conn, 0)
+	_, err = controller.NewRegistryClient(conn).GetAgent(context.Background(), &controller.GetAgentRequest{Id: testAgentID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestControllerQUIC(t *testing.T) {
+	sConf, _ := testutil.GetTLCConfig()
+	opts := mux.QUICOptions{Enable0RTT: true}
+	l, err := mux.ListenQUIC("127.0.0.1:0", sConf, opts)
+	require.NoError(t, err)
+	defer l.Close()
+	c := &Controller{Registry: &Registry{}, QUIC: opts}
+	go func() { _ = c.ServeQUIC(l) }()
+	conn := startOperator(t, c)
+
+	startAgent(t, l.Addr().String(), agent.TransportQUIC, func(a *agent.Agent) {
+		a.QUIC = opts
+		a.Backoff = agent.Backoff{Initial: 10 * time.Millisecond}
+	})
+	agents := waitAgents(t, conn, 1)
+	require.Equal(t, TransportQUIC, agents[0].GetTransport())
+	runEcho(t, conn, testAgentID)
+
+	// 断开后 agent 使用 0-RTT 重连
+	a, ok := c.Registry.Get(testAgentID)
+	require.True(t, ok)
+	require.NoError(t, a.Close())
+	require.Eventually(t, func() bool {
+		b, ok := c.Registry.Get(testAgentID)
+		return ok && b != a
+	}, 5*time.Second, 10*time.Millisecond)
+	runEcho(t, conn, testAgentID)
+}
+
+func TestControllerWebSocket(t *testing.T) {
+	sConf, _ := testutil.GetTLCConfig()
+	sConf.NextProtos = nil
+	wsl := mux.NewWebSocketListener(nil)
+	defer wsl.Close()
+	s := httptest.NewUnstartedServer(wsl)
+	s.TLS = sConf
+	s.StartTLS()
+	defer s.Close()
+	c := &Controller{Registry: &Registry{}}
+	go func() { _ = c.ServeWebSocket(wsl) }()
+	conn := startOperator(t, c)
+
+	startAgent(t, "wss://"+s.Listener.Addr().String()+"/tianmen", agent.TransportWebSocket)
+	agents := waitAgents(t, conn, 1)
+	require.Equal(t, testAgentID, agents[0].GetId())
+	require.Equal(t, TransportWebSocket, agents[0].GetTransport())
+	runEcho(t, conn, testAgentID)
+}
+
+func TestControllerRegisterTimeout(t *testing.T) {
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	errCh := make(chan error, 1)
+	c := &Controller{
+		Registry:        &Registry{},
+		RegisterTimeout: 100 * time.Millisecond,
+		ErrorLog:        func(_ net.Addr, err error) { errCh <- err },
+	}
+	go func() { _ = c.ServeTLS(l) }()
+
+	_, cConf := testutil.GetTLCConfig()
+	conn, err := tls.Dial("tcp", addr.String(), cConf)
+	require.NoError(t, err)
+	defer conn.Close()
+	tunnel, err := mux.NewSMuxTunnel(conn, true)
+	require.NoError(t, err)
+	defer tunnel.Close()
+	// 不调用 Register 的连接被关闭
+	select {
+	case err = <-errCh:
+		require.ErrorContains(t, err, "did not register")
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed")
+	}
+	<-tunnel.Done()
+	require.Empty(t, c.Registry.List())
+}
+
+func TestControllerEvict(t *testing.T) {
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	evicted := make(chan string, 1)
+	c := &Controller{
+		Registry:  &Registry{},
+		KeepAlive: mux.KeepAlive{Interval: 20 * time.Millisecond, Timeout: 20 * time.Millisecond, MaxFailures: 2},
+		OnEvict: func(a *AgentConn, _ error) {
+			evicted <- a.ID
+		},
+	}
+	go func() { _ = c.ServeTLS(l) }()
+
+	// 注册后不再响应心跳的 agent
+	_, cConf := testutil.GetTLCConfig()
+	conn, err := tls.Dial("tcp", addr.String(), cConf)
+	require.NoError(t, err)
+	defer conn.Close()
+	tunnel, err := mux.NewSMuxTunnel(conn, true)
+	require.NoError(t, err)
+	cc, err := mux.NewClientConn(mux.NewSession(tunnel), mux.InsecureClient())
+	require.NoError(t, err)
+	defer cc.Close()
+	_, err = controller.NewAgentClient(cc).Register(context.Background(), &controller.RegisterRequest{
+		Meta: &controller.AgentMeta{Hostname: "test"},
+	})
+	require.NoError(t, err)
+	_, ok := c.Registry.Get(testAgentID)
+	require.True(t, ok)
+
+	select {
+	case id := <-evicted:
+		require.Equal(t, testAgentID, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent not evicted")
+	}
+	<-tunnel.Done()
+	require.Eventually(t, func() bool {
+		return len(c.Registry.List()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestProxyErrors(t *testing.T) {
+	conn := startOperator(t, &Controller{Registry: &Registry{}})
+	cli := core.NewShellClient(conn)
+
+	_, err := cli.ListSessions(context.Background(), &core.ListSessionsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = cli.ListSessions(AgentContext(context.Background(), "unknown"), &core.ListSessionsRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestControllerHub(t *testing.T) {
+	hub := &Hub{ConfigDir: t.TempDir(), ArtifactDir: t.TempDir(), LogDir: t.TempDir()}
+	require.NoError(t, os.WriteFile(filepath.Join(hub.ConfigDir, "default.json"), []byte(`{"a":1}`), 0o600))
+	artifact := bytes.Repeat([]byte("0123456789"), 10<<10)
+	require.NoError(t, os.WriteFile(filepath.Join(hub.ArtifactDir, "pkg.tar"), artifact, 0o750))
+
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	c := &Controller{Registry: &Registry{}, Hub: hub}
+	go func() { _ = c.ServeTLS(l) }()
+
+	var a *agent.Agent
+	startAgent(t, addr.String(), agent.TransportTLS, func(ag *agent.Agent) { a = ag })
+	require.Eventually(t, func() bool {
+		_, err := a.Conn()
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	ctx := context.Background()
+
+	// 没有 agent 自己的配置时使用 default.json
+	res, err := a.PullConfig(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, string(res.GetData()))
+	res, err = a.PullConfig(ctx, res.GetVersion())
+	require.NoError(t, err)
+	require.True(t, res.GetNotModified())
+	require.NoError(t, os.WriteFile(filepath.Join(hub.ConfigDir, testAgentID+".json"), []byte(`{"a":2}`), 0o600))
+	res, err = a.PullConfig(ctx, res.GetVersion())
+	require.NoError(t, err)
+	require.Equal(t, `{"a":2}`, string(res.GetData()))
+
+	// 从已下载的部分继续下载
+	dst := filepath.Join(t.TempDir(), "pkg.tar")
+	require.NoError(t, os.WriteFile(dst+".part", artifact[:12345], 0o600))
+	require.NoError(t, a.DownloadArtifact(ctx, "pkg.tar", dst))
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, artifact, data)
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.EqualValues(t, 0o750, info.Mode().Perm())
+	require.NoFileExists(t, dst+".part")
+	// 已下载的部分比制品长时重新下载
+	require.NoError(t, os.WriteFile(dst+".part", append(bytes.Clone(artifact), "extra"...), 0o600))
+	require.NoError(t, a.DownloadArtifact(ctx, "pkg.tar", dst))
+	data, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, artifact, data)
+	// 已下载的部分内容错误时校验失败
+	require.NoError(t, os.WriteFile(dst+".part", []byte("bad"), 0o600))
+	require.ErrorContains(t, a.DownloadArtifact(ctx, "pkg.tar", dst), "sha256 mismatch")
+	err = a.DownloadArtifact(ctx, "../pkg.tar", dst)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = a.UploadLogs(ctx,
+		&controller.LogRecord{Time: time.Now().UnixNano(), Level: "info", Message: "hello"},
+		&controller.LogRecord{Time: time.Now().UnixNano(), Level: "error", Message: "world", Fields: map[string]string{"k": "v"}},
+	)
+	require.NoError(t, err)
+	logs, err := os.ReadFile(filepath.Join(hub.LogDir, testAgentID+".log"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(logs)), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[1], `"message":"world"`)
+	require.Contains(t, lines[1], `"fields":{"k":"v"}`)
+}
+
+func TestControllerForward(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer target.Close()
+	go func() {
+		conn, err := target.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("hello"))
+		_ = conn.Close()
+	}()
+
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	c := &Controller{Registry: &Registry{}}
+	go func() { _ = c.ServeTLS(l) }()
+	conn := startOperator(t, c)
+	startAgent(t, addr.String(), agent.TransportTLS, func(a *agent.Agent) {
+		a.Forward = &serviceCore.ForwardServer{Allow: []string{"127.0.0.1:*"}}
+	})
+	agents := waitAgents(t, conn, 1)
+	require.Contains(t, agents[0].GetMeta().GetServices(), core.Forward_ServiceDesc.ServiceName)
+
+	// 经运维接口转发到 agent, 建立连接后 ctx 结束不影响连接
+	ctx, cancel := context.WithCancel(context.Background())
+	d := serviceCore.ForwardDialer{Client: core.NewForwardClient(AgentClientConn(conn, testAgentID))}
+	fc, err := d.DialContext(ctx, target.Addr().String())
+	require.NoError(t, err)
+	defer fc.Close()
+	cancel()
+	data, err := io.ReadAll(fc)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(data))
+}
+
+func TestListenOperator(t *testing.T) {
+	sConf, _ := testutil.GetTLCConfig()
+	_, err := listenOperator("127.0.0.1:0", nil)
+	require.ErrorContains(t, err, "client certificates")
+	_, err = listenOperator("127.0.0.1:0", &tls.Config{Certificates: sConf.Certificates})
+	require.ErrorContains(t, err, "client certificates")
+	l, err := listenOperator("127.0.0.1:0", sConf)
+	require.NoError(t, err)
+	_ = l.Close()
+	l, err = listenOperator("unix://"+filepath.Join(t.TempDir(), "operator.sock"), nil)
+	require.NoError(t, err)
+	_ = l.Close()
+
+	_, err = New(&Config{ListenTLS: "127.0.0.1:0", Operator: OperatorConfig{Listen: "127.0.0.1:0"}})
+	require.ErrorContains(t, err, "operator.tls is required")
+}
+
+func TestControllerRemoteForward(t *testing.T) {
+	ctx := context.Background()
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	c := &Controller{Registry: &Registry{}}
+	go func() { _ = c.ServeTLS(l) }()
+	conn := startOperator(t, c)
+	startAgent(t, addr.String(), agent.TransportTLS, func(a *agent.Agent) {
+		a.Forward = &serviceCore.ForwardServer{ListenAllow: []string{"127.0.0.1:*"}}
+	})
+	waitAgents(t, conn, 1)
+	cli := controller.NewRemoteForwardClient(conn)
+
+	stream, err := cli.Forward(ctx, &controller.RemoteForwardRequest{Agent: "unknown", ListenAddress: "127.0.0.1:0"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// agent 接收的连接由控制端连接目标地址
+	echo := testutil.StartTCPEcho(t)
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err = cli.Forward(sctx, &controller.RemoteForwardRequest{
+		Agent:         testAgentID,
+		ListenAddress: "127.0.0.1:0",
+		TargetAddress: echo,
+	})
+	require.NoError(t, err)
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	fc, err := net.Dial("tcp", event.GetAddress())
+	require.NoError(t, err)
+	defer fc.Close()
+	_, err = fc.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(fc, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+
+	// 运维人员的调用结束时 agent 停止监听并关闭连接
+	cancel()
+	_, err = io.ReadAll(fc)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		c, err := net.Dial("tcp", event.GetAddress())
+		if err == nil {
+			_ = c.Close()
+		}
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 控制端连接目标失败时报告错误
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, target.Close())
+	stream, err = cli.Forward(ctx, &controller.RemoteForwardRequest{
+		Agent:         testAgentID,
+		ListenAddress: "127.0.0.1:0",
+		TargetAddress: target.Addr().String(),
+	})
+	require.NoError(t, err)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	fc, err = net.Dial("tcp", event.GetAddress())
+	require.NoError(t, err)
+	defer fc.Close()
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	require.Contains(t, event.GetError(), "connection refused")
+}
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+)
+
+// artifactChunkSize 下载制品时单个消息的数据大小
+const artifactChunkSize = 32 << 10
+
+// Hub 控制端在 agent 连接上提供给 agent 的服务, 为空的目录对应的服务不启用
+type Hub struct {
+	// ConfigDir agent 配置目录, 下发 <ID>.json, 不存在时下发 default.json
+	ConfigDir string
+	// ArtifactDir 制品目录, agent 可以下载其中的文件
+	ArtifactDir string
+	// LogDir 日志目录, agent 上传的日志以 JSON 行写入 <ID>.log
+	LogDir string
+
+	logMu sync.Mutex
+}
+
+// register 在 agent 连接的 grpc.Server 上注册服务
+func (h *Hub) register(gs *grpc.Server, a *AgentConn) {
+	if h.ConfigDir != "" {
+		controller.RegisterConfigServer(gs, &configServer{hub: h, agent: a})
+	}
+	if h.ArtifactDir != "" {
+		controller.RegisterArtifactsServer(gs, &artifactServer{hub: h, agent: a})
+	}
+	if h.LogDir != "" {
+		controller.RegisterLogsServer(gs, &logServer{hub: h, agent: a})
+	}
+}
+
+// registered agent 调用 Register 之前不提供服务
+func registered(a *AgentConn) error {
+	if a.Meta() == nil {
+		return status.Error(codes.FailedPrecondition, "agent not registered")
+	}
+	return nil
+}
+
+// agentFile agent ID 作为文件名时不能包含路径
+func agentFile(id, ext string) (string, error) {
+	name := id + ext
+	if !filepath.IsLocal(name) || filepath.Base(name) != name {
+		return "", status.Errorf(codes.InvalidArgument, "invalid agent id %q", id)
+	}
+	return name, nil
+}
+
+type configServer struct {
+	controller.UnimplementedConfigServer
+	hub   *Hub
+	agent *AgentConn
+}
+
+// Pull 返回 agent 的配置, 版本与请求相同时只返回 NotModified
+func (s *configServer) Pull(_ context.Context, req *controller.PullConfigRequest) (*controller.PullConfigResponse, error) {
+	err := registered(s.agent)
+	if err != nil {
+		return nil, err
+	}
+	name, err := agentFile(s.agent.ID, ".json")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.hub.ConfigDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = os.ReadFile(filepath.Join(s.hub.ConfigDir, "default.json"))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, status.Errorf(codes.NotFound, "no config for agent %s", s.agent.ID)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	sum := sha256.Sum256(data)
+	version := hex.EncodeToString(sum[:])
+	if version == req.GetVersion() {
+		return &controller.PullConfigResponse{Version: version, NotModified: true}, nil
+	}
+	return &controller.PullConfigResponse{Version: version, Data: data}, nil
+}
+
+type artifactServer struct {
+	controller.UnimplementedArtifactsServer
+	hub   *Hub
+	agent *AgentConn
+}
+
+// Download 从 Offset 开始发送制品内容, 第一个消息包含整个制品的大小和 SHA-256
+func (s *artifactServer) Download(req *controller.DownloadArtifactRequest, stream grpc.ServerStreamingServer[controller.ArtifactChunk]) error {
+	err := registered(s.agent)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsLocal(req.GetName()) {
+		return status.Errorf(codes.InvalidArgument, "invalid artifact name %q", req.GetName())
+	}
+	f, err := os.Open(filepath.Join(s.hub.ArtifactDir, req.GetName()))
+	if errors.Is(err, fs.ErrNotExist) {
+		return status.Errorf(codes.NotFound, "artifact %s not found", req.GetName())
+	}
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if !info.Mode().IsRegular() {
+		return status.Errorf(codes.InvalidArgument, "artifact %s is not a regular file", req.GetName())
+	}
+	if req.GetOffset() < 0 || req.GetOffset() > info.Size() {
+		return status.Errorf(codes.OutOfRange, "offset %d out of range", req.GetOffset())
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	_, err = f.Seek(req.GetOffset(), io.SeekStart)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	chunk := &controller.ArtifactChunk{
+		Size:   info.Size(),
+		Sha256: hex.EncodeToString(h.Sum(nil)),
+		Mode:   uint32(info.Mode().Perm()),
+	}
+	buf := make([]byte, artifactChunkSize)
+	first := true
+	for {
+		n, err := f.Read(buf)
+		// 空文件或 Offset 等于大小时也发送第一个消息
+		if n > 0 || first {
+			chunk.Data = buf[:n]
+			sendErr := stream.Send(chunk)
+			if sendErr != nil {
+				return sendErr
+			}
+			first = false
+			chunk = &controller.ArtifactChunk{}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+type logServer struct {
+	controller.UnimplementedLogsServer
+	hub   *Hub
+	agent *AgentConn
+}
+
+// logLine 写入日志文件的一行
+type logLine struct {
+	Time    time.Time         `json:"time"`
+	Agent   string            `json:"agent"`
+	Level   string            `json:"level,omitempty"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Upload 将 agent 上传的日志追加到 <ID>.log
+func (s *logServer) Upload(stream grpc.ClientStreamingServer[controller.LogRecord, controller.UploadLogsResponse]) error {
+	err := registered(s.agent)
+	if err != nil {
+		return err
+	}
+	name, err := agentFile(s.agent.ID, ".log")
+	if err != nil {
+		return err
+	}
+	var count int64
+	for {
+		record, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&controller.UploadLogsResponse{Count: count})
+		}
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(logLine{
+			Time:    time.Unix(0, record.GetTime()),
+			Agent:   s.agent.ID,
+			Level:   record.GetLevel(),
+			Message: record.GetMessage(),
+			Fields:  record.GetFields(),
+		})
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		err = s.hub.appendLog(name, append(line, '\n'))
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		count++
+	}
+}
+
+// appendLog 追加写入日志文件, 同一时间只有一个写入者
+func (h *Hub) appendLog(name string, line []byte) error {
+	h.logMu.Lock()
+	defer h.logMu.Unlock()
+	f, err := os.OpenFile(filepath.Join(h.LogDir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(line)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
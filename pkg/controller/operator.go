@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	serviceCore "github.com/lyp256/tianmen/pkg/rpc/service/core"
+)
+
+// OperatorServer 创建提供给运维人员的 gRPC 服务,
+// 包含 controller.Registry、controller.RemoteForward 以及转发到 agent 的其他服务
+func (c *Controller) OperatorServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(c.proxy),
+	)
+	gs := grpc.NewServer(opts...)
+	controller.RegisterRegistryServer(gs, &registryServer{registry: c.Registry})
+	controller.RegisterRemoteForwardServer(gs, &remoteForwardServer{registry: c.Registry})
+	return gs
+}
+
+type registryServer struct {
+	controller.UnimplementedRegistryServer
+	registry *Registry
+}
+
+func (s *registryServer) ListAgents(context.Context, *controller.ListAgentsRequest) (*controller.ListAgentsResponse, error) {
+	res := &controller.ListAgentsResponse{}
+	for _, a := range s.registry.List() {
+		res.Agents = append(res.Agents, a.Info())
+	}
+	return res, nil
+}
+
+func (s *registryServer) GetAgent(_ context.Context, req *controller.GetAgentRequest) (*controller.AgentInfo, error) {
+	a, ok := s.registry.Get(req.GetId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "agent %s not connected", req.GetId())
+	}
+	return a.Info(), nil
+}
+
+type remoteForwardServer struct {
+	controller.UnimplementedRemoteForwardServer
+	registry *Registry
+}
+
+// Forward 请求 agent 监听, 由控制端连接目标地址并转发 agent 接收的连接.
+// 运维人员的调用结束时 agent 停止监听并关闭所有连接
+func (s *remoteForwardServer) Forward(req *controller.RemoteForwardRequest, stream grpc.ServerStreamingServer[controller.RemoteForwardEvent]) error {
+	targetNet := req.GetTargetNetwork()
+	if targetNet == "" {
+		targetNet = "tcp"
+	}
+	switch targetNet {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported network: %s", targetNet)
+	}
+	a, ok := s.registry.Get(req.GetAgent())
+	if !ok {
+		return status.Errorf(codes.Unavailable, "agent %s not connected", req.GetAgent())
+	}
+	l, err := serviceCore.ListenRemote(stream.Context(), core.NewForwardClient(a.Conn), req.GetListenNetwork(), req.GetListenAddress())
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	err = stream.Send(&controller.RemoteForwardEvent{Address: l.Addr().String()})
+	if err != nil {
+		return err
+	}
+
+	// 连接失败的报告在 Forward 返回后丢弃
+	var mu sync.Mutex
+	done := false
+	defer func() {
+		mu.Lock()
+		done = true
+		mu.Unlock()
+	}()
+	dial := func(ctx context.Context) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, targetNet, req.GetTargetAddress())
+	}
+	return serviceCore.ServeForward(l, dial, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			_ = stream.Send(&controller.RemoteForwardEvent{Error: err.Error()})
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AgentMetadataKey 指定请求转发到哪个 agent 的 metadata
+const AgentMetadataKey = "tianmen-agent"
+
+// AgentContext 返回将请求转发到 agent id 的 context
+func AgentContext(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, AgentMetadataKey, id)
+}
+
+// AgentClientConn 返回调用 id 上服务的连接, 每个调用的 context 都附加 AgentMetadataKey.
+// cc 为运维接口的连接
+func AgentClientConn(cc grpc.ClientConnInterface, id string) grpc.ClientConnInterface {
+	return agentClientConn{cc: cc, id: id}
+}
+
+type agentClientConn struct {
+	cc grpc.ClientConnInterface
+	id string
+}
+
+func (c agentClientConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.cc.Invoke(AgentContext(ctx, c.id), method, args, reply, opts...)
+}
+
+func (c agentClientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.cc.NewStream(AgentContext(ctx, c.id), desc, method, opts...)
+}
+
+// frame 转发时不解码的消息
+type frame struct {
+	payload []byte
+}
+
+// rawCodec 转发时直接传递消息的字节, 其他消息使用 proto 编码
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	if f, ok := v.(*frame); ok {
+		return f.payload, nil
+	}
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	if f, ok := v.(*frame); ok {
+		f.payload = append(f.payload[:0], data...)
+		return nil
+	}
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// proxy 将未注册的服务转发到 metadata 指定的 agent
+func (c *Controller) proxy(_ any, stream grpc.ServerStream) error {
+	method, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "unknown method")
+	}
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	ids := md.Get(AgentMetadataKey)
+	if len(ids) == 0 {
+		return status.Errorf(codes.InvalidArgument, "metadata %s is required", AgentMetadataKey)
+	}
+	agent, ok := c.Registry.Get(ids[0])
+	if !ok {
+		return status.Errorf(codes.Unavailable, "agent %s not connected", ids[0])
+	}
+
+	out := metadata.MD{}
+	for k, v := range md {
+		if k == AgentMetadataKey || strings.HasPrefix(k, ":") {
+			continue
+		}
+		out[k] = v
+	}
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(stream.Context(), out))
+	defer cancel()
+	desc := &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
+	cs, err := agent.Conn.NewStream(ctx, desc, method, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return err
+	}
+
+	upstream := make(chan error, 1)
+	go func() {
+		upstream <- forwardToAgent(stream, cs)
+	}()
+	downstream := make(chan error, 1)
+	go func() {
+		downstream <- forwardToClient(cs, stream)
+	}()
+	for {
+		select {
+		case err = <-upstream:
+			if !errors.Is(err, io.EOF) {
+				return status.Errorf(codes.Canceled, "receive from client: %v", err)
+			}
+			// 客户端发送结束
+			_ = cs.CloseSend()
+			upstream = nil
+		case err = <-downstream:
+			stream.SetTrailer(cs.Trailer())
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func forwardToAgent(src grpc.ServerStream, dst grpc.ClientStream) error {
+	for {
+		f := &frame{}
+		err := src.RecvMsg(f)
+		if err != nil {
+			return err
+		}
+		err = dst.SendMsg(f)
+		if err != nil {
+			// 发送失败的原因由 RecvMsg 返回
+			return io.EOF
+		}
+	}
+}
+
+func forwardToClient(src grpc.ClientStream, dst grpc.ServerStream) error {
+	md, err := src.Header()
+	if err == nil && len(md) > 0 {
+		err = dst.SendHeader(md)
+		if err != nil {
+			return err
+		}
+	}
+	for {
+		f := &frame{}
+		err = src.RecvMsg(f)
+		if err != nil {
+			return err
+		}
+		err = dst.SendMsg(f)
+		if err != nil {
+			return err
+		}
+	}
+}
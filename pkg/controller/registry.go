@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/controller"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+// AgentConn 一个在线的 agent
+type AgentConn struct {
+	// ID agent 标识, 为证书的 CN
+	ID          string
+	RemoteAddr  net.Addr
+	Transport   string
+	ConnectedAt time.Time
+	// Conn 调用 agent 上服务的 gRPC 连接
+	Conn *grpc.ClientConn
+
+	tunnel mux.Tunnel
+	mu     sync.Mutex
+	meta   *controller.AgentMeta
+}
+
+// Done 连接断开时关闭
+func (a *AgentConn) Done() <-chan struct{} {
+	return a.tunnel.Done()
+}
+
+// Close 断开与 agent 的连接
+func (a *AgentConn) Close() error {
+	_ = a.Conn.Close()
+	return a.tunnel.Close()
+}
+
+// Stats 返回连接的 stream 和流量统计
+func (a *AgentConn) Stats() mux.TunnelStats {
+	return a.tunnel.Stats()
+}
+
+// Meta 返回 agent 注册时上报的信息
+func (a *AgentConn) Meta() *controller.AgentMeta {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.meta
+}
+
+func (a *AgentConn) setMeta(meta *controller.AgentMeta) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.meta = proto.Clone(meta).(*controller.AgentMeta)
+}
+
+// Info 转换为 API 中的 AgentInfo
+func (a *AgentConn) Info() *controller.AgentInfo {
+	return &controller.AgentInfo{
+		Id:          a.ID,
+		RemoteAddr:  a.RemoteAddr.String(),
+		Transport:   a.Transport,
+		ConnectedAt: a.ConnectedAt.Unix(),
+		Meta:        a.Meta(),
+	}
+}
+
+// Registry 在线 agent 列表, 同一 ID 重复连接时旧的连接被关闭
+type Registry struct {
+	mu     sync.RWMutex
+	agents map[string]*AgentConn
+}
+
+// add 添加 agent, 连接断开后自动移除
+func (r *Registry) add(a *AgentConn) {
+	r.mu.Lock()
+	if r.agents == nil {
+		r.agents = make(map[string]*AgentConn)
+	}
+	old := r.agents[a.ID]
+	r.agents[a.ID] = a
+	r.mu.Unlock()
+	if old == a {
+		return
+	}
+	if old != nil {
+		_ = old.Close()
+	}
+	go func() {
+		<-a.Done()
+		r.remove(a)
+	}()
+}
+
+// remove 移除 agent, 已被新连接替换时不做处理
+func (r *Registry) remove(a *AgentConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.agents[a.ID] == a {
+		delete(r.agents, a.ID)
+	}
+}
+
+// Get 根据 ID 获取在线的 agent
+func (r *Registry) Get(id string) (*AgentConn, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.agents[id]
+	return a, ok
+}
+
+// List 按 ID 排序返回所有在线的 agent
+func (r *Registry) List() []*AgentConn {
+	r.mu.RLock()
+	list := make([]*AgentConn, 0, len(r.agents))
+	for _, a := range r.agents {
+		list = append(list, a)
+	}
+	r.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
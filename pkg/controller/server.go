@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+// defaultWebSocketPath 默认的 WebSocket 路径
+const defaultWebSocketPath = "/tianmen"
+
+// Server 监听 agent 连接和运维接口
+type Server struct {
+	Controller *Controller
+	ListenTLS  string
+	ListenQUIC string
+	// ListenWebSocket 接收 WebSocket 连接的 HTTPS 地址
+	ListenWebSocket string
+	// WebSocketPath WebSocket 的 HTTP 路径, 默认 /tianmen
+	WebSocketPath string
+	// TLSConfig agent 连接使用的 TLS 配置, 需要校验客户端证书
+	TLSConfig *tls.Config
+	// OperatorListen 运维接口地址, host:port 或 unix:///path/to/socket
+	OperatorListen string
+	// OperatorTLS 不为空时运维接口使用 TLS. 运维接口可以操作所有 agent,
+	// 监听 host:port 时必须设置并校验客户端证书
+	OperatorTLS *tls.Config
+}
+
+// Run 开始服务, 直到 ctx 结束或任一 listener 出错
+func (s *Server) Run(ctx context.Context) error {
+	var closers []func()
+	defer func() {
+		for _, c := range closers {
+			c()
+		}
+	}()
+	errCh := make(chan error, 5)
+
+	if s.ListenTLS != "" {
+		l, err := tls.Listen("tcp", s.ListenTLS, s.TLSConfig)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, func() { _ = l.Close() })
+		go func() { errCh <- s.Controller.ServeTLS(l) }()
+	}
+	if s.ListenQUIC != "" {
+		opts := s.Controller.QUIC.WithKeepAlive(s.Controller.KeepAlive)
+		l, err := mux.ListenQUIC(s.ListenQUIC, s.TLSConfig, opts)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, func() { _ = l.Close() })
+		go func() { errCh <- s.Controller.ServeQUIC(l) }()
+	}
+	if s.ListenWebSocket != "" {
+		closeWS, err := s.listenWebSocket(errCh)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, closeWS)
+	}
+
+	l, err := listenOperator(s.OperatorListen, s.OperatorTLS)
+	if err != nil {
+		return err
+	}
+	var opts []grpc.ServerOption
+	if s.OperatorTLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.OperatorTLS)))
+	}
+	gs := s.Controller.OperatorServer(opts...)
+	closers = append(closers, gs.Stop)
+	go func() { errCh <- gs.Serve(l) }()
+
+	defer func() {
+		for _, a := range s.Controller.Registry.List() {
+			_ = a.Close()
+		}
+	}()
+	select {
+	case err = <-errCh:
+		if err == nil {
+			err = errors.New("listener closed")
+		}
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// listenWebSocket 启动接收 WebSocket 连接的 HTTPS 服务
+func (s *Server) listenWebSocket(errCh chan<- error) (func(), error) {
+	conf := s.TLSConfig.Clone()
+	conf.NextProtos = []string{"http/1.1"}
+	l, err := tls.Listen("tcp", s.ListenWebSocket, conf)
+	if err != nil {
+		return nil, err
+	}
+	path := s.WebSocketPath
+	if path == "" {
+		path = defaultWebSocketPath
+	}
+	wsl := mux.NewWebSocketListener(l.Addr())
+	handler := http.NewServeMux()
+	handler.Handle(path, wsl)
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: handshakeTimeout}
+	go func() {
+		err := srv.Serve(l)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		errCh <- err
+	}()
+	go func() { errCh <- s.Controller.ServeWebSocket(wsl) }()
+	return func() {
+		_ = wsl.Close()
+		_ = srv.Close()
+	}, nil
+}
+
+// listenOperator 监听运维接口, unix socket 已存在时先删除.
+// TCP 地址没有其他访问控制, 只允许使用校验客户端证书的 TLS
+func listenOperator(addr string, conf *tls.Config) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		_ = os.Remove(path)
+		return net.Listen("unix", path)
+	}
+	if conf == nil || conf.ClientAuth != tls.RequireAndVerifyClientCert {
+		return nil, fmt.Errorf("operator %s: tls with client certificates is required for tcp", addr)
+	}
+	return net.Listen("tcp", addr)
+}
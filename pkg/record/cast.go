@@ -0,0 +1,193 @@
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// asciinema v2 事件类型
+const (
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r"
+)
+
+// Header asciinema v2 文件头
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event asciinema v2 事件, Time 为相对录制开始的秒数
+type Event struct {
+	Time float64
+	Type string
+	Data string
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Time, e.Type, e.Data})
+}
+
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var v []json.RawMessage
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	if len(v) != 3 {
+		return fmt.Errorf("invalid event: %s", b)
+	}
+	err = json.Unmarshal(v[0], &e.Time)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(v[1], &e.Type)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(v[2], &e.Data)
+}
+
+// Writer 写入 asciinema v2 格式的录像, 可以并发调用
+type Writer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	// pending 上次输出末尾不完整的 UTF-8 字符
+	pending map[string][]byte
+}
+
+// NewWriter 写入文件头并返回 Writer
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	start := time.Now()
+	h.Version = 2
+	if h.Timestamp == 0 {
+		h.Timestamp = start.Unix()
+	}
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(append(b, '\n'))
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:       w,
+		start:   start,
+		pending: make(map[string][]byte),
+	}, nil
+}
+
+// Output 记录输出事件
+func (w *Writer) Output(p []byte) error {
+	return w.data(EventOutput, p)
+}
+
+// Input 记录输入事件
+func (w *Writer) Input(p []byte) error {
+	return w.data(EventInput, p)
+}
+
+// Resize 记录终端大小变化
+func (w *Writer) Resize(cols, rows int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.event(EventResize, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (w *Writer) data(t string, p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p = append(w.pending[t], p...)
+	n := completeUTF8(p)
+	w.pending[t] = append([]byte(nil), p[n:]...)
+	if n == 0 {
+		return nil
+	}
+	return w.event(t, string(p[:n]))
+}
+
+func (w *Writer) event(t, data string) error {
+	b, err := json.Marshal(Event{
+		Time: float64(time.Since(w.start).Microseconds()) / 1e6,
+		Type: t,
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(b, '\n'))
+	return err
+}
+
+// completeUTF8 返回 p 中以完整 UTF-8 字符结尾的前缀长度
+func completeUTF8(p []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(p); i++ {
+		c := p[len(p)-i]
+		if !utf8.RuneStart(c) {
+			continue
+		}
+		if !utf8.FullRune(p[len(p)-i:]) {
+			return len(p) - i
+		}
+		break
+	}
+	return len(p)
+}
+
+// Reader 读取 asciinema v2 格式的录像
+type Reader struct {
+	Header Header
+	s      *bufio.Scanner
+}
+
+// NewReader 读取并解析文件头
+func NewReader(r io.Reader) (*Reader, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !s.Scan() {
+		if s.Err() != nil {
+			return nil, s.Err()
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	reader := &Reader{s: s}
+	err := json.Unmarshal(s.Bytes(), &reader.Header)
+	if err != nil {
+		return nil, err
+	}
+	if reader.Header.Version != 2 {
+		return nil, errors.New("unsupported asciicast version: " + strconv.Itoa(reader.Header.Version))
+	}
+	return reader, nil
+}
+
+// Next 读取下一个事件, 结束时返回 io.EOF
+func (r *Reader) Next() (Event, error) {
+	var e Event
+	for r.s.Scan() {
+		if len(r.s.Bytes()) == 0 {
+			continue
+		}
+		err := json.Unmarshal(r.s.Bytes(), &e)
+		return e, err
+	}
+	if r.s.Err() != nil {
+		return e, r.s.Err()
+	}
+	return e, io.EOF
+}
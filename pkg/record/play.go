@@ -0,0 +1,57 @@
+package record
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// PlayOptions 回放参数
+type PlayOptions struct {
+	// Speed 回放速度倍数, 为 0 时按 1 倍速回放
+	Speed float64
+	// IdleLimit 事件之间的最长等待时间, 为 0 时不限制
+	IdleLimit time.Duration
+}
+
+// Play 按录制时的时间间隔将输出事件写入 w
+func Play(ctx context.Context, r *Reader, w io.Writer, opts PlayOptions) error {
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	var last float64
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if e.Type != EventOutput {
+			continue
+		}
+		delay := time.Duration((e.Time - last) / speed * float64(time.Second))
+		last = e.Time
+		if opts.IdleLimit > 0 && delay > opts.IdleLimit {
+			delay = opts.IdleLimit
+		}
+		if delay > 0 {
+			timer.Reset(delay)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		_, err = io.WriteString(w, e.Data)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterReader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, Header{Width: 80, Height: 24, Env: map[string]string{"TERM": "xterm"}})
+	require.NoError(t, err)
+	require.NoError(t, w.Output([]byte("hello ")))
+	// 跨越两次写入的多字节字符
+	require.NoError(t, w.Output([]byte("世界"[:4])))
+	require.NoError(t, w.Output([]byte("世界"[4:])))
+	require.NoError(t, w.Input([]byte("ls\r")))
+	require.NoError(t, w.Resize(100, 40))
+
+	r, err := NewReader(buf)
+	require.NoError(t, err)
+	require.Equal(t, 2, r.Header.Version)
+	require.Equal(t, 80, r.Header.Width)
+	require.Equal(t, "xterm", r.Header.Env["TERM"])
+
+	var events []Event
+	for {
+		e, err := r.Next()
+		if err != nil {
+			break
+		}
+		events = append(events, e)
+	}
+	require.Len(t, events, 5)
+	require.Equal(t, "hello ", events[0].Data)
+	require.Equal(t, "世", events[1].Data)
+	require.Equal(t, "界", events[2].Data)
+	require.Equal(t, EventInput, events[3].Type)
+	require.Equal(t, Event{Time: events[4].Time, Type: EventResize, Data: "100x40"}, events[4])
+}
+
+func TestPlay(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	require.NoError(t, w.Output([]byte("foo")))
+	require.NoError(t, w.Input([]byte("x")))
+	require.NoError(t, w.Output([]byte("bar")))
+
+	r, err := NewReader(buf)
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	require.NoError(t, Play(context.Background(), r, out, PlayOptions{Speed: 10, IdleLimit: time.Millisecond}))
+	require.Equal(t, "foobar", out.String())
+}
+
+func TestRecorderRotate(t *testing.T) {
+	dir := t.TempDir()
+	r := &Recorder{Dir: dir, MaxFileSize: 200, MaxFiles: 2}
+	rec, err := r.Start("session", Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, rec.Output(bytes.Repeat([]byte("a"), 50)))
+	}
+	require.NoError(t, rec.Close())
+	require.ErrorIs(t, rec.Output([]byte("a")), os.ErrClosed)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.cast"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, name := range files {
+		f, err := os.Open(name)
+		require.NoError(t, err)
+		_, err = NewReader(f)
+		require.NoError(t, err)
+		_ = f.Close()
+	}
+}
+
+func TestRecorderCleanup(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.cast")
+	require.NoError(t, os.WriteFile(old, nil, 0o600))
+	require.NoError(t, os.Chtimes(old, time.Now(), time.Now().Add(-2*time.Hour)))
+
+	r := &Recorder{Dir: dir, MaxAge: time.Hour}
+	rec, err := r.Start("session", Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	require.NoError(t, rec.Close())
+	require.NoFileExists(t, old)
+}
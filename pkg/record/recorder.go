@@ -0,0 +1,212 @@
+package record
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder 将会话录像写入目录, 并按策略切分和清理录像文件
+type Recorder struct {
+	Dir string
+	// MaxFileSize 单个录像文件的最大字节数, 超过后切换到新文件, 为 0 时不限制
+	MaxFileSize int64
+	// MaxAge 录像文件的保留时长, 为 0 时不按时间清理
+	MaxAge time.Duration
+	// MaxFiles 目录中最多保留的录像文件数, 为 0 时不按数量清理
+	MaxFiles int
+
+	mu   sync.Mutex
+	open map[string]struct{}
+}
+
+// Start 开始一个新的录像, name 用于区分文件, 通常为会话 ID
+func (r *Recorder) Start(name string, h Header) (*Recording, error) {
+	err := os.MkdirAll(r.Dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Recording{
+		recorder: r,
+		name:     time.Now().Format("20060102-150405") + "-" + name,
+		header:   h,
+	}
+	err = rec.rotate()
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (r *Recorder) opened(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.open == nil {
+		r.open = make(map[string]struct{})
+	}
+	r.open[path] = struct{}{}
+}
+
+func (r *Recorder) closed(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.open, path)
+}
+
+// Cleanup 按 MaxAge 和 MaxFiles 删除旧的录像文件, 正在写入的文件不会被删除
+func (r *Recorder) Cleanup() error {
+	if r.MaxAge <= 0 && r.MaxFiles <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(r.Dir)
+	if err != nil {
+		return err
+	}
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+	var files []file
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".cast") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{path: filepath.Join(r.Dir, e.Name()), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, f := range files {
+		if _, ok := r.open[f.path]; ok {
+			continue
+		}
+		expired := r.MaxAge > 0 && time.Since(f.modTime) > r.MaxAge
+		if expired || (r.MaxFiles > 0 && i >= r.MaxFiles) {
+			_ = os.Remove(f.path)
+		}
+	}
+	return nil
+}
+
+// Recording 一个会话的录像, 文件超过 MaxFileSize 时切换到新的分段
+type Recording struct {
+	recorder *Recorder
+	name     string
+	header   Header
+
+	mu     sync.Mutex
+	part   int
+	path   string
+	file   *sizeWriter
+	writer *Writer
+}
+
+// sizeWriter 统计写入文件的字节数
+type sizeWriter struct {
+	*os.File
+	size int64
+}
+
+func (w *sizeWriter) Write(p []byte) (int, error) {
+	n, err := w.File.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (rec *Recording) rotate() error {
+	if rec.file != nil {
+		_ = rec.file.Close()
+		rec.recorder.closed(rec.path)
+	}
+	name := rec.name
+	if rec.part > 0 {
+		name = fmt.Sprintf("%s.%d", name, rec.part)
+	}
+	rec.part++
+	rec.path = filepath.Join(rec.recorder.Dir, name+".cast")
+	f, err := os.OpenFile(rec.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	rec.file = &sizeWriter{File: f}
+	rec.recorder.opened(rec.path)
+	rec.header.Timestamp = 0
+	rec.writer, err = NewWriter(rec.file, rec.header)
+	if err != nil {
+		return err
+	}
+	return rec.recorder.Cleanup()
+}
+
+// checkSize 检查录像是否已关闭, 当前分段超过 MaxFileSize 时切换文件
+func (rec *Recording) checkSize() error {
+	if rec.file == nil {
+		return os.ErrClosed
+	}
+	if rec.recorder.MaxFileSize > 0 && rec.file.size >= rec.recorder.MaxFileSize {
+		return rec.rotate()
+	}
+	return nil
+}
+
+// Output 记录输出事件
+func (rec *Recording) Output(p []byte) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	err := rec.checkSize()
+	if err != nil {
+		return err
+	}
+	return rec.writer.Output(p)
+}
+
+// Input 记录输入事件
+func (rec *Recording) Input(p []byte) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	err := rec.checkSize()
+	if err != nil {
+		return err
+	}
+	return rec.writer.Input(p)
+}
+
+// Resize 记录终端大小变化, 新的分段使用最新的大小作为文件头
+func (rec *Recording) Resize(cols, rows int) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.header.Width = cols
+	rec.header.Height = rows
+	err := rec.checkSize()
+	if err != nil {
+		return err
+	}
+	return rec.writer.Resize(cols, rows)
+}
+
+// Close 关闭当前分段文件
+func (rec *Recording) Close() error {
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.file == nil {
+		return nil
+	}
+	err := rec.file.Close()
+	rec.recorder.closed(rec.path)
+	rec.file = nil
+	return err
+}
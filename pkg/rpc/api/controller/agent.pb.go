@@ -0,0 +1,302 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.24.4
+// source: agent.proto
+
+package controller
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// AgentMeta agent 注册时上报的信息
+type AgentMeta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=Hostname,proto3" json:"Hostname,omitempty"`
+	OS            string                 `protobuf:"bytes,2,opt,name=OS,proto3" json:"OS,omitempty"`           // runtime.GOOS
+	Arch          string                 `protobuf:"bytes,3,opt,name=Arch,proto3" json:"Arch,omitempty"`       // runtime.GOARCH
+	Kernel        string                 `protobuf:"bytes,4,opt,name=Kernel,proto3" json:"Kernel,omitempty"`   // 内核版本
+	Version       string                 `protobuf:"bytes,5,opt,name=Version,proto3" json:"Version,omitempty"` // agent 版本
+	Labels        map[string]string      `protobuf:"bytes,6,rep,name=Labels,proto3" json:"Labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Services      []string               `protobuf:"bytes,7,rep,name=Services,proto3" json:"Services,omitempty"`     // agent 提供的 gRPC 服务
+	BootId        string                 `protobuf:"bytes,8,opt,name=BootId,proto3" json:"BootId,omitempty"`         // 每次开机不同, 用于识别重启
+	InstanceId    string                 `protobuf:"bytes,9,opt,name=InstanceId,proto3" json:"InstanceId,omitempty"` // agent 进程启动时生成, 重连时不变
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMeta) Reset() {
+	*x = AgentMeta{}
+	mi := &file_agent_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMeta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMeta) ProtoMessage() {}
+
+func (x *AgentMeta) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMeta.ProtoReflect.Descriptor instead.
+func (*AgentMeta) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AgentMeta) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *AgentMeta) GetOS() string {
+	if x != nil {
+		return x.OS
+	}
+	return ""
+}
+
+func (x *AgentMeta) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *AgentMeta) GetKernel() string {
+	if x != nil {
+		return x.Kernel
+	}
+	return ""
+}
+
+func (x *AgentMeta) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *AgentMeta) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *AgentMeta) GetServices() []string {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *AgentMeta) GetBootId() string {
+	if x != nil {
+		return x.BootId
+	}
+	return ""
+}
+
+func (x *AgentMeta) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Meta          *AgentMeta             `protobuf:"bytes,1,opt,name=Meta,proto3" json:"Meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_agent_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegisterRequest) GetMeta() *AgentMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"` // 控制端识别的 agent ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	mi := &file_agent_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RegisterResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_agent_proto protoreflect.FileDescriptor
+
+const file_agent_proto_rawDesc = "" +
+	"\n" +
+	"\vagent.proto\"\xbc\x02\n" +
+	"\tAgentMeta\x12\x1a\n" +
+	"\bHostname\x18\x01 \x01(\tR\bHostname\x12\x0e\n" +
+	"\x02OS\x18\x02 \x01(\tR\x02OS\x12\x12\n" +
+	"\x04Arch\x18\x03 \x01(\tR\x04Arch\x12\x16\n" +
+	"\x06Kernel\x18\x04 \x01(\tR\x06Kernel\x12\x18\n" +
+	"\aVersion\x18\x05 \x01(\tR\aVersion\x12.\n" +
+	"\x06Labels\x18\x06 \x03(\v2\x16.AgentMeta.LabelsEntryR\x06Labels\x12\x1a\n" +
+	"\bServices\x18\a \x03(\tR\bServices\x12\x16\n" +
+	"\x06BootId\x18\b \x01(\tR\x06BootId\x12\x1e\n" +
+	"\n" +
+	"InstanceId\x18\t \x01(\tR\n" +
+	"InstanceId\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"1\n" +
+	"\x0fRegisterRequest\x12\x1e\n" +
+	"\x04Meta\x18\x01 \x01(\v2\n" +
+	".AgentMetaR\x04Meta\"\"\n" +
+	"\x10RegisterResponse\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\tR\x02Id28\n" +
+	"\x05Agent\x12/\n" +
+	"\bRegister\x12\x10.RegisterRequest\x1a\x11.RegisterResponseB\x0eZ\f.;controllerb\x06proto3"
+
+var (
+	file_agent_proto_rawDescOnce sync.Once
+	file_agent_proto_rawDescData []byte
+)
+
+func file_agent_proto_rawDescGZIP() []byte {
+	file_agent_proto_rawDescOnce.Do(func() {
+		file_agent_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)))
+	})
+	return file_agent_proto_rawDescData
+}
+
+var file_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_agent_proto_goTypes = []any{
+	(*AgentMeta)(nil),        // 0: AgentMeta
+	(*RegisterRequest)(nil),  // 1: RegisterRequest
+	(*RegisterResponse)(nil), // 2: RegisterResponse
+	nil,                      // 3: AgentMeta.LabelsEntry
+}
+var file_agent_proto_depIdxs = []int32{
+	3, // 0: AgentMeta.Labels:type_name -> AgentMeta.LabelsEntry
+	0, // 1: RegisterRequest.Meta:type_name -> AgentMeta
+	1, // 2: Agent.Register:input_type -> RegisterRequest
+	2, // 3: Agent.Register:output_type -> RegisterResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_agent_proto_init() }
+func file_agent_proto_init() {
+	if File_agent_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_agent_proto_goTypes,
+		DependencyIndexes: file_agent_proto_depIdxs,
+		MessageInfos:      file_agent_proto_msgTypes,
+	}.Build()
+	File_agent_proto = out.File
+	file_agent_proto_goTypes = nil
+	file_agent_proto_depIdxs = nil
+}
@@ -0,0 +1,3 @@
+package controller
+
+//go:generate protoc --go_out=. --go-grpc_out=.  agent.proto registry.proto hub.proto remote_forward.proto
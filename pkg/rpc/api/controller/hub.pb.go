@@ -0,0 +1,457 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.24.4
+// source: hub.proto
+
+package controller
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PullConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=Version,proto3" json:"Version,omitempty"` // agent 当前配置的版本, 与控制端相同时不返回内容
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PullConfigRequest) Reset() {
+	*x = PullConfigRequest{}
+	mi := &file_hub_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PullConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PullConfigRequest) ProtoMessage() {}
+
+func (x *PullConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PullConfigRequest.ProtoReflect.Descriptor instead.
+func (*PullConfigRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PullConfigRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type PullConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=Version,proto3" json:"Version,omitempty"` // 配置内容的 SHA-256
+	Data          []byte                 `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+	NotModified   bool                   `protobuf:"varint,3,opt,name=NotModified,proto3" json:"NotModified,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PullConfigResponse) Reset() {
+	*x = PullConfigResponse{}
+	mi := &file_hub_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PullConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PullConfigResponse) ProtoMessage() {}
+
+func (x *PullConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PullConfigResponse.ProtoReflect.Descriptor instead.
+func (*PullConfigResponse) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PullConfigResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PullConfigResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *PullConfigResponse) GetNotModified() bool {
+	if x != nil {
+		return x.NotModified
+	}
+	return false
+}
+
+type DownloadArtifactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`      // 制品目录下的相对路径
+	Offset        int64                  `protobuf:"varint,2,opt,name=Offset,proto3" json:"Offset,omitempty"` // 从该偏移开始传输, 用于断点续传
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadArtifactRequest) Reset() {
+	*x = DownloadArtifactRequest{}
+	mi := &file_hub_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadArtifactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadArtifactRequest) ProtoMessage() {}
+
+func (x *DownloadArtifactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadArtifactRequest.ProtoReflect.Descriptor instead.
+func (*DownloadArtifactRequest) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DownloadArtifactRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DownloadArtifactRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// ArtifactChunk 第一个消息包含 Size 和 Sha256, 之后的消息只包含 Data
+type ArtifactChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=Size,proto3" json:"Size,omitempty"`    // 制品总大小
+	Sha256        string                 `protobuf:"bytes,2,opt,name=Sha256,proto3" json:"Sha256,omitempty"` // 整个制品的 SHA-256
+	Mode          uint32                 `protobuf:"varint,3,opt,name=Mode,proto3" json:"Mode,omitempty"`
+	Data          []byte                 `protobuf:"bytes,4,opt,name=Data,proto3" json:"Data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArtifactChunk) Reset() {
+	*x = ArtifactChunk{}
+	mi := &file_hub_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArtifactChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArtifactChunk) ProtoMessage() {}
+
+func (x *ArtifactChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArtifactChunk.ProtoReflect.Descriptor instead.
+func (*ArtifactChunk) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ArtifactChunk) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ArtifactChunk) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *ArtifactChunk) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *ArtifactChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type LogRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          int64                  `protobuf:"varint,1,opt,name=Time,proto3" json:"Time,omitempty"` // unix 纳秒
+	Level         string                 `protobuf:"bytes,2,opt,name=Level,proto3" json:"Level,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=Message,proto3" json:"Message,omitempty"`
+	Fields        map[string]string      `protobuf:"bytes,4,rep,name=Fields,proto3" json:"Fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogRecord) Reset() {
+	*x = LogRecord{}
+	mi := &file_hub_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogRecord) ProtoMessage() {}
+
+func (x *LogRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogRecord.ProtoReflect.Descriptor instead.
+func (*LogRecord) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LogRecord) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *LogRecord) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *LogRecord) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LogRecord) GetFields() map[string]string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type UploadLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=Count,proto3" json:"Count,omitempty"` // 接收的记录数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadLogsResponse) Reset() {
+	*x = UploadLogsResponse{}
+	mi := &file_hub_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadLogsResponse) ProtoMessage() {}
+
+func (x *UploadLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadLogsResponse.ProtoReflect.Descriptor instead.
+func (*UploadLogsResponse) Descriptor() ([]byte, []int) {
+	return file_hub_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UploadLogsResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+var File_hub_proto protoreflect.FileDescriptor
+
+const file_hub_proto_rawDesc = "" +
+	"\n" +
+	"\thub.proto\"-\n" +
+	"\x11PullConfigRequest\x12\x18\n" +
+	"\aVersion\x18\x01 \x01(\tR\aVersion\"d\n" +
+	"\x12PullConfigResponse\x12\x18\n" +
+	"\aVersion\x18\x01 \x01(\tR\aVersion\x12\x12\n" +
+	"\x04Data\x18\x02 \x01(\fR\x04Data\x12 \n" +
+	"\vNotModified\x18\x03 \x01(\bR\vNotModified\"E\n" +
+	"\x17DownloadArtifactRequest\x12\x12\n" +
+	"\x04Name\x18\x01 \x01(\tR\x04Name\x12\x16\n" +
+	"\x06Offset\x18\x02 \x01(\x03R\x06Offset\"c\n" +
+	"\rArtifactChunk\x12\x12\n" +
+	"\x04Size\x18\x01 \x01(\x03R\x04Size\x12\x16\n" +
+	"\x06Sha256\x18\x02 \x01(\tR\x06Sha256\x12\x12\n" +
+	"\x04Mode\x18\x03 \x01(\rR\x04Mode\x12\x12\n" +
+	"\x04Data\x18\x04 \x01(\fR\x04Data\"\xba\x01\n" +
+	"\tLogRecord\x12\x12\n" +
+	"\x04Time\x18\x01 \x01(\x03R\x04Time\x12\x14\n" +
+	"\x05Level\x18\x02 \x01(\tR\x05Level\x12\x18\n" +
+	"\aMessage\x18\x03 \x01(\tR\aMessage\x12.\n" +
+	"\x06Fields\x18\x04 \x03(\v2\x16.LogRecord.FieldsEntryR\x06Fields\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"*\n" +
+	"\x12UploadLogsResponse\x12\x14\n" +
+	"\x05Count\x18\x01 \x01(\x03R\x05Count29\n" +
+	"\x06Config\x12/\n" +
+	"\x04Pull\x12\x12.PullConfigRequest\x1a\x13.PullConfigResponse2C\n" +
+	"\tArtifacts\x126\n" +
+	"\bDownload\x12\x18.DownloadArtifactRequest\x1a\x0e.ArtifactChunk0\x0123\n" +
+	"\x04Logs\x12+\n" +
+	"\x06Upload\x12\n" +
+	".LogRecord\x1a\x13.UploadLogsResponse(\x01B\x0eZ\f.;controllerb\x06proto3"
+
+var (
+	file_hub_proto_rawDescOnce sync.Once
+	file_hub_proto_rawDescData []byte
+)
+
+func file_hub_proto_rawDescGZIP() []byte {
+	file_hub_proto_rawDescOnce.Do(func() {
+		file_hub_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_hub_proto_rawDesc), len(file_hub_proto_rawDesc)))
+	})
+	return file_hub_proto_rawDescData
+}
+
+var file_hub_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_hub_proto_goTypes = []any{
+	(*PullConfigRequest)(nil),       // 0: PullConfigRequest
+	(*PullConfigResponse)(nil),      // 1: PullConfigResponse
+	(*DownloadArtifactRequest)(nil), // 2: DownloadArtifactRequest
+	(*ArtifactChunk)(nil),           // 3: ArtifactChunk
+	(*LogRecord)(nil),               // 4: LogRecord
+	(*UploadLogsResponse)(nil),      // 5: UploadLogsResponse
+	nil,                             // 6: LogRecord.FieldsEntry
+}
+var file_hub_proto_depIdxs = []int32{
+	6, // 0: LogRecord.Fields:type_name -> LogRecord.FieldsEntry
+	0, // 1: Config.Pull:input_type -> PullConfigRequest
+	2, // 2: Artifacts.Download:input_type -> DownloadArtifactRequest
+	4, // 3: Logs.Upload:input_type -> LogRecord
+	1, // 4: Config.Pull:output_type -> PullConfigResponse
+	3, // 5: Artifacts.Download:output_type -> ArtifactChunk
+	5, // 6: Logs.Upload:output_type -> UploadLogsResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_hub_proto_init() }
+func file_hub_proto_init() {
+	if File_hub_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hub_proto_rawDesc), len(file_hub_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_hub_proto_goTypes,
+		DependencyIndexes: file_hub_proto_depIdxs,
+		MessageInfos:      file_hub_proto_msgTypes,
+	}.Build()
+	File_hub_proto = out.File
+	file_hub_proto_goTypes = nil
+	file_hub_proto_depIdxs = nil
+}
@@ -0,0 +1,333 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v4.24.4
+// source: hub.proto
+
+package controller
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Config_Pull_FullMethodName = "/Config/Pull"
+)
+
+// ConfigClient is the client API for Config service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Config 由控制端在 agent 连接上提供, agent 拉取控制端为其下发的配置
+type ConfigClient interface {
+	Pull(ctx context.Context, in *PullConfigRequest, opts ...grpc.CallOption) (*PullConfigResponse, error)
+}
+
+type configClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConfigClient(cc grpc.ClientConnInterface) ConfigClient {
+	return &configClient{cc}
+}
+
+func (c *configClient) Pull(ctx context.Context, in *PullConfigRequest, opts ...grpc.CallOption) (*PullConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PullConfigResponse)
+	err := c.cc.Invoke(ctx, Config_Pull_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConfigServer is the server API for Config service.
+// All implementations must embed UnimplementedConfigServer
+// for forward compatibility.
+//
+// Config 由控制端在 agent 连接上提供, agent 拉取控制端为其下发的配置
+type ConfigServer interface {
+	Pull(context.Context, *PullConfigRequest) (*PullConfigResponse, error)
+	mustEmbedUnimplementedConfigServer()
+}
+
+// UnimplementedConfigServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConfigServer struct{}
+
+func (UnimplementedConfigServer) Pull(context.Context, *PullConfigRequest) (*PullConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pull not implemented")
+}
+func (UnimplementedConfigServer) mustEmbedUnimplementedConfigServer() {}
+func (UnimplementedConfigServer) testEmbeddedByValue()                {}
+
+// UnsafeConfigServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConfigServer will
+// result in compilation errors.
+type UnsafeConfigServer interface {
+	mustEmbedUnimplementedConfigServer()
+}
+
+func RegisterConfigServer(s grpc.ServiceRegistrar, srv ConfigServer) {
+	// If the following call pancis, it indicates UnimplementedConfigServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Config_ServiceDesc, srv)
+}
+
+func _Config_Pull_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PullConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigServer).Pull(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Config_Pull_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigServer).Pull(ctx, req.(*PullConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Config_ServiceDesc is the grpc.ServiceDesc for Config service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Config_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Config",
+	HandlerType: (*ConfigServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Pull",
+			Handler:    _Config_Pull_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "hub.proto",
+}
+
+const (
+	Artifacts_Download_FullMethodName = "/Artifacts/Download"
+)
+
+// ArtifactsClient is the client API for Artifacts service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Artifacts 由控制端在 agent 连接上提供, agent 下载安装包等制品
+type ArtifactsClient interface {
+	Download(ctx context.Context, in *DownloadArtifactRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArtifactChunk], error)
+}
+
+type artifactsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewArtifactsClient(cc grpc.ClientConnInterface) ArtifactsClient {
+	return &artifactsClient{cc}
+}
+
+func (c *artifactsClient) Download(ctx context.Context, in *DownloadArtifactRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArtifactChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Artifacts_ServiceDesc.Streams[0], Artifacts_Download_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadArtifactRequest, ArtifactChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Artifacts_DownloadClient = grpc.ServerStreamingClient[ArtifactChunk]
+
+// ArtifactsServer is the server API for Artifacts service.
+// All implementations must embed UnimplementedArtifactsServer
+// for forward compatibility.
+//
+// Artifacts 由控制端在 agent 连接上提供, agent 下载安装包等制品
+type ArtifactsServer interface {
+	Download(*DownloadArtifactRequest, grpc.ServerStreamingServer[ArtifactChunk]) error
+	mustEmbedUnimplementedArtifactsServer()
+}
+
+// UnimplementedArtifactsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedArtifactsServer struct{}
+
+func (UnimplementedArtifactsServer) Download(*DownloadArtifactRequest, grpc.ServerStreamingServer[ArtifactChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedArtifactsServer) mustEmbedUnimplementedArtifactsServer() {}
+func (UnimplementedArtifactsServer) testEmbeddedByValue()                   {}
+
+// UnsafeArtifactsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ArtifactsServer will
+// result in compilation errors.
+type UnsafeArtifactsServer interface {
+	mustEmbedUnimplementedArtifactsServer()
+}
+
+func RegisterArtifactsServer(s grpc.ServiceRegistrar, srv ArtifactsServer) {
+	// If the following call pancis, it indicates UnimplementedArtifactsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Artifacts_ServiceDesc, srv)
+}
+
+func _Artifacts_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadArtifactRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ArtifactsServer).Download(m, &grpc.GenericServerStream[DownloadArtifactRequest, ArtifactChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Artifacts_DownloadServer = grpc.ServerStreamingServer[ArtifactChunk]
+
+// Artifacts_ServiceDesc is the grpc.ServiceDesc for Artifacts service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Artifacts_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Artifacts",
+	HandlerType: (*ArtifactsServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Download",
+			Handler:       _Artifacts_Download_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "hub.proto",
+}
+
+const (
+	Logs_Upload_FullMethodName = "/Logs/Upload"
+)
+
+// LogsClient is the client API for Logs service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Logs 由控制端在 agent 连接上提供, agent 上传日志
+type LogsClient interface {
+	Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[LogRecord, UploadLogsResponse], error)
+}
+
+type logsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogsClient(cc grpc.ClientConnInterface) LogsClient {
+	return &logsClient{cc}
+}
+
+func (c *logsClient) Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[LogRecord, UploadLogsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Logs_ServiceDesc.Streams[0], Logs_Upload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LogRecord, UploadLogsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Logs_UploadClient = grpc.ClientStreamingClient[LogRecord, UploadLogsResponse]
+
+// LogsServer is the server API for Logs service.
+// All implementations must embed UnimplementedLogsServer
+// for forward compatibility.
+//
+// Logs 由控制端在 agent 连接上提供, agent 上传日志
+type LogsServer interface {
+	Upload(grpc.ClientStreamingServer[LogRecord, UploadLogsResponse]) error
+	mustEmbedUnimplementedLogsServer()
+}
+
+// UnimplementedLogsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogsServer struct{}
+
+func (UnimplementedLogsServer) Upload(grpc.ClientStreamingServer[LogRecord, UploadLogsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedLogsServer) mustEmbedUnimplementedLogsServer() {}
+func (UnimplementedLogsServer) testEmbeddedByValue()              {}
+
+// UnsafeLogsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogsServer will
+// result in compilation errors.
+type UnsafeLogsServer interface {
+	mustEmbedUnimplementedLogsServer()
+}
+
+func RegisterLogsServer(s grpc.ServiceRegistrar, srv LogsServer) {
+	// If the following call pancis, it indicates UnimplementedLogsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Logs_ServiceDesc, srv)
+}
+
+func _Logs_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogsServer).Upload(&grpc.GenericServerStream[LogRecord, UploadLogsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Logs_UploadServer = grpc.ClientStreamingServer[LogRecord, UploadLogsResponse]
+
+// Logs_ServiceDesc is the grpc.ServiceDesc for Logs service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Logs_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Logs",
+	HandlerType: (*LogsServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Upload",
+			Handler:       _Logs_Upload_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "hub.proto",
+}
@@ -0,0 +1,220 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.24.4
+// source: remote_forward.proto
+
+package controller
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RemoteForwardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Agent         string                 `protobuf:"bytes,1,opt,name=Agent,proto3" json:"Agent,omitempty"`                 // agent ID
+	ListenNetwork string                 `protobuf:"bytes,2,opt,name=ListenNetwork,proto3" json:"ListenNetwork,omitempty"` // agent 上的监听, tcp 或 unix, 为空时为 tcp
+	ListenAddress string                 `protobuf:"bytes,3,opt,name=ListenAddress,proto3" json:"ListenAddress,omitempty"` // host:port 或 unix socket 路径
+	TargetNetwork string                 `protobuf:"bytes,4,opt,name=TargetNetwork,proto3" json:"TargetNetwork,omitempty"` // 控制端连接的目标, tcp 或 unix, 为空时为 tcp
+	TargetAddress string                 `protobuf:"bytes,5,opt,name=TargetAddress,proto3" json:"TargetAddress,omitempty"` // host:port 或 unix socket 路径
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteForwardRequest) Reset() {
+	*x = RemoteForwardRequest{}
+	mi := &file_remote_forward_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteForwardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteForwardRequest) ProtoMessage() {}
+
+func (x *RemoteForwardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_remote_forward_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteForwardRequest.ProtoReflect.Descriptor instead.
+func (*RemoteForwardRequest) Descriptor() ([]byte, []int) {
+	return file_remote_forward_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RemoteForwardRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *RemoteForwardRequest) GetListenNetwork() string {
+	if x != nil {
+		return x.ListenNetwork
+	}
+	return ""
+}
+
+func (x *RemoteForwardRequest) GetListenAddress() string {
+	if x != nil {
+		return x.ListenAddress
+	}
+	return ""
+}
+
+func (x *RemoteForwardRequest) GetTargetNetwork() string {
+	if x != nil {
+		return x.TargetNetwork
+	}
+	return ""
+}
+
+func (x *RemoteForwardRequest) GetTargetAddress() string {
+	if x != nil {
+		return x.TargetAddress
+	}
+	return ""
+}
+
+// RemoteForwardEvent 第一条消息的 Address 为 agent 实际监听的地址,
+// 之后每个转发失败的连接发送一条 Error
+type RemoteForwardEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=Error,proto3" json:"Error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteForwardEvent) Reset() {
+	*x = RemoteForwardEvent{}
+	mi := &file_remote_forward_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteForwardEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteForwardEvent) ProtoMessage() {}
+
+func (x *RemoteForwardEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_remote_forward_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteForwardEvent.ProtoReflect.Descriptor instead.
+func (*RemoteForwardEvent) Descriptor() ([]byte, []int) {
+	return file_remote_forward_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RemoteForwardEvent) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *RemoteForwardEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_remote_forward_proto protoreflect.FileDescriptor
+
+const file_remote_forward_proto_rawDesc = "" +
+	"\n" +
+	"\x14remote_forward.proto\"\xc4\x01\n" +
+	"\x14RemoteForwardRequest\x12\x14\n" +
+	"\x05Agent\x18\x01 \x01(\tR\x05Agent\x12$\n" +
+	"\rListenNetwork\x18\x02 \x01(\tR\rListenNetwork\x12$\n" +
+	"\rListenAddress\x18\x03 \x01(\tR\rListenAddress\x12$\n" +
+	"\rTargetNetwork\x18\x04 \x01(\tR\rTargetNetwork\x12$\n" +
+	"\rTargetAddress\x18\x05 \x01(\tR\rTargetAddress\"D\n" +
+	"\x12RemoteForwardEvent\x12\x18\n" +
+	"\aAddress\x18\x01 \x01(\tR\aAddress\x12\x14\n" +
+	"\x05Error\x18\x02 \x01(\tR\x05Error2H\n" +
+	"\rRemoteForward\x127\n" +
+	"\aForward\x12\x15.RemoteForwardRequest\x1a\x13.RemoteForwardEvent0\x01B\x0eZ\f.;controllerb\x06proto3"
+
+var (
+	file_remote_forward_proto_rawDescOnce sync.Once
+	file_remote_forward_proto_rawDescData []byte
+)
+
+func file_remote_forward_proto_rawDescGZIP() []byte {
+	file_remote_forward_proto_rawDescOnce.Do(func() {
+		file_remote_forward_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_remote_forward_proto_rawDesc), len(file_remote_forward_proto_rawDesc)))
+	})
+	return file_remote_forward_proto_rawDescData
+}
+
+var file_remote_forward_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_remote_forward_proto_goTypes = []any{
+	(*RemoteForwardRequest)(nil), // 0: RemoteForwardRequest
+	(*RemoteForwardEvent)(nil),   // 1: RemoteForwardEvent
+}
+var file_remote_forward_proto_depIdxs = []int32{
+	0, // 0: RemoteForward.Forward:input_type -> RemoteForwardRequest
+	1, // 1: RemoteForward.Forward:output_type -> RemoteForwardEvent
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_remote_forward_proto_init() }
+func file_remote_forward_proto_init() {
+	if File_remote_forward_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_remote_forward_proto_rawDesc), len(file_remote_forward_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_remote_forward_proto_goTypes,
+		DependencyIndexes: file_remote_forward_proto_depIdxs,
+		MessageInfos:      file_remote_forward_proto_msgTypes,
+	}.Build()
+	File_remote_forward_proto = out.File
+	file_remote_forward_proto_goTypes = nil
+	file_remote_forward_proto_depIdxs = nil
+}
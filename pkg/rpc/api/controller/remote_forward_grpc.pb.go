@@ -0,0 +1,130 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v4.24.4
+// source: remote_forward.proto
+
+package controller
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RemoteForward_Forward_FullMethodName = "/RemoteForward/Forward"
+)
+
+// RemoteForwardClient is the client API for RemoteForward service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RemoteForward 提供给运维人员, 在 agent 上监听, 接收的连接由控制端连接目标地址并转发
+type RemoteForwardClient interface {
+	// Forward stream 结束时 agent 停止监听并关闭所有经该监听接收的连接
+	Forward(ctx context.Context, in *RemoteForwardRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RemoteForwardEvent], error)
+}
+
+type remoteForwardClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRemoteForwardClient(cc grpc.ClientConnInterface) RemoteForwardClient {
+	return &remoteForwardClient{cc}
+}
+
+func (c *remoteForwardClient) Forward(ctx context.Context, in *RemoteForwardRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RemoteForwardEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoteForward_ServiceDesc.Streams[0], RemoteForward_Forward_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RemoteForwardRequest, RemoteForwardEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteForward_ForwardClient = grpc.ServerStreamingClient[RemoteForwardEvent]
+
+// RemoteForwardServer is the server API for RemoteForward service.
+// All implementations must embed UnimplementedRemoteForwardServer
+// for forward compatibility.
+//
+// RemoteForward 提供给运维人员, 在 agent 上监听, 接收的连接由控制端连接目标地址并转发
+type RemoteForwardServer interface {
+	// Forward stream 结束时 agent 停止监听并关闭所有经该监听接收的连接
+	Forward(*RemoteForwardRequest, grpc.ServerStreamingServer[RemoteForwardEvent]) error
+	mustEmbedUnimplementedRemoteForwardServer()
+}
+
+// UnimplementedRemoteForwardServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRemoteForwardServer struct{}
+
+func (UnimplementedRemoteForwardServer) Forward(*RemoteForwardRequest, grpc.ServerStreamingServer[RemoteForwardEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Forward not implemented")
+}
+func (UnimplementedRemoteForwardServer) mustEmbedUnimplementedRemoteForwardServer() {}
+func (UnimplementedRemoteForwardServer) testEmbeddedByValue()                       {}
+
+// UnsafeRemoteForwardServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RemoteForwardServer will
+// result in compilation errors.
+type UnsafeRemoteForwardServer interface {
+	mustEmbedUnimplementedRemoteForwardServer()
+}
+
+func RegisterRemoteForwardServer(s grpc.ServiceRegistrar, srv RemoteForwardServer) {
+	// If the following call pancis, it indicates UnimplementedRemoteForwardServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RemoteForward_ServiceDesc, srv)
+}
+
+func _RemoteForward_Forward_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RemoteForwardRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RemoteForwardServer).Forward(m, &grpc.GenericServerStream[RemoteForwardRequest, RemoteForwardEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteForward_ForwardServer = grpc.ServerStreamingServer[RemoteForwardEvent]
+
+// RemoteForward_ServiceDesc is the grpc.ServiceDesc for RemoteForward service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RemoteForward_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "RemoteForward",
+	HandlerType: (*RemoteForwardServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Forward",
+			Handler:       _RemoteForward_Forward_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "remote_forward.proto",
+}
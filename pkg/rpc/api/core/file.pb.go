@@ -0,0 +1,1231 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.24.4
+// source: file.proto
+
+package core
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FileMsgType int32
+
+const (
+	FileMsgType_FILE_MSG_TYPE_DATA   FileMsgType = 0 // 文件数据
+	FileMsgType_FILE_MSG_TYPE_UPLOAD FileMsgType = 1 // Upload 的第一条消息, 目标路径和文件属性
+	FileMsgType_FILE_MSG_TYPE_OFFSET FileMsgType = 2 // agent 已接收的字节数, 客户端从该偏移继续发送
+	FileMsgType_FILE_MSG_TYPE_INFO   FileMsgType = 3 // 文件属性, Download 的第一条消息, Upload 完成后 agent 的最后一条消息
+)
+
+// Enum value maps for FileMsgType.
+var (
+	FileMsgType_name = map[int32]string{
+		0: "FILE_MSG_TYPE_DATA",
+		1: "FILE_MSG_TYPE_UPLOAD",
+		2: "FILE_MSG_TYPE_OFFSET",
+		3: "FILE_MSG_TYPE_INFO",
+	}
+	FileMsgType_value = map[string]int32{
+		"FILE_MSG_TYPE_DATA":   0,
+		"FILE_MSG_TYPE_UPLOAD": 1,
+		"FILE_MSG_TYPE_OFFSET": 2,
+		"FILE_MSG_TYPE_INFO":   3,
+	}
+)
+
+func (x FileMsgType) Enum() *FileMsgType {
+	p := new(FileMsgType)
+	*p = x
+	return p
+}
+
+func (x FileMsgType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FileMsgType) Descriptor() protoreflect.EnumDescriptor {
+	return file_file_proto_enumTypes[0].Descriptor()
+}
+
+func (FileMsgType) Type() protoreflect.EnumType {
+	return &file_file_proto_enumTypes[0]
+}
+
+func (x FileMsgType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FileMsgType.Descriptor instead.
+func (FileMsgType) EnumDescriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{0}
+}
+
+type SyncMsgType int32
+
+const (
+	SyncMsgType_SYNC_MSG_TYPE_DATA         SyncMsgType = 0 // 文件差异中的新数据
+	SyncMsgType_SYNC_MSG_TYPE_START        SyncMsgType = 1 // Sync 的第一条消息, agent 上的目标目录和选项
+	SyncMsgType_SYNC_MSG_TYPE_ENTRY        SyncMsgType = 2 // 客户端清单中的一个文件或目录
+	SyncMsgType_SYNC_MSG_TYPE_MANIFEST_END SyncMsgType = 3 // 客户端清单结束
+	SyncMsgType_SYNC_MSG_TYPE_REQUEST      SyncMsgType = 4 // agent 请求一个文件的差异, 携带 agent 上已有文件的块校验和
+	SyncMsgType_SYNC_MSG_TYPE_COPY         SyncMsgType = 5 // 文件差异中复用 agent 上已有文件的块
+	SyncMsgType_SYNC_MSG_TYPE_FILE_END     SyncMsgType = 6 // 一个文件的差异结束
+	SyncMsgType_SYNC_MSG_TYPE_RESULT       SyncMsgType = 7 // agent 对一个路径的处理结果
+)
+
+// Enum value maps for SyncMsgType.
+var (
+	SyncMsgType_name = map[int32]string{
+		0: "SYNC_MSG_TYPE_DATA",
+		1: "SYNC_MSG_TYPE_START",
+		2: "SYNC_MSG_TYPE_ENTRY",
+		3: "SYNC_MSG_TYPE_MANIFEST_END",
+		4: "SYNC_MSG_TYPE_REQUEST",
+		5: "SYNC_MSG_TYPE_COPY",
+		6: "SYNC_MSG_TYPE_FILE_END",
+		7: "SYNC_MSG_TYPE_RESULT",
+	}
+	SyncMsgType_value = map[string]int32{
+		"SYNC_MSG_TYPE_DATA":         0,
+		"SYNC_MSG_TYPE_START":        1,
+		"SYNC_MSG_TYPE_ENTRY":        2,
+		"SYNC_MSG_TYPE_MANIFEST_END": 3,
+		"SYNC_MSG_TYPE_REQUEST":      4,
+		"SYNC_MSG_TYPE_COPY":         5,
+		"SYNC_MSG_TYPE_FILE_END":     6,
+		"SYNC_MSG_TYPE_RESULT":       7,
+	}
+)
+
+func (x SyncMsgType) Enum() *SyncMsgType {
+	p := new(SyncMsgType)
+	*p = x
+	return p
+}
+
+func (x SyncMsgType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SyncMsgType) Descriptor() protoreflect.EnumDescriptor {
+	return file_file_proto_enumTypes[1].Descriptor()
+}
+
+func (SyncMsgType) Type() protoreflect.EnumType {
+	return &file_file_proto_enumTypes[1]
+}
+
+func (x SyncMsgType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SyncMsgType.Descriptor instead.
+func (SyncMsgType) EnumDescriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{1}
+}
+
+type SyncAction int32
+
+const (
+	SyncAction_SYNC_ACTION_UNCHANGED SyncAction = 0
+	SyncAction_SYNC_ACTION_CREATED   SyncAction = 1
+	SyncAction_SYNC_ACTION_UPDATED   SyncAction = 2 // 内容已更新
+	SyncAction_SYNC_ACTION_ATTRS     SyncAction = 3 // 内容相同, 只更新了权限、属主或修改时间
+	SyncAction_SYNC_ACTION_DELETED   SyncAction = 4
+	SyncAction_SYNC_ACTION_FAILED    SyncAction = 5
+)
+
+// Enum value maps for SyncAction.
+var (
+	SyncAction_name = map[int32]string{
+		0: "SYNC_ACTION_UNCHANGED",
+		1: "SYNC_ACTION_CREATED",
+		2: "SYNC_ACTION_UPDATED",
+		3: "SYNC_ACTION_ATTRS",
+		4: "SYNC_ACTION_DELETED",
+		5: "SYNC_ACTION_FAILED",
+	}
+	SyncAction_value = map[string]int32{
+		"SYNC_ACTION_UNCHANGED": 0,
+		"SYNC_ACTION_CREATED":   1,
+		"SYNC_ACTION_UPDATED":   2,
+		"SYNC_ACTION_ATTRS":     3,
+		"SYNC_ACTION_DELETED":   4,
+		"SYNC_ACTION_FAILED":    5,
+	}
+)
+
+func (x SyncAction) Enum() *SyncAction {
+	p := new(SyncAction)
+	*p = x
+	return p
+}
+
+func (x SyncAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SyncAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_file_proto_enumTypes[2].Descriptor()
+}
+
+func (SyncAction) Type() protoreflect.EnumType {
+	return &file_file_proto_enumTypes[2]
+}
+
+func (x SyncAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SyncAction.Descriptor instead.
+func (SyncAction) EnumDescriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{2}
+}
+
+// FileInfo 文件属性
+type FileInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=Size,proto3" json:"Size,omitempty"`
+	Mode          uint32                 `protobuf:"varint,3,opt,name=Mode,proto3" json:"Mode,omitempty"`   // 权限位, 同 os.FileMode.Perm
+	Mtime         int64                  `protobuf:"varint,4,opt,name=Mtime,proto3" json:"Mtime,omitempty"` // 修改时间, unix 纳秒
+	Uid           uint32                 `protobuf:"varint,5,opt,name=Uid,proto3" json:"Uid,omitempty"`
+	Gid           uint32                 `protobuf:"varint,6,opt,name=Gid,proto3" json:"Gid,omitempty"`
+	Sha256        string                 `protobuf:"bytes,7,opt,name=Sha256,proto3" json:"Sha256,omitempty"` // 整个文件的 SHA-256, 目录为空
+	Dir           bool                   `protobuf:"varint,8,opt,name=Dir,proto3" json:"Dir,omitempty"`      // 目录, 只用于 Sync 的清单
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileInfo) Reset() {
+	*x = FileInfo{}
+	mi := &file_file_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileInfo) ProtoMessage() {}
+
+func (x *FileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileInfo.ProtoReflect.Descriptor instead.
+func (*FileInfo) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FileInfo) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileInfo) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *FileInfo) GetMtime() int64 {
+	if x != nil {
+		return x.Mtime
+	}
+	return 0
+}
+
+func (x *FileInfo) GetUid() uint32 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *FileInfo) GetGid() uint32 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *FileInfo) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *FileInfo) GetDir() bool {
+	if x != nil {
+		return x.Dir
+	}
+	return false
+}
+
+type FileUpload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Info          *FileInfo              `protobuf:"bytes,1,opt,name=Info,proto3" json:"Info,omitempty"`      // Path 为 agent 上的目标路径, Size 和 Sha256 用于校验
+	Resume        bool                   `protobuf:"varint,2,opt,name=Resume,proto3" json:"Resume,omitempty"` // 保留上次中断时已接收的数据, 从其末尾继续上传
+	Chown         bool                   `protobuf:"varint,3,opt,name=Chown,proto3" json:"Chown,omitempty"`   // 将属主设置为 Info 中的 Uid 和 Gid
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileUpload) Reset() {
+	*x = FileUpload{}
+	mi := &file_file_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileUpload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileUpload) ProtoMessage() {}
+
+func (x *FileUpload) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileUpload.ProtoReflect.Descriptor instead.
+func (*FileUpload) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FileUpload) GetInfo() *FileInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *FileUpload) GetResume() bool {
+	if x != nil {
+		return x.Resume
+	}
+	return false
+}
+
+func (x *FileUpload) GetChown() bool {
+	if x != nil {
+		return x.Chown
+	}
+	return false
+}
+
+type FileDownload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=Offset,proto3" json:"Offset,omitempty"` // 从该偏移开始传输, 用于断点续传
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileDownload) Reset() {
+	*x = FileDownload{}
+	mi := &file_file_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileDownload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileDownload) ProtoMessage() {}
+
+func (x *FileDownload) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileDownload.ProtoReflect.Descriptor instead.
+func (*FileDownload) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FileDownload) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileDownload) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type FileOffset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int64                  `protobuf:"varint,1,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileOffset) Reset() {
+	*x = FileOffset{}
+	mi := &file_file_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileOffset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileOffset) ProtoMessage() {}
+
+func (x *FileOffset) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileOffset.ProtoReflect.Descriptor instead.
+func (*FileOffset) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FileOffset) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// FileMsg 客户端关闭发送方向表示上传的数据结束
+type FileMsg struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  FileMsgType            `protobuf:"varint,1,opt,name=type,proto3,enum=FileMsgType" json:"type,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*FileMsg_Upload
+	//	*FileMsg_Offset
+	//	*FileMsg_Info
+	//	*FileMsg_Payload
+	Data          isFileMsg_Data `protobuf_oneof:"Data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileMsg) Reset() {
+	*x = FileMsg{}
+	mi := &file_file_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileMsg) ProtoMessage() {}
+
+func (x *FileMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileMsg.ProtoReflect.Descriptor instead.
+func (*FileMsg) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FileMsg) GetType() FileMsgType {
+	if x != nil {
+		return x.Type
+	}
+	return FileMsgType_FILE_MSG_TYPE_DATA
+}
+
+func (x *FileMsg) GetData() isFileMsg_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *FileMsg) GetUpload() *FileUpload {
+	if x != nil {
+		if x, ok := x.Data.(*FileMsg_Upload); ok {
+			return x.Upload
+		}
+	}
+	return nil
+}
+
+func (x *FileMsg) GetOffset() *FileOffset {
+	if x != nil {
+		if x, ok := x.Data.(*FileMsg_Offset); ok {
+			return x.Offset
+		}
+	}
+	return nil
+}
+
+func (x *FileMsg) GetInfo() *FileInfo {
+	if x != nil {
+		if x, ok := x.Data.(*FileMsg_Info); ok {
+			return x.Info
+		}
+	}
+	return nil
+}
+
+func (x *FileMsg) GetPayload() []byte {
+	if x != nil {
+		if x, ok := x.Data.(*FileMsg_Payload); ok {
+			return x.Payload
+		}
+	}
+	return nil
+}
+
+type isFileMsg_Data interface {
+	isFileMsg_Data()
+}
+
+type FileMsg_Upload struct {
+	Upload *FileUpload `protobuf:"bytes,2,opt,name=Upload,proto3,oneof"`
+}
+
+type FileMsg_Offset struct {
+	Offset *FileOffset `protobuf:"bytes,3,opt,name=Offset,proto3,oneof"`
+}
+
+type FileMsg_Info struct {
+	Info *FileInfo `protobuf:"bytes,4,opt,name=Info,proto3,oneof"`
+}
+
+type FileMsg_Payload struct {
+	Payload []byte `protobuf:"bytes,5,opt,name=Payload,proto3,oneof"`
+}
+
+func (*FileMsg_Upload) isFileMsg_Data() {}
+
+func (*FileMsg_Offset) isFileMsg_Data() {}
+
+func (*FileMsg_Info) isFileMsg_Data() {}
+
+func (*FileMsg_Payload) isFileMsg_Data() {}
+
+type SyncStart struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`      // agent 上的目标目录, 不存在时创建
+	Delete        bool                   `protobuf:"varint,2,opt,name=Delete,proto3" json:"Delete,omitempty"` // 删除目标目录中不在清单里的文件和目录
+	Chown         bool                   `protobuf:"varint,3,opt,name=Chown,proto3" json:"Chown,omitempty"`   // 将属主设置为清单中的 Uid 和 Gid
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncStart) Reset() {
+	*x = SyncStart{}
+	mi := &file_file_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncStart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncStart) ProtoMessage() {}
+
+func (x *SyncStart) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncStart.ProtoReflect.Descriptor instead.
+func (*SyncStart) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SyncStart) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SyncStart) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+func (x *SyncStart) GetChown() bool {
+	if x != nil {
+		return x.Chown
+	}
+	return false
+}
+
+// BlockSum 文件块的校验和, Weak 为滚动校验和, Strong 为 SHA-256 的前 16 字节
+type BlockSum struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Weak          uint32                 `protobuf:"varint,1,opt,name=Weak,proto3" json:"Weak,omitempty"`
+	Strong        []byte                 `protobuf:"bytes,2,opt,name=Strong,proto3" json:"Strong,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockSum) Reset() {
+	*x = BlockSum{}
+	mi := &file_file_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockSum) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockSum) ProtoMessage() {}
+
+func (x *BlockSum) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockSum.ProtoReflect.Descriptor instead.
+func (*BlockSum) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BlockSum) GetWeak() uint32 {
+	if x != nil {
+		return x.Weak
+	}
+	return 0
+}
+
+func (x *BlockSum) GetStrong() []byte {
+	if x != nil {
+		return x.Strong
+	}
+	return nil
+}
+
+type SyncRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"` // 清单中的路径
+	BlockSize     int64                  `protobuf:"varint,2,opt,name=BlockSize,proto3" json:"BlockSize,omitempty"`
+	Blocks        []*BlockSum            `protobuf:"bytes,3,rep,name=Blocks,proto3" json:"Blocks,omitempty"` // agent 上已有文件的块, 文件不存在时为空
+	Size          int64                  `protobuf:"varint,4,opt,name=Size,proto3" json:"Size,omitempty"`    // agent 上已有文件的大小
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncRequest) Reset() {
+	*x = SyncRequest{}
+	mi := &file_file_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncRequest) ProtoMessage() {}
+
+func (x *SyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SyncRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SyncRequest) GetBlockSize() int64 {
+	if x != nil {
+		return x.BlockSize
+	}
+	return 0
+}
+
+func (x *SyncRequest) GetBlocks() []*BlockSum {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *SyncRequest) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+// SyncCopy 复用已有文件从 Block 开始的 Count 个块
+type SyncCopy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Block         int64                  `protobuf:"varint,1,opt,name=Block,proto3" json:"Block,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=Count,proto3" json:"Count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncCopy) Reset() {
+	*x = SyncCopy{}
+	mi := &file_file_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncCopy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncCopy) ProtoMessage() {}
+
+func (x *SyncCopy) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncCopy.ProtoReflect.Descriptor instead.
+func (*SyncCopy) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SyncCopy) GetBlock() int64 {
+	if x != nil {
+		return x.Block
+	}
+	return 0
+}
+
+func (x *SyncCopy) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type SyncResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"` // 相对目标目录的路径, 以 / 分隔
+	Action        SyncAction             `protobuf:"varint,2,opt,name=Action,proto3,enum=SyncAction" json:"Action,omitempty"`
+	Literal       int64                  `protobuf:"varint,3,opt,name=Literal,proto3" json:"Literal,omitempty"` // 传输的新数据字节数
+	Matched       int64                  `protobuf:"varint,4,opt,name=Matched,proto3" json:"Matched,omitempty"` // 复用已有文件的字节数
+	Error         string                 `protobuf:"bytes,5,opt,name=Error,proto3" json:"Error,omitempty"`      // Action 为 FAILED 时的原因
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncResult) Reset() {
+	*x = SyncResult{}
+	mi := &file_file_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncResult) ProtoMessage() {}
+
+func (x *SyncResult) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncResult.ProtoReflect.Descriptor instead.
+func (*SyncResult) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SyncResult) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SyncResult) GetAction() SyncAction {
+	if x != nil {
+		return x.Action
+	}
+	return SyncAction_SYNC_ACTION_UNCHANGED
+}
+
+func (x *SyncResult) GetLiteral() int64 {
+	if x != nil {
+		return x.Literal
+	}
+	return 0
+}
+
+func (x *SyncResult) GetMatched() int64 {
+	if x != nil {
+		return x.Matched
+	}
+	return 0
+}
+
+func (x *SyncResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// SyncMsg 客户端依次发送 START、ENTRY 和 MANIFEST_END, agent 按清单顺序处理每个路径,
+// 对内容变化的文件发送 REQUEST, 客户端回复 DATA 和 COPY 组成的差异并以 FILE_END 结束.
+// agent 为每个路径发送 RESULT, 全部处理完成后结束 stream
+type SyncMsg struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  SyncMsgType            `protobuf:"varint,1,opt,name=type,proto3,enum=SyncMsgType" json:"type,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*SyncMsg_Start
+	//	*SyncMsg_Entry
+	//	*SyncMsg_Request
+	//	*SyncMsg_Copy
+	//	*SyncMsg_Payload
+	//	*SyncMsg_Result
+	Data          isSyncMsg_Data `protobuf_oneof:"Data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncMsg) Reset() {
+	*x = SyncMsg{}
+	mi := &file_file_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncMsg) ProtoMessage() {}
+
+func (x *SyncMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_file_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncMsg.ProtoReflect.Descriptor instead.
+func (*SyncMsg) Descriptor() ([]byte, []int) {
+	return file_file_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SyncMsg) GetType() SyncMsgType {
+	if x != nil {
+		return x.Type
+	}
+	return SyncMsgType_SYNC_MSG_TYPE_DATA
+}
+
+func (x *SyncMsg) GetData() isSyncMsg_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *SyncMsg) GetStart() *SyncStart {
+	if x != nil {
+		if x, ok := x.Data.(*SyncMsg_Start); ok {
+			return x.Start
+		}
+	}
+	return nil
+}
+
+func (x *SyncMsg) GetEntry() *FileInfo {
+	if x != nil {
+		if x, ok := x.Data.(*SyncMsg_Entry); ok {
+			return x.Entry
+		}
+	}
+	return nil
+}
+
+func (x *SyncMsg) GetRequest() *SyncRequest {
+	if x != nil {
+		if x, ok := x.Data.(*SyncMsg_Request); ok {
+			return x.Request
+		}
+	}
+	return nil
+}
+
+func (x *SyncMsg) GetCopy() *SyncCopy {
+	if x != nil {
+		if x, ok := x.Data.(*SyncMsg_Copy); ok {
+			return x.Copy
+		}
+	}
+	return nil
+}
+
+func (x *SyncMsg) GetPayload() []byte {
+	if x != nil {
+		if x, ok := x.Data.(*SyncMsg_Payload); ok {
+			return x.Payload
+		}
+	}
+	return nil
+}
+
+func (x *SyncMsg) GetResult() *SyncResult {
+	if x != nil {
+		if x, ok := x.Data.(*SyncMsg_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isSyncMsg_Data interface {
+	isSyncMsg_Data()
+}
+
+type SyncMsg_Start struct {
+	Start *SyncStart `protobuf:"bytes,2,opt,name=Start,proto3,oneof"`
+}
+
+type SyncMsg_Entry struct {
+	Entry *FileInfo `protobuf:"bytes,3,opt,name=Entry,proto3,oneof"` // Path 为相对源目录的路径, 以 / 分隔
+}
+
+type SyncMsg_Request struct {
+	Request *SyncRequest `protobuf:"bytes,4,opt,name=Request,proto3,oneof"`
+}
+
+type SyncMsg_Copy struct {
+	Copy *SyncCopy `protobuf:"bytes,5,opt,name=Copy,proto3,oneof"`
+}
+
+type SyncMsg_Payload struct {
+	Payload []byte `protobuf:"bytes,6,opt,name=Payload,proto3,oneof"`
+}
+
+type SyncMsg_Result struct {
+	Result *SyncResult `protobuf:"bytes,7,opt,name=Result,proto3,oneof"`
+}
+
+func (*SyncMsg_Start) isSyncMsg_Data() {}
+
+func (*SyncMsg_Entry) isSyncMsg_Data() {}
+
+func (*SyncMsg_Request) isSyncMsg_Data() {}
+
+func (*SyncMsg_Copy) isSyncMsg_Data() {}
+
+func (*SyncMsg_Payload) isSyncMsg_Data() {}
+
+func (*SyncMsg_Result) isSyncMsg_Data() {}
+
+var File_file_proto protoreflect.FileDescriptor
+
+const file_file_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"file.proto\"\xaa\x01\n" +
+	"\bFileInfo\x12\x12\n" +
+	"\x04Path\x18\x01 \x01(\tR\x04Path\x12\x12\n" +
+	"\x04Size\x18\x02 \x01(\x03R\x04Size\x12\x12\n" +
+	"\x04Mode\x18\x03 \x01(\rR\x04Mode\x12\x14\n" +
+	"\x05Mtime\x18\x04 \x01(\x03R\x05Mtime\x12\x10\n" +
+	"\x03Uid\x18\x05 \x01(\rR\x03Uid\x12\x10\n" +
+	"\x03Gid\x18\x06 \x01(\rR\x03Gid\x12\x16\n" +
+	"\x06Sha256\x18\a \x01(\tR\x06Sha256\x12\x10\n" +
+	"\x03Dir\x18\b \x01(\bR\x03Dir\"Y\n" +
+	"\n" +
+	"FileUpload\x12\x1d\n" +
+	"\x04Info\x18\x01 \x01(\v2\t.FileInfoR\x04Info\x12\x16\n" +
+	"\x06Resume\x18\x02 \x01(\bR\x06Resume\x12\x14\n" +
+	"\x05Chown\x18\x03 \x01(\bR\x05Chown\":\n" +
+	"\fFileDownload\x12\x12\n" +
+	"\x04Path\x18\x01 \x01(\tR\x04Path\x12\x16\n" +
+	"\x06Offset\x18\x02 \x01(\x03R\x06Offset\"$\n" +
+	"\n" +
+	"FileOffset\x12\x16\n" +
+	"\x06Offset\x18\x01 \x01(\x03R\x06Offset\"\xbe\x01\n" +
+	"\aFileMsg\x12 \n" +
+	"\x04type\x18\x01 \x01(\x0e2\f.FileMsgTypeR\x04type\x12%\n" +
+	"\x06Upload\x18\x02 \x01(\v2\v.FileUploadH\x00R\x06Upload\x12%\n" +
+	"\x06Offset\x18\x03 \x01(\v2\v.FileOffsetH\x00R\x06Offset\x12\x1f\n" +
+	"\x04Info\x18\x04 \x01(\v2\t.FileInfoH\x00R\x04Info\x12\x1a\n" +
+	"\aPayload\x18\x05 \x01(\fH\x00R\aPayloadB\x06\n" +
+	"\x04Data\"M\n" +
+	"\tSyncStart\x12\x12\n" +
+	"\x04Path\x18\x01 \x01(\tR\x04Path\x12\x16\n" +
+	"\x06Delete\x18\x02 \x01(\bR\x06Delete\x12\x14\n" +
+	"\x05Chown\x18\x03 \x01(\bR\x05Chown\"6\n" +
+	"\bBlockSum\x12\x12\n" +
+	"\x04Weak\x18\x01 \x01(\rR\x04Weak\x12\x16\n" +
+	"\x06Strong\x18\x02 \x01(\fR\x06Strong\"v\n" +
+	"\vSyncRequest\x12\x12\n" +
+	"\x04Path\x18\x01 \x01(\tR\x04Path\x12\x1c\n" +
+	"\tBlockSize\x18\x02 \x01(\x03R\tBlockSize\x12!\n" +
+	"\x06Blocks\x18\x03 \x03(\v2\t.BlockSumR\x06Blocks\x12\x12\n" +
+	"\x04Size\x18\x04 \x01(\x03R\x04Size\"6\n" +
+	"\bSyncCopy\x12\x14\n" +
+	"\x05Block\x18\x01 \x01(\x03R\x05Block\x12\x14\n" +
+	"\x05Count\x18\x02 \x01(\x03R\x05Count\"\x8f\x01\n" +
+	"\n" +
+	"SyncResult\x12\x12\n" +
+	"\x04Path\x18\x01 \x01(\tR\x04Path\x12#\n" +
+	"\x06Action\x18\x02 \x01(\x0e2\v.SyncActionR\x06Action\x12\x18\n" +
+	"\aLiteral\x18\x03 \x01(\x03R\aLiteral\x12\x18\n" +
+	"\aMatched\x18\x04 \x01(\x03R\aMatched\x12\x14\n" +
+	"\x05Error\x18\x05 \x01(\tR\x05Error\"\x88\x02\n" +
+	"\aSyncMsg\x12 \n" +
+	"\x04type\x18\x01 \x01(\x0e2\f.SyncMsgTypeR\x04type\x12\"\n" +
+	"\x05Start\x18\x02 \x01(\v2\n" +
+	".SyncStartH\x00R\x05Start\x12!\n" +
+	"\x05Entry\x18\x03 \x01(\v2\t.FileInfoH\x00R\x05Entry\x12(\n" +
+	"\aRequest\x18\x04 \x01(\v2\f.SyncRequestH\x00R\aRequest\x12\x1f\n" +
+	"\x04Copy\x18\x05 \x01(\v2\t.SyncCopyH\x00R\x04Copy\x12\x1a\n" +
+	"\aPayload\x18\x06 \x01(\fH\x00R\aPayload\x12%\n" +
+	"\x06Result\x18\a \x01(\v2\v.SyncResultH\x00R\x06ResultB\x06\n" +
+	"\x04Data*q\n" +
+	"\vFileMsgType\x12\x16\n" +
+	"\x12FILE_MSG_TYPE_DATA\x10\x00\x12\x18\n" +
+	"\x14FILE_MSG_TYPE_UPLOAD\x10\x01\x12\x18\n" +
+	"\x14FILE_MSG_TYPE_OFFSET\x10\x02\x12\x16\n" +
+	"\x12FILE_MSG_TYPE_INFO\x10\x03*\xe0\x01\n" +
+	"\vSyncMsgType\x12\x16\n" +
+	"\x12SYNC_MSG_TYPE_DATA\x10\x00\x12\x17\n" +
+	"\x13SYNC_MSG_TYPE_START\x10\x01\x12\x17\n" +
+	"\x13SYNC_MSG_TYPE_ENTRY\x10\x02\x12\x1e\n" +
+	"\x1aSYNC_MSG_TYPE_MANIFEST_END\x10\x03\x12\x19\n" +
+	"\x15SYNC_MSG_TYPE_REQUEST\x10\x04\x12\x16\n" +
+	"\x12SYNC_MSG_TYPE_COPY\x10\x05\x12\x1a\n" +
+	"\x16SYNC_MSG_TYPE_FILE_END\x10\x06\x12\x18\n" +
+	"\x14SYNC_MSG_TYPE_RESULT\x10\a*\xa1\x01\n" +
+	"\n" +
+	"SyncAction\x12\x19\n" +
+	"\x15SYNC_ACTION_UNCHANGED\x10\x00\x12\x17\n" +
+	"\x13SYNC_ACTION_CREATED\x10\x01\x12\x17\n" +
+	"\x13SYNC_ACTION_UPDATED\x10\x02\x12\x15\n" +
+	"\x11SYNC_ACTION_ATTRS\x10\x03\x12\x17\n" +
+	"\x13SYNC_ACTION_DELETED\x10\x04\x12\x16\n" +
+	"\x12SYNC_ACTION_FAILED\x10\x052w\n" +
+	"\fFileTransfer\x12 \n" +
+	"\x06Upload\x12\b.FileMsg\x1a\b.FileMsg(\x010\x01\x12%\n" +
+	"\bDownload\x12\r.FileDownload\x1a\b.FileMsg0\x01\x12\x1e\n" +
+	"\x04Sync\x12\b.SyncMsg\x1a\b.SyncMsg(\x010\x01B\bZ\x06.;coreb\x06proto3"
+
+var (
+	file_file_proto_rawDescOnce sync.Once
+	file_file_proto_rawDescData []byte
+)
+
+func file_file_proto_rawDescGZIP() []byte {
+	file_file_proto_rawDescOnce.Do(func() {
+		file_file_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_file_proto_rawDesc), len(file_file_proto_rawDesc)))
+	})
+	return file_file_proto_rawDescData
+}
+
+var file_file_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_file_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_file_proto_goTypes = []any{
+	(FileMsgType)(0),     // 0: FileMsgType
+	(SyncMsgType)(0),     // 1: SyncMsgType
+	(SyncAction)(0),      // 2: SyncAction
+	(*FileInfo)(nil),     // 3: FileInfo
+	(*FileUpload)(nil),   // 4: FileUpload
+	(*FileDownload)(nil), // 5: FileDownload
+	(*FileOffset)(nil),   // 6: FileOffset
+	(*FileMsg)(nil),      // 7: FileMsg
+	(*SyncStart)(nil),    // 8: SyncStart
+	(*BlockSum)(nil),     // 9: BlockSum
+	(*SyncRequest)(nil),  // 10: SyncRequest
+	(*SyncCopy)(nil),     // 11: SyncCopy
+	(*SyncResult)(nil),   // 12: SyncResult
+	(*SyncMsg)(nil),      // 13: SyncMsg
+}
+var file_file_proto_depIdxs = []int32{
+	3,  // 0: FileUpload.Info:type_name -> FileInfo
+	0,  // 1: FileMsg.type:type_name -> FileMsgType
+	4,  // 2: FileMsg.Upload:type_name -> FileUpload
+	6,  // 3: FileMsg.Offset:type_name -> FileOffset
+	3,  // 4: FileMsg.Info:type_name -> FileInfo
+	9,  // 5: SyncRequest.Blocks:type_name -> BlockSum
+	2,  // 6: SyncResult.Action:type_name -> SyncAction
+	1,  // 7: SyncMsg.type:type_name -> SyncMsgType
+	8,  // 8: SyncMsg.Start:type_name -> SyncStart
+	3,  // 9: SyncMsg.Entry:type_name -> FileInfo
+	10, // 10: SyncMsg.Request:type_name -> SyncRequest
+	11, // 11: SyncMsg.Copy:type_name -> SyncCopy
+	12, // 12: SyncMsg.Result:type_name -> SyncResult
+	7,  // 13: FileTransfer.Upload:input_type -> FileMsg
+	5,  // 14: FileTransfer.Download:input_type -> FileDownload
+	13, // 15: FileTransfer.Sync:input_type -> SyncMsg
+	7,  // 16: FileTransfer.Upload:output_type -> FileMsg
+	7,  // 17: FileTransfer.Download:output_type -> FileMsg
+	13, // 18: FileTransfer.Sync:output_type -> SyncMsg
+	16, // [16:19] is the sub-list for method output_type
+	13, // [13:16] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_file_proto_init() }
+func file_file_proto_init() {
+	if File_file_proto != nil {
+		return
+	}
+	file_file_proto_msgTypes[4].OneofWrappers = []any{
+		(*FileMsg_Upload)(nil),
+		(*FileMsg_Offset)(nil),
+		(*FileMsg_Info)(nil),
+		(*FileMsg_Payload)(nil),
+	}
+	file_file_proto_msgTypes[10].OneofWrappers = []any{
+		(*SyncMsg_Start)(nil),
+		(*SyncMsg_Entry)(nil),
+		(*SyncMsg_Request)(nil),
+		(*SyncMsg_Copy)(nil),
+		(*SyncMsg_Payload)(nil),
+		(*SyncMsg_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_file_proto_rawDesc), len(file_file_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_file_proto_goTypes,
+		DependencyIndexes: file_file_proto_depIdxs,
+		EnumInfos:         file_file_proto_enumTypes,
+		MessageInfos:      file_file_proto_msgTypes,
+	}.Build()
+	File_file_proto = out.File
+	file_file_proto_goTypes = nil
+	file_file_proto_depIdxs = nil
+}
@@ -0,0 +1,198 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v4.24.4
+// source: file.proto
+
+package core
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FileTransfer_Upload_FullMethodName   = "/FileTransfer/Upload"
+	FileTransfer_Download_FullMethodName = "/FileTransfer/Download"
+	FileTransfer_Sync_FullMethodName     = "/FileTransfer/Sync"
+)
+
+// FileTransferClient is the client API for FileTransfer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FileTransfer 由 agent 提供, 在客户端和 agent 之间传输文件
+type FileTransferClient interface {
+	// Upload 数据写入临时文件, 校验大小和 SHA-256 并设置属性后重命名为目标路径
+	Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[FileMsg, FileMsg], error)
+	// Download 第一条消息为 INFO, 之后为从 Offset 开始的数据
+	Download(ctx context.Context, in *FileDownload, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileMsg], error)
+	// Sync 将客户端的目录同步到 agent, 只传输内容变化的文件与已有文件的差异
+	Sync(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncMsg, SyncMsg], error)
+}
+
+type fileTransferClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFileTransferClient(cc grpc.ClientConnInterface) FileTransferClient {
+	return &fileTransferClient{cc}
+}
+
+func (c *fileTransferClient) Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[FileMsg, FileMsg], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FileTransfer_ServiceDesc.Streams[0], FileTransfer_Upload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FileMsg, FileMsg]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransfer_UploadClient = grpc.BidiStreamingClient[FileMsg, FileMsg]
+
+func (c *fileTransferClient) Download(ctx context.Context, in *FileDownload, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileMsg], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FileTransfer_ServiceDesc.Streams[1], FileTransfer_Download_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FileDownload, FileMsg]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransfer_DownloadClient = grpc.ServerStreamingClient[FileMsg]
+
+func (c *fileTransferClient) Sync(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncMsg, SyncMsg], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FileTransfer_ServiceDesc.Streams[2], FileTransfer_Sync_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SyncMsg, SyncMsg]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransfer_SyncClient = grpc.BidiStreamingClient[SyncMsg, SyncMsg]
+
+// FileTransferServer is the server API for FileTransfer service.
+// All implementations must embed UnimplementedFileTransferServer
+// for forward compatibility.
+//
+// FileTransfer 由 agent 提供, 在客户端和 agent 之间传输文件
+type FileTransferServer interface {
+	// Upload 数据写入临时文件, 校验大小和 SHA-256 并设置属性后重命名为目标路径
+	Upload(grpc.BidiStreamingServer[FileMsg, FileMsg]) error
+	// Download 第一条消息为 INFO, 之后为从 Offset 开始的数据
+	Download(*FileDownload, grpc.ServerStreamingServer[FileMsg]) error
+	// Sync 将客户端的目录同步到 agent, 只传输内容变化的文件与已有文件的差异
+	Sync(grpc.BidiStreamingServer[SyncMsg, SyncMsg]) error
+	mustEmbedUnimplementedFileTransferServer()
+}
+
+// UnimplementedFileTransferServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFileTransferServer struct{}
+
+func (UnimplementedFileTransferServer) Upload(grpc.BidiStreamingServer[FileMsg, FileMsg]) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedFileTransferServer) Download(*FileDownload, grpc.ServerStreamingServer[FileMsg]) error {
+	return status.Errorf(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedFileTransferServer) Sync(grpc.BidiStreamingServer[SyncMsg, SyncMsg]) error {
+	return status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
+func (UnimplementedFileTransferServer) mustEmbedUnimplementedFileTransferServer() {}
+func (UnimplementedFileTransferServer) testEmbeddedByValue()                      {}
+
+// UnsafeFileTransferServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FileTransferServer will
+// result in compilation errors.
+type UnsafeFileTransferServer interface {
+	mustEmbedUnimplementedFileTransferServer()
+}
+
+func RegisterFileTransferServer(s grpc.ServiceRegistrar, srv FileTransferServer) {
+	// If the following call pancis, it indicates UnimplementedFileTransferServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FileTransfer_ServiceDesc, srv)
+}
+
+func _FileTransfer_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FileTransferServer).Upload(&grpc.GenericServerStream[FileMsg, FileMsg]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransfer_UploadServer = grpc.BidiStreamingServer[FileMsg, FileMsg]
+
+func _FileTransfer_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FileDownload)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FileTransferServer).Download(m, &grpc.GenericServerStream[FileDownload, FileMsg]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransfer_DownloadServer = grpc.ServerStreamingServer[FileMsg]
+
+func _FileTransfer_Sync_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FileTransferServer).Sync(&grpc.GenericServerStream[SyncMsg, SyncMsg]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileTransfer_SyncServer = grpc.BidiStreamingServer[SyncMsg, SyncMsg]
+
+// FileTransfer_ServiceDesc is the grpc.ServiceDesc for FileTransfer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FileTransfer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "FileTransfer",
+	HandlerType: (*FileTransferServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Upload",
+			Handler:       _FileTransfer_Upload_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Download",
+			Handler:       _FileTransfer_Download_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Sync",
+			Handler:       _FileTransfer_Sync_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "file.proto",
+}
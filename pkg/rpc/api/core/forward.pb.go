@@ -0,0 +1,665 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.24.4
+// source: forward.proto
+
+package core
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ForwardMsgType int32
+
+const (
+	ForwardMsgType_FORWARD_MSG_TYPE_DATA      ForwardMsgType = 0 // 转发的数据, udp 连接中一条消息为一个数据报
+	ForwardMsgType_FORWARD_MSG_TYPE_DIAL      ForwardMsgType = 1 // 客户端的第一条消息, 请求 agent 连接目标
+	ForwardMsgType_FORWARD_MSG_TYPE_CONNECTED ForwardMsgType = 2 // agent 已连接目标, 之后双方只发送数据
+	ForwardMsgType_FORWARD_MSG_TYPE_LISTENING ForwardMsgType = 3 // Listen 的第一条消息, agent 已开始监听
+	ForwardMsgType_FORWARD_MSG_TYPE_ACCEPTED  ForwardMsgType = 4 // agent 的监听接收了新的连接
+	ForwardMsgType_FORWARD_MSG_TYPE_ATTACH    ForwardMsgType = 5 // Accept 的第一条消息, 认领 ACCEPTED 中的连接
+)
+
+// Enum value maps for ForwardMsgType.
+var (
+	ForwardMsgType_name = map[int32]string{
+		0: "FORWARD_MSG_TYPE_DATA",
+		1: "FORWARD_MSG_TYPE_DIAL",
+		2: "FORWARD_MSG_TYPE_CONNECTED",
+		3: "FORWARD_MSG_TYPE_LISTENING",
+		4: "FORWARD_MSG_TYPE_ACCEPTED",
+		5: "FORWARD_MSG_TYPE_ATTACH",
+	}
+	ForwardMsgType_value = map[string]int32{
+		"FORWARD_MSG_TYPE_DATA":      0,
+		"FORWARD_MSG_TYPE_DIAL":      1,
+		"FORWARD_MSG_TYPE_CONNECTED": 2,
+		"FORWARD_MSG_TYPE_LISTENING": 3,
+		"FORWARD_MSG_TYPE_ACCEPTED":  4,
+		"FORWARD_MSG_TYPE_ATTACH":    5,
+	}
+)
+
+func (x ForwardMsgType) Enum() *ForwardMsgType {
+	p := new(ForwardMsgType)
+	*p = x
+	return p
+}
+
+func (x ForwardMsgType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ForwardMsgType) Descriptor() protoreflect.EnumDescriptor {
+	return file_forward_proto_enumTypes[0].Descriptor()
+}
+
+func (ForwardMsgType) Type() protoreflect.EnumType {
+	return &file_forward_proto_enumTypes[0]
+}
+
+func (x ForwardMsgType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ForwardMsgType.Descriptor instead.
+func (ForwardMsgType) EnumDescriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{0}
+}
+
+type ForwardDial struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=Network,proto3" json:"Network,omitempty"` // tcp、udp 或 unix, 为空时为 tcp
+	Address       string                 `protobuf:"bytes,2,opt,name=Address,proto3" json:"Address,omitempty"` // host:port 或 unix socket 路径
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardDial) Reset() {
+	*x = ForwardDial{}
+	mi := &file_forward_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardDial) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardDial) ProtoMessage() {}
+
+func (x *ForwardDial) ProtoReflect() protoreflect.Message {
+	mi := &file_forward_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardDial.ProtoReflect.Descriptor instead.
+func (*ForwardDial) Descriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ForwardDial) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ForwardDial) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type ForwardConnected struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LocalAddr     string                 `protobuf:"bytes,1,opt,name=LocalAddr,proto3" json:"LocalAddr,omitempty"`   // agent 连接目标使用的本地地址
+	RemoteAddr    string                 `protobuf:"bytes,2,opt,name=RemoteAddr,proto3" json:"RemoteAddr,omitempty"` // 目标地址
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardConnected) Reset() {
+	*x = ForwardConnected{}
+	mi := &file_forward_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardConnected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardConnected) ProtoMessage() {}
+
+func (x *ForwardConnected) ProtoReflect() protoreflect.Message {
+	mi := &file_forward_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardConnected.ProtoReflect.Descriptor instead.
+func (*ForwardConnected) Descriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ForwardConnected) GetLocalAddr() string {
+	if x != nil {
+		return x.LocalAddr
+	}
+	return ""
+}
+
+func (x *ForwardConnected) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+type ForwardListen struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       string                 `protobuf:"bytes,1,opt,name=Network,proto3" json:"Network,omitempty"` // tcp 或 unix, 为空时为 tcp
+	Address       string                 `protobuf:"bytes,2,opt,name=Address,proto3" json:"Address,omitempty"` // agent 上的监听地址, host:port 或 unix socket 路径
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardListen) Reset() {
+	*x = ForwardListen{}
+	mi := &file_forward_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardListen) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardListen) ProtoMessage() {}
+
+func (x *ForwardListen) ProtoReflect() protoreflect.Message {
+	mi := &file_forward_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardListen.ProtoReflect.Descriptor instead.
+func (*ForwardListen) Descriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ForwardListen) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ForwardListen) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type ForwardListening struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"` // 实际监听的地址
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardListening) Reset() {
+	*x = ForwardListening{}
+	mi := &file_forward_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardListening) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardListening) ProtoMessage() {}
+
+func (x *ForwardListening) ProtoReflect() protoreflect.Message {
+	mi := &file_forward_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardListening.ProtoReflect.Descriptor instead.
+func (*ForwardListening) Descriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ForwardListening) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type ForwardAccepted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`                 // 客户端使用该 ID 调用 Accept
+	RemoteAddr    string                 `protobuf:"bytes,2,opt,name=RemoteAddr,proto3" json:"RemoteAddr,omitempty"` // 连接到 agent 监听地址的对端
+	LocalAddr     string                 `protobuf:"bytes,3,opt,name=LocalAddr,proto3" json:"LocalAddr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardAccepted) Reset() {
+	*x = ForwardAccepted{}
+	mi := &file_forward_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardAccepted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardAccepted) ProtoMessage() {}
+
+func (x *ForwardAccepted) ProtoReflect() protoreflect.Message {
+	mi := &file_forward_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardAccepted.ProtoReflect.Descriptor instead.
+func (*ForwardAccepted) Descriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ForwardAccepted) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ForwardAccepted) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *ForwardAccepted) GetLocalAddr() string {
+	if x != nil {
+		return x.LocalAddr
+	}
+	return ""
+}
+
+type ForwardAttach struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardAttach) Reset() {
+	*x = ForwardAttach{}
+	mi := &file_forward_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardAttach) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardAttach) ProtoMessage() {}
+
+func (x *ForwardAttach) ProtoReflect() protoreflect.Message {
+	mi := &file_forward_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardAttach.ProtoReflect.Descriptor instead.
+func (*ForwardAttach) Descriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ForwardAttach) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// ForwardMsg 客户端关闭发送方向表示不再写入, agent 关闭目标连接的写方向;
+// 目标连接读取结束后 agent 结束 stream
+type ForwardMsg struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  ForwardMsgType         `protobuf:"varint,1,opt,name=type,proto3,enum=ForwardMsgType" json:"type,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*ForwardMsg_Dial
+	//	*ForwardMsg_Connected
+	//	*ForwardMsg_Payload
+	//	*ForwardMsg_Listening
+	//	*ForwardMsg_Accepted
+	//	*ForwardMsg_Attach
+	Data          isForwardMsg_Data `protobuf_oneof:"Data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForwardMsg) Reset() {
+	*x = ForwardMsg{}
+	mi := &file_forward_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForwardMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwardMsg) ProtoMessage() {}
+
+func (x *ForwardMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_forward_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwardMsg.ProtoReflect.Descriptor instead.
+func (*ForwardMsg) Descriptor() ([]byte, []int) {
+	return file_forward_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ForwardMsg) GetType() ForwardMsgType {
+	if x != nil {
+		return x.Type
+	}
+	return ForwardMsgType_FORWARD_MSG_TYPE_DATA
+}
+
+func (x *ForwardMsg) GetData() isForwardMsg_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ForwardMsg) GetDial() *ForwardDial {
+	if x != nil {
+		if x, ok := x.Data.(*ForwardMsg_Dial); ok {
+			return x.Dial
+		}
+	}
+	return nil
+}
+
+func (x *ForwardMsg) GetConnected() *ForwardConnected {
+	if x != nil {
+		if x, ok := x.Data.(*ForwardMsg_Connected); ok {
+			return x.Connected
+		}
+	}
+	return nil
+}
+
+func (x *ForwardMsg) GetPayload() []byte {
+	if x != nil {
+		if x, ok := x.Data.(*ForwardMsg_Payload); ok {
+			return x.Payload
+		}
+	}
+	return nil
+}
+
+func (x *ForwardMsg) GetListening() *ForwardListening {
+	if x != nil {
+		if x, ok := x.Data.(*ForwardMsg_Listening); ok {
+			return x.Listening
+		}
+	}
+	return nil
+}
+
+func (x *ForwardMsg) GetAccepted() *ForwardAccepted {
+	if x != nil {
+		if x, ok := x.Data.(*ForwardMsg_Accepted); ok {
+			return x.Accepted
+		}
+	}
+	return nil
+}
+
+func (x *ForwardMsg) GetAttach() *ForwardAttach {
+	if x != nil {
+		if x, ok := x.Data.(*ForwardMsg_Attach); ok {
+			return x.Attach
+		}
+	}
+	return nil
+}
+
+type isForwardMsg_Data interface {
+	isForwardMsg_Data()
+}
+
+type ForwardMsg_Dial struct {
+	Dial *ForwardDial `protobuf:"bytes,2,opt,name=Dial,proto3,oneof"`
+}
+
+type ForwardMsg_Connected struct {
+	Connected *ForwardConnected `protobuf:"bytes,3,opt,name=Connected,proto3,oneof"`
+}
+
+type ForwardMsg_Payload struct {
+	Payload []byte `protobuf:"bytes,4,opt,name=Payload,proto3,oneof"`
+}
+
+type ForwardMsg_Listening struct {
+	Listening *ForwardListening `protobuf:"bytes,5,opt,name=Listening,proto3,oneof"`
+}
+
+type ForwardMsg_Accepted struct {
+	Accepted *ForwardAccepted `protobuf:"bytes,6,opt,name=Accepted,proto3,oneof"`
+}
+
+type ForwardMsg_Attach struct {
+	Attach *ForwardAttach `protobuf:"bytes,7,opt,name=Attach,proto3,oneof"`
+}
+
+func (*ForwardMsg_Dial) isForwardMsg_Data() {}
+
+func (*ForwardMsg_Connected) isForwardMsg_Data() {}
+
+func (*ForwardMsg_Payload) isForwardMsg_Data() {}
+
+func (*ForwardMsg_Listening) isForwardMsg_Data() {}
+
+func (*ForwardMsg_Accepted) isForwardMsg_Data() {}
+
+func (*ForwardMsg_Attach) isForwardMsg_Data() {}
+
+var File_forward_proto protoreflect.FileDescriptor
+
+const file_forward_proto_rawDesc = "" +
+	"\n" +
+	"\rforward.proto\"A\n" +
+	"\vForwardDial\x12\x18\n" +
+	"\aNetwork\x18\x01 \x01(\tR\aNetwork\x12\x18\n" +
+	"\aAddress\x18\x02 \x01(\tR\aAddress\"P\n" +
+	"\x10ForwardConnected\x12\x1c\n" +
+	"\tLocalAddr\x18\x01 \x01(\tR\tLocalAddr\x12\x1e\n" +
+	"\n" +
+	"RemoteAddr\x18\x02 \x01(\tR\n" +
+	"RemoteAddr\"C\n" +
+	"\rForwardListen\x12\x18\n" +
+	"\aNetwork\x18\x01 \x01(\tR\aNetwork\x12\x18\n" +
+	"\aAddress\x18\x02 \x01(\tR\aAddress\",\n" +
+	"\x10ForwardListening\x12\x18\n" +
+	"\aAddress\x18\x01 \x01(\tR\aAddress\"_\n" +
+	"\x0fForwardAccepted\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\tR\x02Id\x12\x1e\n" +
+	"\n" +
+	"RemoteAddr\x18\x02 \x01(\tR\n" +
+	"RemoteAddr\x12\x1c\n" +
+	"\tLocalAddr\x18\x03 \x01(\tR\tLocalAddr\"\x1f\n" +
+	"\rForwardAttach\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\tR\x02Id\"\xb9\x02\n" +
+	"\n" +
+	"ForwardMsg\x12#\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x0f.ForwardMsgTypeR\x04type\x12\"\n" +
+	"\x04Dial\x18\x02 \x01(\v2\f.ForwardDialH\x00R\x04Dial\x121\n" +
+	"\tConnected\x18\x03 \x01(\v2\x11.ForwardConnectedH\x00R\tConnected\x12\x1a\n" +
+	"\aPayload\x18\x04 \x01(\fH\x00R\aPayload\x121\n" +
+	"\tListening\x18\x05 \x01(\v2\x11.ForwardListeningH\x00R\tListening\x12.\n" +
+	"\bAccepted\x18\x06 \x01(\v2\x10.ForwardAcceptedH\x00R\bAccepted\x12(\n" +
+	"\x06Attach\x18\a \x01(\v2\x0e.ForwardAttachH\x00R\x06AttachB\x06\n" +
+	"\x04Data*\xc2\x01\n" +
+	"\x0eForwardMsgType\x12\x19\n" +
+	"\x15FORWARD_MSG_TYPE_DATA\x10\x00\x12\x19\n" +
+	"\x15FORWARD_MSG_TYPE_DIAL\x10\x01\x12\x1e\n" +
+	"\x1aFORWARD_MSG_TYPE_CONNECTED\x10\x02\x12\x1e\n" +
+	"\x1aFORWARD_MSG_TYPE_LISTENING\x10\x03\x12\x1d\n" +
+	"\x19FORWARD_MSG_TYPE_ACCEPTED\x10\x04\x12\x1b\n" +
+	"\x17FORWARD_MSG_TYPE_ATTACH\x10\x052\x83\x01\n" +
+	"\aForward\x12'\n" +
+	"\aForward\x12\v.ForwardMsg\x1a\v.ForwardMsg(\x010\x01\x12'\n" +
+	"\x06Listen\x12\x0e.ForwardListen\x1a\v.ForwardMsg0\x01\x12&\n" +
+	"\x06Accept\x12\v.ForwardMsg\x1a\v.ForwardMsg(\x010\x01B\bZ\x06.;coreb\x06proto3"
+
+var (
+	file_forward_proto_rawDescOnce sync.Once
+	file_forward_proto_rawDescData []byte
+)
+
+func file_forward_proto_rawDescGZIP() []byte {
+	file_forward_proto_rawDescOnce.Do(func() {
+		file_forward_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_forward_proto_rawDesc), len(file_forward_proto_rawDesc)))
+	})
+	return file_forward_proto_rawDescData
+}
+
+var file_forward_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_forward_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_forward_proto_goTypes = []any{
+	(ForwardMsgType)(0),      // 0: ForwardMsgType
+	(*ForwardDial)(nil),      // 1: ForwardDial
+	(*ForwardConnected)(nil), // 2: ForwardConnected
+	(*ForwardListen)(nil),    // 3: ForwardListen
+	(*ForwardListening)(nil), // 4: ForwardListening
+	(*ForwardAccepted)(nil),  // 5: ForwardAccepted
+	(*ForwardAttach)(nil),    // 6: ForwardAttach
+	(*ForwardMsg)(nil),       // 7: ForwardMsg
+}
+var file_forward_proto_depIdxs = []int32{
+	0, // 0: ForwardMsg.type:type_name -> ForwardMsgType
+	1, // 1: ForwardMsg.Dial:type_name -> ForwardDial
+	2, // 2: ForwardMsg.Connected:type_name -> ForwardConnected
+	4, // 3: ForwardMsg.Listening:type_name -> ForwardListening
+	5, // 4: ForwardMsg.Accepted:type_name -> ForwardAccepted
+	6, // 5: ForwardMsg.Attach:type_name -> ForwardAttach
+	7, // 6: Forward.Forward:input_type -> ForwardMsg
+	3, // 7: Forward.Listen:input_type -> ForwardListen
+	7, // 8: Forward.Accept:input_type -> ForwardMsg
+	7, // 9: Forward.Forward:output_type -> ForwardMsg
+	7, // 10: Forward.Listen:output_type -> ForwardMsg
+	7, // 11: Forward.Accept:output_type -> ForwardMsg
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_forward_proto_init() }
+func file_forward_proto_init() {
+	if File_forward_proto != nil {
+		return
+	}
+	file_forward_proto_msgTypes[6].OneofWrappers = []any{
+		(*ForwardMsg_Dial)(nil),
+		(*ForwardMsg_Connected)(nil),
+		(*ForwardMsg_Payload)(nil),
+		(*ForwardMsg_Listening)(nil),
+		(*ForwardMsg_Accepted)(nil),
+		(*ForwardMsg_Attach)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_forward_proto_rawDesc), len(file_forward_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_forward_proto_goTypes,
+		DependencyIndexes: file_forward_proto_depIdxs,
+		EnumInfos:         file_forward_proto_enumTypes,
+		MessageInfos:      file_forward_proto_msgTypes,
+	}.Build()
+	File_forward_proto = out.File
+	file_forward_proto_goTypes = nil
+	file_forward_proto_depIdxs = nil
+}
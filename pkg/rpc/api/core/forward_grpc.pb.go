@@ -0,0 +1,196 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v4.24.4
+// source: forward.proto
+
+package core
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Forward_Forward_FullMethodName = "/Forward/Forward"
+	Forward_Listen_FullMethodName  = "/Forward/Listen"
+	Forward_Accept_FullMethodName  = "/Forward/Accept"
+)
+
+// ForwardClient is the client API for Forward service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Forward 由 agent 提供, 将 stream 转发到 agent 所在网络中的地址
+type ForwardClient interface {
+	Forward(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ForwardMsg, ForwardMsg], error)
+	// Listen 在 agent 上监听, 每接收一个连接发送一条 ACCEPTED, stream 结束时停止监听并关闭所有连接
+	Listen(ctx context.Context, in *ForwardListen, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ForwardMsg], error)
+	// Accept 认领 Listen 接收的连接, 之后与 Forward 相同地转发数据
+	Accept(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ForwardMsg, ForwardMsg], error)
+}
+
+type forwardClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewForwardClient(cc grpc.ClientConnInterface) ForwardClient {
+	return &forwardClient{cc}
+}
+
+func (c *forwardClient) Forward(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ForwardMsg, ForwardMsg], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Forward_ServiceDesc.Streams[0], Forward_Forward_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ForwardMsg, ForwardMsg]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forward_ForwardClient = grpc.BidiStreamingClient[ForwardMsg, ForwardMsg]
+
+func (c *forwardClient) Listen(ctx context.Context, in *ForwardListen, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ForwardMsg], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Forward_ServiceDesc.Streams[1], Forward_Listen_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ForwardListen, ForwardMsg]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forward_ListenClient = grpc.ServerStreamingClient[ForwardMsg]
+
+func (c *forwardClient) Accept(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ForwardMsg, ForwardMsg], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Forward_ServiceDesc.Streams[2], Forward_Accept_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ForwardMsg, ForwardMsg]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forward_AcceptClient = grpc.BidiStreamingClient[ForwardMsg, ForwardMsg]
+
+// ForwardServer is the server API for Forward service.
+// All implementations must embed UnimplementedForwardServer
+// for forward compatibility.
+//
+// Forward 由 agent 提供, 将 stream 转发到 agent 所在网络中的地址
+type ForwardServer interface {
+	Forward(grpc.BidiStreamingServer[ForwardMsg, ForwardMsg]) error
+	// Listen 在 agent 上监听, 每接收一个连接发送一条 ACCEPTED, stream 结束时停止监听并关闭所有连接
+	Listen(*ForwardListen, grpc.ServerStreamingServer[ForwardMsg]) error
+	// Accept 认领 Listen 接收的连接, 之后与 Forward 相同地转发数据
+	Accept(grpc.BidiStreamingServer[ForwardMsg, ForwardMsg]) error
+	mustEmbedUnimplementedForwardServer()
+}
+
+// UnimplementedForwardServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedForwardServer struct{}
+
+func (UnimplementedForwardServer) Forward(grpc.BidiStreamingServer[ForwardMsg, ForwardMsg]) error {
+	return status.Errorf(codes.Unimplemented, "method Forward not implemented")
+}
+func (UnimplementedForwardServer) Listen(*ForwardListen, grpc.ServerStreamingServer[ForwardMsg]) error {
+	return status.Errorf(codes.Unimplemented, "method Listen not implemented")
+}
+func (UnimplementedForwardServer) Accept(grpc.BidiStreamingServer[ForwardMsg, ForwardMsg]) error {
+	return status.Errorf(codes.Unimplemented, "method Accept not implemented")
+}
+func (UnimplementedForwardServer) mustEmbedUnimplementedForwardServer() {}
+func (UnimplementedForwardServer) testEmbeddedByValue()                 {}
+
+// UnsafeForwardServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ForwardServer will
+// result in compilation errors.
+type UnsafeForwardServer interface {
+	mustEmbedUnimplementedForwardServer()
+}
+
+func RegisterForwardServer(s grpc.ServiceRegistrar, srv ForwardServer) {
+	// If the following call pancis, it indicates UnimplementedForwardServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Forward_ServiceDesc, srv)
+}
+
+func _Forward_Forward_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ForwardServer).Forward(&grpc.GenericServerStream[ForwardMsg, ForwardMsg]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forward_ForwardServer = grpc.BidiStreamingServer[ForwardMsg, ForwardMsg]
+
+func _Forward_Listen_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ForwardListen)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ForwardServer).Listen(m, &grpc.GenericServerStream[ForwardListen, ForwardMsg]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forward_ListenServer = grpc.ServerStreamingServer[ForwardMsg]
+
+func _Forward_Accept_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ForwardServer).Accept(&grpc.GenericServerStream[ForwardMsg, ForwardMsg]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Forward_AcceptServer = grpc.BidiStreamingServer[ForwardMsg, ForwardMsg]
+
+// Forward_ServiceDesc is the grpc.ServiceDesc for Forward service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Forward_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Forward",
+	HandlerType: (*ForwardServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Forward",
+			Handler:       _Forward_Forward_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Listen",
+			Handler:       _Forward_Listen_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Accept",
+			Handler:       _Forward_Accept_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "forward.proto",
+}
@@ -1,3 +1,3 @@
 package core
 
-//go:generate protoc --go_out=. --go-grpc_out=.  shell.proto
+//go:generate protoc --go_out=. --go-grpc_out=.  shell.proto forward.proto file.proto
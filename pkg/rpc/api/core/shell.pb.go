@@ -24,9 +24,16 @@ const (
 type ShellMsgType int32
 
 const (
-	ShellMsgType_SHELL_MSG_TYPE_IO      ShellMsgType = 0 // 程序输入输出数据
-	ShellMsgType_SHELL_MSG_TYPE_COMMAND ShellMsgType = 1 // 初始化 shell 的命令
-	ShellMsgType_SHELL_MSG_TYPE_RESIZE  ShellMsgType = 2 // 改变窗口大小
+	ShellMsgType_SHELL_MSG_TYPE_IO        ShellMsgType = 0 // 程序输入输出数据
+	ShellMsgType_SHELL_MSG_TYPE_COMMAND   ShellMsgType = 1 // 初始化 shell 的命令
+	ShellMsgType_SHELL_MSG_TYPE_RESIZE    ShellMsgType = 2 // 改变窗口大小
+	ShellMsgType_SHELL_MSG_TYPE_EXIT      ShellMsgType = 3 // 进程退出状态, 服务端在关闭 stream 前发送
+	ShellMsgType_SHELL_MSG_TYPE_STDIN_EOF ShellMsgType = 4 // stdin 输入结束, 管道模式下关闭 stdin, pty 模式下发送 VEOF
+	ShellMsgType_SHELL_MSG_TYPE_SIGNAL    ShellMsgType = 5 // 向远端进程组发送信号
+	ShellMsgType_SHELL_MSG_TYPE_SESSION   ShellMsgType = 6 // 服务端告知客户端会话 ID 和回放的起始偏移
+	ShellMsgType_SHELL_MSG_TYPE_ATTACH    ShellMsgType = 7 // 连接到已存在的会话, AttachSession 的第一条消息
+	ShellMsgType_SHELL_MSG_TYPE_DETACH    ShellMsgType = 8 // 客户端主动断开会话, 进程继续运行
+	ShellMsgType_SHELL_MSG_TYPE_CONTROL   ShellMsgType = 9 // 会话写入权的变更
 )
 
 // Enum value maps for ShellMsgType.
@@ -35,11 +42,25 @@ var (
 		0: "SHELL_MSG_TYPE_IO",
 		1: "SHELL_MSG_TYPE_COMMAND",
 		2: "SHELL_MSG_TYPE_RESIZE",
+		3: "SHELL_MSG_TYPE_EXIT",
+		4: "SHELL_MSG_TYPE_STDIN_EOF",
+		5: "SHELL_MSG_TYPE_SIGNAL",
+		6: "SHELL_MSG_TYPE_SESSION",
+		7: "SHELL_MSG_TYPE_ATTACH",
+		8: "SHELL_MSG_TYPE_DETACH",
+		9: "SHELL_MSG_TYPE_CONTROL",
 	}
 	ShellMsgType_value = map[string]int32{
-		"SHELL_MSG_TYPE_IO":      0,
-		"SHELL_MSG_TYPE_COMMAND": 1,
-		"SHELL_MSG_TYPE_RESIZE":  2,
+		"SHELL_MSG_TYPE_IO":        0,
+		"SHELL_MSG_TYPE_COMMAND":   1,
+		"SHELL_MSG_TYPE_RESIZE":    2,
+		"SHELL_MSG_TYPE_EXIT":      3,
+		"SHELL_MSG_TYPE_STDIN_EOF": 4,
+		"SHELL_MSG_TYPE_SIGNAL":    5,
+		"SHELL_MSG_TYPE_SESSION":   6,
+		"SHELL_MSG_TYPE_ATTACH":    7,
+		"SHELL_MSG_TYPE_DETACH":    8,
+		"SHELL_MSG_TYPE_CONTROL":   9,
 	}
 )
 
@@ -70,6 +91,55 @@ func (ShellMsgType) EnumDescriptor() ([]byte, []int) {
 	return file_shell_proto_rawDescGZIP(), []int{0}
 }
 
+type EnvMode int32
+
+const (
+	EnvMode_ENV_MODE_MERGE   EnvMode = 0 // 继承 agent 的环境变量并合并 Cmd.Envs
+	EnvMode_ENV_MODE_INHERIT EnvMode = 1 // 仅继承 agent 的环境变量, 忽略 Cmd.Envs
+	EnvMode_ENV_MODE_CLEAR   EnvMode = 2 // 不继承 agent 的环境变量, 仅使用 Cmd.Envs
+)
+
+// Enum value maps for EnvMode.
+var (
+	EnvMode_name = map[int32]string{
+		0: "ENV_MODE_MERGE",
+		1: "ENV_MODE_INHERIT",
+		2: "ENV_MODE_CLEAR",
+	}
+	EnvMode_value = map[string]int32{
+		"ENV_MODE_MERGE":   0,
+		"ENV_MODE_INHERIT": 1,
+		"ENV_MODE_CLEAR":   2,
+	}
+)
+
+func (x EnvMode) Enum() *EnvMode {
+	p := new(EnvMode)
+	*p = x
+	return p
+}
+
+func (x EnvMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EnvMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_shell_proto_enumTypes[1].Descriptor()
+}
+
+func (EnvMode) Type() protoreflect.EnumType {
+	return &file_shell_proto_enumTypes[1]
+}
+
+func (x EnvMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EnvMode.Descriptor instead.
+func (EnvMode) EnumDescriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{1}
+}
+
 type IODataType int32
 
 const (
@@ -103,11 +173,11 @@ func (x IODataType) String() string {
 }
 
 func (IODataType) Descriptor() protoreflect.EnumDescriptor {
-	return file_shell_proto_enumTypes[1].Descriptor()
+	return file_shell_proto_enumTypes[2].Descriptor()
 }
 
 func (IODataType) Type() protoreflect.EnumType {
-	return &file_shell_proto_enumTypes[1]
+	return &file_shell_proto_enumTypes[2]
 }
 
 func (x IODataType) Number() protoreflect.EnumNumber {
@@ -116,7 +186,59 @@ func (x IODataType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use IODataType.Descriptor instead.
 func (IODataType) EnumDescriptor() ([]byte, []int) {
-	return file_shell_proto_rawDescGZIP(), []int{1}
+	return file_shell_proto_rawDescGZIP(), []int{2}
+}
+
+type ControlAction int32
+
+const (
+	ControlAction_CONTROL_ACTION_WRITER   ControlAction = 0 // 服务端通知写入者变更, ClientId 为空表示没有写入者
+	ControlAction_CONTROL_ACTION_HANDOVER ControlAction = 1 // 写入者将写入权交给 ClientId
+	ControlAction_CONTROL_ACTION_TAKE     ControlAction = 2 // 没有写入者时获取写入权
+	ControlAction_CONTROL_ACTION_REJECTED ControlAction = 3 // 服务端拒绝了没有写入权的客户端的输入
+)
+
+// Enum value maps for ControlAction.
+var (
+	ControlAction_name = map[int32]string{
+		0: "CONTROL_ACTION_WRITER",
+		1: "CONTROL_ACTION_HANDOVER",
+		2: "CONTROL_ACTION_TAKE",
+		3: "CONTROL_ACTION_REJECTED",
+	}
+	ControlAction_value = map[string]int32{
+		"CONTROL_ACTION_WRITER":   0,
+		"CONTROL_ACTION_HANDOVER": 1,
+		"CONTROL_ACTION_TAKE":     2,
+		"CONTROL_ACTION_REJECTED": 3,
+	}
+)
+
+func (x ControlAction) Enum() *ControlAction {
+	p := new(ControlAction)
+	*p = x
+	return p
+}
+
+func (x ControlAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ControlAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_shell_proto_enumTypes[3].Descriptor()
+}
+
+func (ControlAction) Type() protoreflect.EnumType {
+	return &file_shell_proto_enumTypes[3]
+}
+
+func (x ControlAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ControlAction.Descriptor instead.
+func (ControlAction) EnumDescriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{3}
 }
 
 type SysProcAttrLinux struct {
@@ -344,11 +466,13 @@ func (x *Env) GetValue() string {
 }
 
 type Cmd struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	Path  string                 `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
-	Args  []string               `protobuf:"bytes,2,rep,name=Args,proto3" json:"Args,omitempty"`
-	Envs  []*Env                 `protobuf:"bytes,3,rep,name=Envs,proto3" json:"Envs,omitempty"`
-	Dir   string                 `protobuf:"bytes,4,opt,name=Dir,proto3" json:"Dir,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Path       string                 `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Args       []string               `protobuf:"bytes,2,rep,name=Args,proto3" json:"Args,omitempty"`
+	Envs       []*Env                 `protobuf:"bytes,3,rep,name=Envs,proto3" json:"Envs,omitempty"`
+	Dir        string                 `protobuf:"bytes,4,opt,name=Dir,proto3" json:"Dir,omitempty"`
+	DisablePty bool                   `protobuf:"varint,5,opt,name=DisablePty,proto3" json:"DisablePty,omitempty"` // 不分配 pty, 使用管道分别传输 stdout 和 stderr
+	EnvMode    EnvMode                `protobuf:"varint,6,opt,name=EnvMode,proto3,enum=EnvMode" json:"EnvMode,omitempty"`
 	// Types that are valid to be assigned to SysProcAttr:
 	//
 	//	*Cmd_Linux
@@ -416,6 +540,20 @@ func (x *Cmd) GetDir() string {
 	return ""
 }
 
+func (x *Cmd) GetDisablePty() bool {
+	if x != nil {
+		return x.DisablePty
+	}
+	return false
+}
+
+func (x *Cmd) GetEnvMode() EnvMode {
+	if x != nil {
+		return x.EnvMode
+	}
+	return EnvMode_ENV_MODE_MERGE
+}
+
 func (x *Cmd) GetSysProcAttr() isCmd_SysProcAttr {
 	if x != nil {
 		return x.SysProcAttr
@@ -561,33 +699,36 @@ func (x *IoData) GetData() []byte {
 	return nil
 }
 
-type ShellMsg struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	Type  ShellMsgType           `protobuf:"varint,1,opt,name=type,proto3,enum=ShellMsgType" json:"type,omitempty"`
-	// Types that are valid to be assigned to Data:
-	//
-	//	*ShellMsg_Cmd
-	//	*ShellMsg_IO
-	//	*ShellMsg_Resize
-	Data          isShellMsg_Data `protobuf_oneof:"Data"`
+// Rusage 进程资源使用情况, 时间单位为微秒
+type Rusage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Utime         int64                  `protobuf:"varint,1,opt,name=Utime,proto3" json:"Utime,omitempty"`   // 用户态 CPU 时间
+	Stime         int64                  `protobuf:"varint,2,opt,name=Stime,proto3" json:"Stime,omitempty"`   // 内核态 CPU 时间
+	Maxrss        int64                  `protobuf:"varint,3,opt,name=Maxrss,proto3" json:"Maxrss,omitempty"` // 最大常驻内存, KB
+	Minflt        int64                  `protobuf:"varint,4,opt,name=Minflt,proto3" json:"Minflt,omitempty"`
+	Majflt        int64                  `protobuf:"varint,5,opt,name=Majflt,proto3" json:"Majflt,omitempty"`
+	Inblock       int64                  `protobuf:"varint,6,opt,name=Inblock,proto3" json:"Inblock,omitempty"`
+	Oublock       int64                  `protobuf:"varint,7,opt,name=Oublock,proto3" json:"Oublock,omitempty"`
+	Nvcsw         int64                  `protobuf:"varint,8,opt,name=Nvcsw,proto3" json:"Nvcsw,omitempty"`
+	Nivcsw        int64                  `protobuf:"varint,9,opt,name=Nivcsw,proto3" json:"Nivcsw,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ShellMsg) Reset() {
-	*x = ShellMsg{}
+func (x *Rusage) Reset() {
+	*x = Rusage{}
 	mi := &file_shell_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ShellMsg) String() string {
+func (x *Rusage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ShellMsg) ProtoMessage() {}
+func (*Rusage) ProtoMessage() {}
 
-func (x *ShellMsg) ProtoReflect() protoreflect.Message {
+func (x *Rusage) ProtoReflect() protoreflect.Message {
 	mi := &file_shell_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -599,125 +740,956 @@ func (x *ShellMsg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ShellMsg.ProtoReflect.Descriptor instead.
-func (*ShellMsg) Descriptor() ([]byte, []int) {
+// Deprecated: Use Rusage.ProtoReflect.Descriptor instead.
+func (*Rusage) Descriptor() ([]byte, []int) {
 	return file_shell_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ShellMsg) GetType() ShellMsgType {
+func (x *Rusage) GetUtime() int64 {
 	if x != nil {
-		return x.Type
+		return x.Utime
 	}
-	return ShellMsgType_SHELL_MSG_TYPE_IO
+	return 0
 }
 
-func (x *ShellMsg) GetData() isShellMsg_Data {
+func (x *Rusage) GetStime() int64 {
 	if x != nil {
-		return x.Data
+		return x.Stime
 	}
-	return nil
+	return 0
 }
 
-func (x *ShellMsg) GetCmd() *Cmd {
+func (x *Rusage) GetMaxrss() int64 {
 	if x != nil {
-		if x, ok := x.Data.(*ShellMsg_Cmd); ok {
-			return x.Cmd
-		}
+		return x.Maxrss
 	}
-	return nil
+	return 0
 }
 
-func (x *ShellMsg) GetIO() *IoData {
+func (x *Rusage) GetMinflt() int64 {
 	if x != nil {
-		if x, ok := x.Data.(*ShellMsg_IO); ok {
-			return x.IO
+		return x.Minflt
+	}
+	return 0
+}
+
+func (x *Rusage) GetMajflt() int64 {
+	if x != nil {
+		return x.Majflt
+	}
+	return 0
+}
+
+func (x *Rusage) GetInblock() int64 {
+	if x != nil {
+		return x.Inblock
+	}
+	return 0
+}
+
+func (x *Rusage) GetOublock() int64 {
+	if x != nil {
+		return x.Oublock
+	}
+	return 0
+}
+
+func (x *Rusage) GetNvcsw() int64 {
+	if x != nil {
+		return x.Nvcsw
+	}
+	return 0
+}
+
+func (x *Rusage) GetNivcsw() int64 {
+	if x != nil {
+		return x.Nivcsw
+	}
+	return 0
+}
+
+type ExitStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=Code,proto3" json:"Code,omitempty"`     // 退出码, 被信号终止时为 -1
+	Signal        int32                  `protobuf:"varint,2,opt,name=Signal,proto3" json:"Signal,omitempty"` // 终止进程的信号, 0 表示正常退出
+	CoreDumped    bool                   `protobuf:"varint,3,opt,name=CoreDumped,proto3" json:"CoreDumped,omitempty"`
+	Rusage        *Rusage                `protobuf:"bytes,4,opt,name=Rusage,proto3" json:"Rusage,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=Error,proto3" json:"Error,omitempty"` // 等待进程时发生的其他错误
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExitStatus) Reset() {
+	*x = ExitStatus{}
+	mi := &file_shell_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExitStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExitStatus) ProtoMessage() {}
+
+func (x *ExitStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExitStatus.ProtoReflect.Descriptor instead.
+func (*ExitStatus) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExitStatus) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ExitStatus) GetSignal() int32 {
+	if x != nil {
+		return x.Signal
+	}
+	return 0
+}
+
+func (x *ExitStatus) GetCoreDumped() bool {
+	if x != nil {
+		return x.CoreDumped
+	}
+	return false
+}
+
+func (x *ExitStatus) GetRusage() *Rusage {
+	if x != nil {
+		return x.Rusage
 	}
 	return nil
 }
 
-func (x *ShellMsg) GetResize() *WinSize {
+func (x *ExitStatus) GetError() string {
 	if x != nil {
-		if x, ok := x.Data.(*ShellMsg_Resize); ok {
-			return x.Resize
+		return x.Error
+	}
+	return ""
+}
+
+type Signal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"` // 信号名, 如 SIGINT、SIGTERM
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Signal) Reset() {
+	*x = Signal{}
+	mi := &file_shell_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Signal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Signal) ProtoMessage() {}
+
+func (x *Signal) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-type isShellMsg_Data interface {
-	isShellMsg_Data()
+// Deprecated: Use Signal.ProtoReflect.Descriptor instead.
+func (*Signal) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{8}
 }
 
-type ShellMsg_Cmd struct {
-	Cmd *Cmd `protobuf:"bytes,2,opt,name=Cmd,proto3,oneof"`
+func (x *Signal) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
 
-type ShellMsg_IO struct {
-	IO *IoData `protobuf:"bytes,3,opt,name=IO,proto3,oneof"`
+type Session struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=Offset,proto3" json:"Offset,omitempty"`    // 本次回放输出的起始偏移
+	ClientId      string                 `protobuf:"bytes,3,opt,name=ClientId,proto3" json:"ClientId,omitempty"` // 当前客户端在会话中的 ID
+	Writer        bool                   `protobuf:"varint,4,opt,name=Writer,proto3" json:"Writer,omitempty"`    // 当前客户端是否拥有写入权
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-type ShellMsg_Resize struct {
-	Resize *WinSize `protobuf:"bytes,4,opt,name=Resize,proto3,oneof"`
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_shell_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (*ShellMsg_Cmd) isShellMsg_Data() {}
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
 
-func (*ShellMsg_IO) isShellMsg_Data() {}
+func (*Session) ProtoMessage() {}
 
-func (*ShellMsg_Resize) isShellMsg_Data() {}
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
 
-var File_shell_proto protoreflect.FileDescriptor
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{9}
+}
 
-const file_shell_proto_rawDesc = "" +
-	"\n" +
-	"\vshell.proto\"\xa1\x01\n" +
-	"\x10SysProcAttrLinux\x12\x16\n" +
-	"\x06Chroot\x18\x01 \x01(\tR\x06Chroot\x12\x12\n" +
-	"\x03Uid\x18\x02 \x01(\rH\x00R\x03Uid\x12\x1c\n" +
-	"\bUsername\x18\x03 \x01(\tH\x00R\bUsername\x12\x12\n" +
-	"\x03Gid\x18\x04 \x01(\rH\x01R\x03Gid\x12\x1e\n" +
-	"\tGroupname\x18\x05 \x01(\tH\x01R\tGroupnameB\x06\n" +
-	"\x04UserB\a\n" +
-	"\x05Group\"\x14\n" +
-	"\x12SysProcAttrWindows\"/\n" +
-	"\x03Env\x12\x12\n" +
-	"\x04Name\x18\x01 \x01(\tR\x04Name\x12\x14\n" +
-	"\x05Value\x18\x02 \x01(\tR\x05Value\"\xc4\x01\n" +
-	"\x03Cmd\x12\x12\n" +
-	"\x04Path\x18\x01 \x01(\tR\x04Path\x12\x12\n" +
-	"\x04Args\x18\x02 \x03(\tR\x04Args\x12\x18\n" +
-	"\x04Envs\x18\x03 \x03(\v2\x04.EnvR\x04Envs\x12\x10\n" +
-	"\x03Dir\x18\x04 \x01(\tR\x03Dir\x12)\n" +
-	"\x05Linux\x18\n" +
-	" \x01(\v2\x11.SysProcAttrLinuxH\x00R\x05Linux\x12/\n" +
-	"\aWindows\x18\v \x01(\v2\x13.SysProcAttrWindowsH\x00R\aWindowsB\r\n" +
-	"\vSysProcAttr\"1\n" +
-	"\aWinSize\x12\x12\n" +
-	"\x04Cols\x18\x01 \x01(\x05R\x04Cols\x12\x12\n" +
-	"\x04Rows\x18\x02 \x01(\x05R\x04Rows\"=\n" +
-	"\x06IoData\x12\x1f\n" +
-	"\x04Type\x18\x01 \x01(\x0e2\v.IODataTypeR\x04Type\x12\x12\n" +
-	"\x04Data\x18\x02 \x01(\fR\x04Data\"\x8e\x01\n" +
+func (x *Session) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Session) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Session) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Session) GetWriter() bool {
+	if x != nil {
+		return x.Writer
+	}
+	return false
+}
+
+type Attach struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=Offset,proto3" json:"Offset,omitempty"`     // 客户端已接收的输出字节数, 从该位置开始回放
+	ReadOnly      bool                   `protobuf:"varint,3,opt,name=ReadOnly,proto3" json:"ReadOnly,omitempty"` // 以只读观察者身份连接, 否则在没有写入者时获取写入权, 已有写入者时需由其移交
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attach) Reset() {
+	*x = Attach{}
+	mi := &file_shell_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attach) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attach) ProtoMessage() {}
+
+func (x *Attach) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attach.ProtoReflect.Descriptor instead.
+func (*Attach) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Attach) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Attach) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Attach) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+type Control struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        ControlAction          `protobuf:"varint,1,opt,name=Action,proto3,enum=ControlAction" json:"Action,omitempty"`
+	ClientId      string                 `protobuf:"bytes,2,opt,name=ClientId,proto3" json:"ClientId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Control) Reset() {
+	*x = Control{}
+	mi := &file_shell_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Control) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Control) ProtoMessage() {}
+
+func (x *Control) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Control.ProtoReflect.Descriptor instead.
+func (*Control) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Control) GetAction() ControlAction {
+	if x != nil {
+		return x.Action
+	}
+	return ControlAction_CONTROL_ACTION_WRITER
+}
+
+func (x *Control) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+type ShellMsg struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  ShellMsgType           `protobuf:"varint,1,opt,name=type,proto3,enum=ShellMsgType" json:"type,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*ShellMsg_Cmd
+	//	*ShellMsg_IO
+	//	*ShellMsg_Resize
+	//	*ShellMsg_Exit
+	//	*ShellMsg_Signal
+	//	*ShellMsg_Session
+	//	*ShellMsg_Attach
+	//	*ShellMsg_Control
+	Data          isShellMsg_Data `protobuf_oneof:"Data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShellMsg) Reset() {
+	*x = ShellMsg{}
+	mi := &file_shell_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShellMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShellMsg) ProtoMessage() {}
+
+func (x *ShellMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShellMsg.ProtoReflect.Descriptor instead.
+func (*ShellMsg) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ShellMsg) GetType() ShellMsgType {
+	if x != nil {
+		return x.Type
+	}
+	return ShellMsgType_SHELL_MSG_TYPE_IO
+}
+
+func (x *ShellMsg) GetData() isShellMsg_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetCmd() *Cmd {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_Cmd); ok {
+			return x.Cmd
+		}
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetIO() *IoData {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_IO); ok {
+			return x.IO
+		}
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetResize() *WinSize {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_Resize); ok {
+			return x.Resize
+		}
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetExit() *ExitStatus {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_Exit); ok {
+			return x.Exit
+		}
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetSignal() *Signal {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_Signal); ok {
+			return x.Signal
+		}
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetSession() *Session {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_Session); ok {
+			return x.Session
+		}
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetAttach() *Attach {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_Attach); ok {
+			return x.Attach
+		}
+	}
+	return nil
+}
+
+func (x *ShellMsg) GetControl() *Control {
+	if x != nil {
+		if x, ok := x.Data.(*ShellMsg_Control); ok {
+			return x.Control
+		}
+	}
+	return nil
+}
+
+type isShellMsg_Data interface {
+	isShellMsg_Data()
+}
+
+type ShellMsg_Cmd struct {
+	Cmd *Cmd `protobuf:"bytes,2,opt,name=Cmd,proto3,oneof"`
+}
+
+type ShellMsg_IO struct {
+	IO *IoData `protobuf:"bytes,3,opt,name=IO,proto3,oneof"`
+}
+
+type ShellMsg_Resize struct {
+	Resize *WinSize `protobuf:"bytes,4,opt,name=Resize,proto3,oneof"`
+}
+
+type ShellMsg_Exit struct {
+	Exit *ExitStatus `protobuf:"bytes,5,opt,name=Exit,proto3,oneof"`
+}
+
+type ShellMsg_Signal struct {
+	Signal *Signal `protobuf:"bytes,6,opt,name=Signal,proto3,oneof"`
+}
+
+type ShellMsg_Session struct {
+	Session *Session `protobuf:"bytes,7,opt,name=Session,proto3,oneof"`
+}
+
+type ShellMsg_Attach struct {
+	Attach *Attach `protobuf:"bytes,8,opt,name=Attach,proto3,oneof"`
+}
+
+type ShellMsg_Control struct {
+	Control *Control `protobuf:"bytes,9,opt,name=Control,proto3,oneof"`
+}
+
+func (*ShellMsg_Cmd) isShellMsg_Data() {}
+
+func (*ShellMsg_IO) isShellMsg_Data() {}
+
+func (*ShellMsg_Resize) isShellMsg_Data() {}
+
+func (*ShellMsg_Exit) isShellMsg_Data() {}
+
+func (*ShellMsg_Signal) isShellMsg_Data() {}
+
+func (*ShellMsg_Session) isShellMsg_Data() {}
+
+func (*ShellMsg_Attach) isShellMsg_Data() {}
+
+func (*ShellMsg_Control) isShellMsg_Data() {}
+
+type SessionInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=Path,proto3" json:"Path,omitempty"`
+	Args          []string               `protobuf:"bytes,3,rep,name=Args,proto3" json:"Args,omitempty"`
+	Pid           int32                  `protobuf:"varint,4,opt,name=Pid,proto3" json:"Pid,omitempty"`
+	Created       int64                  `protobuf:"varint,5,opt,name=Created,proto3" json:"Created,omitempty"` // unix 时间戳, 秒
+	Attached      bool                   `protobuf:"varint,6,opt,name=Attached,proto3" json:"Attached,omitempty"`
+	Offset        int64                  `protobuf:"varint,7,opt,name=Offset,proto3" json:"Offset,omitempty"`   // 累计输出字节数
+	Exit          *ExitStatus            `protobuf:"bytes,8,opt,name=Exit,proto3" json:"Exit,omitempty"`        // 进程已退出时不为空
+	Clients       int32                  `protobuf:"varint,9,opt,name=Clients,proto3" json:"Clients,omitempty"` // 已连接的客户端数量, 包括只读观察者
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionInfo) Reset() {
+	*x = SessionInfo{}
+	mi := &file_shell_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInfo) ProtoMessage() {}
+
+func (x *SessionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInfo.ProtoReflect.Descriptor instead.
+func (*SessionInfo) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SessionInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *SessionInfo) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetAttached() bool {
+	if x != nil {
+		return x.Attached
+	}
+	return false
+}
+
+func (x *SessionInfo) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetExit() *ExitStatus {
+	if x != nil {
+		return x.Exit
+	}
+	return nil
+}
+
+func (x *SessionInfo) GetClients() int32 {
+	if x != nil {
+		return x.Clients
+	}
+	return 0
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_shell_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{14}
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*SessionInfo         `protobuf:"bytes,1,rep,name=Sessions,proto3" json:"Sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_shell_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*SessionInfo {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type KillSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillSessionRequest) Reset() {
+	*x = KillSessionRequest{}
+	mi := &file_shell_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillSessionRequest) ProtoMessage() {}
+
+func (x *KillSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillSessionRequest.ProtoReflect.Descriptor instead.
+func (*KillSessionRequest) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *KillSessionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type KillSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillSessionResponse) Reset() {
+	*x = KillSessionResponse{}
+	mi := &file_shell_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillSessionResponse) ProtoMessage() {}
+
+func (x *KillSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shell_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillSessionResponse.ProtoReflect.Descriptor instead.
+func (*KillSessionResponse) Descriptor() ([]byte, []int) {
+	return file_shell_proto_rawDescGZIP(), []int{17}
+}
+
+var File_shell_proto protoreflect.FileDescriptor
+
+const file_shell_proto_rawDesc = "" +
+	"\n" +
+	"\vshell.proto\"\xa1\x01\n" +
+	"\x10SysProcAttrLinux\x12\x16\n" +
+	"\x06Chroot\x18\x01 \x01(\tR\x06Chroot\x12\x12\n" +
+	"\x03Uid\x18\x02 \x01(\rH\x00R\x03Uid\x12\x1c\n" +
+	"\bUsername\x18\x03 \x01(\tH\x00R\bUsername\x12\x12\n" +
+	"\x03Gid\x18\x04 \x01(\rH\x01R\x03Gid\x12\x1e\n" +
+	"\tGroupname\x18\x05 \x01(\tH\x01R\tGroupnameB\x06\n" +
+	"\x04UserB\a\n" +
+	"\x05Group\"\x14\n" +
+	"\x12SysProcAttrWindows\"/\n" +
+	"\x03Env\x12\x12\n" +
+	"\x04Name\x18\x01 \x01(\tR\x04Name\x12\x14\n" +
+	"\x05Value\x18\x02 \x01(\tR\x05Value\"\x88\x02\n" +
+	"\x03Cmd\x12\x12\n" +
+	"\x04Path\x18\x01 \x01(\tR\x04Path\x12\x12\n" +
+	"\x04Args\x18\x02 \x03(\tR\x04Args\x12\x18\n" +
+	"\x04Envs\x18\x03 \x03(\v2\x04.EnvR\x04Envs\x12\x10\n" +
+	"\x03Dir\x18\x04 \x01(\tR\x03Dir\x12\x1e\n" +
+	"\n" +
+	"DisablePty\x18\x05 \x01(\bR\n" +
+	"DisablePty\x12\"\n" +
+	"\aEnvMode\x18\x06 \x01(\x0e2\b.EnvModeR\aEnvMode\x12)\n" +
+	"\x05Linux\x18\n" +
+	" \x01(\v2\x11.SysProcAttrLinuxH\x00R\x05Linux\x12/\n" +
+	"\aWindows\x18\v \x01(\v2\x13.SysProcAttrWindowsH\x00R\aWindowsB\r\n" +
+	"\vSysProcAttr\"1\n" +
+	"\aWinSize\x12\x12\n" +
+	"\x04Cols\x18\x01 \x01(\x05R\x04Cols\x12\x12\n" +
+	"\x04Rows\x18\x02 \x01(\x05R\x04Rows\"=\n" +
+	"\x06IoData\x12\x1f\n" +
+	"\x04Type\x18\x01 \x01(\x0e2\v.IODataTypeR\x04Type\x12\x12\n" +
+	"\x04Data\x18\x02 \x01(\fR\x04Data\"\xde\x01\n" +
+	"\x06Rusage\x12\x14\n" +
+	"\x05Utime\x18\x01 \x01(\x03R\x05Utime\x12\x14\n" +
+	"\x05Stime\x18\x02 \x01(\x03R\x05Stime\x12\x16\n" +
+	"\x06Maxrss\x18\x03 \x01(\x03R\x06Maxrss\x12\x16\n" +
+	"\x06Minflt\x18\x04 \x01(\x03R\x06Minflt\x12\x16\n" +
+	"\x06Majflt\x18\x05 \x01(\x03R\x06Majflt\x12\x18\n" +
+	"\aInblock\x18\x06 \x01(\x03R\aInblock\x12\x18\n" +
+	"\aOublock\x18\a \x01(\x03R\aOublock\x12\x14\n" +
+	"\x05Nvcsw\x18\b \x01(\x03R\x05Nvcsw\x12\x16\n" +
+	"\x06Nivcsw\x18\t \x01(\x03R\x06Nivcsw\"\x8f\x01\n" +
+	"\n" +
+	"ExitStatus\x12\x12\n" +
+	"\x04Code\x18\x01 \x01(\x05R\x04Code\x12\x16\n" +
+	"\x06Signal\x18\x02 \x01(\x05R\x06Signal\x12\x1e\n" +
+	"\n" +
+	"CoreDumped\x18\x03 \x01(\bR\n" +
+	"CoreDumped\x12\x1f\n" +
+	"\x06Rusage\x18\x04 \x01(\v2\a.RusageR\x06Rusage\x12\x14\n" +
+	"\x05Error\x18\x05 \x01(\tR\x05Error\"\x1c\n" +
+	"\x06Signal\x12\x12\n" +
+	"\x04Name\x18\x01 \x01(\tR\x04Name\"e\n" +
+	"\aSession\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\tR\x02Id\x12\x16\n" +
+	"\x06Offset\x18\x02 \x01(\x03R\x06Offset\x12\x1a\n" +
+	"\bClientId\x18\x03 \x01(\tR\bClientId\x12\x16\n" +
+	"\x06Writer\x18\x04 \x01(\bR\x06Writer\"L\n" +
+	"\x06Attach\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\tR\x02Id\x12\x16\n" +
+	"\x06Offset\x18\x02 \x01(\x03R\x06Offset\x12\x1a\n" +
+	"\bReadOnly\x18\x03 \x01(\bR\bReadOnly\"M\n" +
+	"\aControl\x12&\n" +
+	"\x06Action\x18\x01 \x01(\x0e2\x0e.ControlActionR\x06Action\x12\x1a\n" +
+	"\bClientId\x18\x02 \x01(\tR\bClientId\"\xc3\x02\n" +
 	"\bShellMsg\x12!\n" +
 	"\x04type\x18\x01 \x01(\x0e2\r.ShellMsgTypeR\x04type\x12\x18\n" +
 	"\x03Cmd\x18\x02 \x01(\v2\x04.CmdH\x00R\x03Cmd\x12\x19\n" +
 	"\x02IO\x18\x03 \x01(\v2\a.IoDataH\x00R\x02IO\x12\"\n" +
-	"\x06Resize\x18\x04 \x01(\v2\b.WinSizeH\x00R\x06ResizeB\x06\n" +
-	"\x04Data*\\\n" +
+	"\x06Resize\x18\x04 \x01(\v2\b.WinSizeH\x00R\x06Resize\x12!\n" +
+	"\x04Exit\x18\x05 \x01(\v2\v.ExitStatusH\x00R\x04Exit\x12!\n" +
+	"\x06Signal\x18\x06 \x01(\v2\a.SignalH\x00R\x06Signal\x12$\n" +
+	"\aSession\x18\a \x01(\v2\b.SessionH\x00R\aSession\x12!\n" +
+	"\x06Attach\x18\b \x01(\v2\a.AttachH\x00R\x06Attach\x12$\n" +
+	"\aControl\x18\t \x01(\v2\b.ControlH\x00R\aControlB\x06\n" +
+	"\x04Data\"\xe0\x01\n" +
+	"\vSessionInfo\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\tR\x02Id\x12\x12\n" +
+	"\x04Path\x18\x02 \x01(\tR\x04Path\x12\x12\n" +
+	"\x04Args\x18\x03 \x03(\tR\x04Args\x12\x10\n" +
+	"\x03Pid\x18\x04 \x01(\x05R\x03Pid\x12\x18\n" +
+	"\aCreated\x18\x05 \x01(\x03R\aCreated\x12\x1a\n" +
+	"\bAttached\x18\x06 \x01(\bR\bAttached\x12\x16\n" +
+	"\x06Offset\x18\a \x01(\x03R\x06Offset\x12\x1f\n" +
+	"\x04Exit\x18\b \x01(\v2\v.ExitStatusR\x04Exit\x12\x18\n" +
+	"\aClients\x18\t \x01(\x05R\aClients\"\x15\n" +
+	"\x13ListSessionsRequest\"@\n" +
+	"\x14ListSessionsResponse\x12(\n" +
+	"\bSessions\x18\x01 \x03(\v2\f.SessionInfoR\bSessions\"$\n" +
+	"\x12KillSessionRequest\x12\x0e\n" +
+	"\x02Id\x18\x01 \x01(\tR\x02Id\"\x15\n" +
+	"\x13KillSessionResponse*\x9c\x02\n" +
 	"\fShellMsgType\x12\x15\n" +
 	"\x11SHELL_MSG_TYPE_IO\x10\x00\x12\x1a\n" +
 	"\x16SHELL_MSG_TYPE_COMMAND\x10\x01\x12\x19\n" +
-	"\x15SHELL_MSG_TYPE_RESIZE\x10\x02*/\n" +
+	"\x15SHELL_MSG_TYPE_RESIZE\x10\x02\x12\x17\n" +
+	"\x13SHELL_MSG_TYPE_EXIT\x10\x03\x12\x1c\n" +
+	"\x18SHELL_MSG_TYPE_STDIN_EOF\x10\x04\x12\x19\n" +
+	"\x15SHELL_MSG_TYPE_SIGNAL\x10\x05\x12\x1a\n" +
+	"\x16SHELL_MSG_TYPE_SESSION\x10\x06\x12\x19\n" +
+	"\x15SHELL_MSG_TYPE_ATTACH\x10\a\x12\x19\n" +
+	"\x15SHELL_MSG_TYPE_DETACH\x10\b\x12\x1a\n" +
+	"\x16SHELL_MSG_TYPE_CONTROL\x10\t*G\n" +
+	"\aEnvMode\x12\x12\n" +
+	"\x0eENV_MODE_MERGE\x10\x00\x12\x14\n" +
+	"\x10ENV_MODE_INHERIT\x10\x01\x12\x12\n" +
+	"\x0eENV_MODE_CLEAR\x10\x02*/\n" +
 	"\n" +
 	"IODataType\x12\t\n" +
 	"\x05Stdin\x10\x00\x12\n" +
 	"\n" +
 	"\x06Stdout\x10\x01\x12\n" +
 	"\n" +
-	"\x06Stderr\x10\x022*\n" +
+	"\x06Stderr\x10\x02*}\n" +
+	"\rControlAction\x12\x19\n" +
+	"\x15CONTROL_ACTION_WRITER\x10\x00\x12\x1b\n" +
+	"\x17CONTROL_ACTION_HANDOVER\x10\x01\x12\x17\n" +
+	"\x13CONTROL_ACTION_TAKE\x10\x02\x12\x1b\n" +
+	"\x17CONTROL_ACTION_REJECTED\x10\x032\xcc\x01\n" +
 	"\x05Shell\x12!\n" +
-	"\x05Shell\x12\t.ShellMsg\x1a\t.ShellMsg(\x010\x01B\bZ\x06.;coreb\x06proto3"
+	"\x05Shell\x12\t.ShellMsg\x1a\t.ShellMsg(\x010\x01\x12;\n" +
+	"\fListSessions\x12\x14.ListSessionsRequest\x1a\x15.ListSessionsResponse\x12)\n" +
+	"\rAttachSession\x12\t.ShellMsg\x1a\t.ShellMsg(\x010\x01\x128\n" +
+	"\vKillSession\x12\x13.KillSessionRequest\x1a\x14.KillSessionResponseB\bZ\x06.;coreb\x06proto3"
 
 var (
 	file_shell_proto_rawDescOnce sync.Once
@@ -731,35 +1703,64 @@ func file_shell_proto_rawDescGZIP() []byte {
 	return file_shell_proto_rawDescData
 }
 
-var file_shell_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_shell_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_shell_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_shell_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_shell_proto_goTypes = []any{
-	(ShellMsgType)(0),          // 0: ShellMsgType
-	(IODataType)(0),            // 1: IODataType
-	(*SysProcAttrLinux)(nil),   // 2: SysProcAttrLinux
-	(*SysProcAttrWindows)(nil), // 3: SysProcAttrWindows
-	(*Env)(nil),                // 4: Env
-	(*Cmd)(nil),                // 5: Cmd
-	(*WinSize)(nil),            // 6: WinSize
-	(*IoData)(nil),             // 7: IoData
-	(*ShellMsg)(nil),           // 8: ShellMsg
+	(ShellMsgType)(0),            // 0: ShellMsgType
+	(EnvMode)(0),                 // 1: EnvMode
+	(IODataType)(0),              // 2: IODataType
+	(ControlAction)(0),           // 3: ControlAction
+	(*SysProcAttrLinux)(nil),     // 4: SysProcAttrLinux
+	(*SysProcAttrWindows)(nil),   // 5: SysProcAttrWindows
+	(*Env)(nil),                  // 6: Env
+	(*Cmd)(nil),                  // 7: Cmd
+	(*WinSize)(nil),              // 8: WinSize
+	(*IoData)(nil),               // 9: IoData
+	(*Rusage)(nil),               // 10: Rusage
+	(*ExitStatus)(nil),           // 11: ExitStatus
+	(*Signal)(nil),               // 12: Signal
+	(*Session)(nil),              // 13: Session
+	(*Attach)(nil),               // 14: Attach
+	(*Control)(nil),              // 15: Control
+	(*ShellMsg)(nil),             // 16: ShellMsg
+	(*SessionInfo)(nil),          // 17: SessionInfo
+	(*ListSessionsRequest)(nil),  // 18: ListSessionsRequest
+	(*ListSessionsResponse)(nil), // 19: ListSessionsResponse
+	(*KillSessionRequest)(nil),   // 20: KillSessionRequest
+	(*KillSessionResponse)(nil),  // 21: KillSessionResponse
 }
 var file_shell_proto_depIdxs = []int32{
-	4, // 0: Cmd.Envs:type_name -> Env
-	2, // 1: Cmd.Linux:type_name -> SysProcAttrLinux
-	3, // 2: Cmd.Windows:type_name -> SysProcAttrWindows
-	1, // 3: IoData.Type:type_name -> IODataType
-	0, // 4: ShellMsg.type:type_name -> ShellMsgType
-	5, // 5: ShellMsg.Cmd:type_name -> Cmd
-	7, // 6: ShellMsg.IO:type_name -> IoData
-	6, // 7: ShellMsg.Resize:type_name -> WinSize
-	8, // 8: Shell.Shell:input_type -> ShellMsg
-	8, // 9: Shell.Shell:output_type -> ShellMsg
-	9, // [9:10] is the sub-list for method output_type
-	8, // [8:9] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	6,  // 0: Cmd.Envs:type_name -> Env
+	1,  // 1: Cmd.EnvMode:type_name -> EnvMode
+	4,  // 2: Cmd.Linux:type_name -> SysProcAttrLinux
+	5,  // 3: Cmd.Windows:type_name -> SysProcAttrWindows
+	2,  // 4: IoData.Type:type_name -> IODataType
+	10, // 5: ExitStatus.Rusage:type_name -> Rusage
+	3,  // 6: Control.Action:type_name -> ControlAction
+	0,  // 7: ShellMsg.type:type_name -> ShellMsgType
+	7,  // 8: ShellMsg.Cmd:type_name -> Cmd
+	9,  // 9: ShellMsg.IO:type_name -> IoData
+	8,  // 10: ShellMsg.Resize:type_name -> WinSize
+	11, // 11: ShellMsg.Exit:type_name -> ExitStatus
+	12, // 12: ShellMsg.Signal:type_name -> Signal
+	13, // 13: ShellMsg.Session:type_name -> Session
+	14, // 14: ShellMsg.Attach:type_name -> Attach
+	15, // 15: ShellMsg.Control:type_name -> Control
+	11, // 16: SessionInfo.Exit:type_name -> ExitStatus
+	17, // 17: ListSessionsResponse.Sessions:type_name -> SessionInfo
+	16, // 18: Shell.Shell:input_type -> ShellMsg
+	18, // 19: Shell.ListSessions:input_type -> ListSessionsRequest
+	16, // 20: Shell.AttachSession:input_type -> ShellMsg
+	20, // 21: Shell.KillSession:input_type -> KillSessionRequest
+	16, // 22: Shell.Shell:output_type -> ShellMsg
+	19, // 23: Shell.ListSessions:output_type -> ListSessionsResponse
+	16, // 24: Shell.AttachSession:output_type -> ShellMsg
+	21, // 25: Shell.KillSession:output_type -> KillSessionResponse
+	22, // [22:26] is the sub-list for method output_type
+	18, // [18:22] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_shell_proto_init() }
@@ -777,18 +1778,23 @@ func file_shell_proto_init() {
 		(*Cmd_Linux)(nil),
 		(*Cmd_Windows)(nil),
 	}
-	file_shell_proto_msgTypes[6].OneofWrappers = []any{
+	file_shell_proto_msgTypes[12].OneofWrappers = []any{
 		(*ShellMsg_Cmd)(nil),
 		(*ShellMsg_IO)(nil),
 		(*ShellMsg_Resize)(nil),
+		(*ShellMsg_Exit)(nil),
+		(*ShellMsg_Signal)(nil),
+		(*ShellMsg_Session)(nil),
+		(*ShellMsg_Attach)(nil),
+		(*ShellMsg_Control)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_shell_proto_rawDesc), len(file_shell_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   7,
+			NumEnums:      4,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,7 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Shell_Shell_FullMethodName = "/Shell/Shell"
+	Shell_Shell_FullMethodName         = "/Shell/Shell"
+	Shell_ListSessions_FullMethodName  = "/Shell/ListSessions"
+	Shell_AttachSession_FullMethodName = "/Shell/AttachSession"
+	Shell_KillSession_FullMethodName   = "/Shell/KillSession"
 )
 
 // ShellClient is the client API for Shell service.
@@ -27,6 +30,9 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ShellClient interface {
 	Shell(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ShellMsg, ShellMsg], error)
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	AttachSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ShellMsg, ShellMsg], error)
+	KillSession(ctx context.Context, in *KillSessionRequest, opts ...grpc.CallOption) (*KillSessionResponse, error)
 }
 
 type shellClient struct {
@@ -50,11 +56,47 @@ func (c *shellClient) Shell(ctx context.Context, opts ...grpc.CallOption) (grpc.
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Shell_ShellClient = grpc.BidiStreamingClient[ShellMsg, ShellMsg]
 
+func (c *shellClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, Shell_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shellClient) AttachSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ShellMsg, ShellMsg], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Shell_ServiceDesc.Streams[1], Shell_AttachSession_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ShellMsg, ShellMsg]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Shell_AttachSessionClient = grpc.BidiStreamingClient[ShellMsg, ShellMsg]
+
+func (c *shellClient) KillSession(ctx context.Context, in *KillSessionRequest, opts ...grpc.CallOption) (*KillSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KillSessionResponse)
+	err := c.cc.Invoke(ctx, Shell_KillSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShellServer is the server API for Shell service.
 // All implementations must embed UnimplementedShellServer
 // for forward compatibility.
 type ShellServer interface {
 	Shell(grpc.BidiStreamingServer[ShellMsg, ShellMsg]) error
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	AttachSession(grpc.BidiStreamingServer[ShellMsg, ShellMsg]) error
+	KillSession(context.Context, *KillSessionRequest) (*KillSessionResponse, error)
 	mustEmbedUnimplementedShellServer()
 }
 
@@ -68,6 +110,15 @@ type UnimplementedShellServer struct{}
 func (UnimplementedShellServer) Shell(grpc.BidiStreamingServer[ShellMsg, ShellMsg]) error {
 	return status.Errorf(codes.Unimplemented, "method Shell not implemented")
 }
+func (UnimplementedShellServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedShellServer) AttachSession(grpc.BidiStreamingServer[ShellMsg, ShellMsg]) error {
+	return status.Errorf(codes.Unimplemented, "method AttachSession not implemented")
+}
+func (UnimplementedShellServer) KillSession(context.Context, *KillSessionRequest) (*KillSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KillSession not implemented")
+}
 func (UnimplementedShellServer) mustEmbedUnimplementedShellServer() {}
 func (UnimplementedShellServer) testEmbeddedByValue()               {}
 
@@ -96,13 +147,65 @@ func _Shell_Shell_Handler(srv interface{}, stream grpc.ServerStream) error {
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Shell_ShellServer = grpc.BidiStreamingServer[ShellMsg, ShellMsg]
 
+func _Shell_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShellServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shell_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShellServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shell_AttachSession_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ShellServer).AttachSession(&grpc.GenericServerStream[ShellMsg, ShellMsg]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Shell_AttachSessionServer = grpc.BidiStreamingServer[ShellMsg, ShellMsg]
+
+func _Shell_KillSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KillSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShellServer).KillSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shell_KillSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShellServer).KillSession(ctx, req.(*KillSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Shell_ServiceDesc is the grpc.ServiceDesc for Shell service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var Shell_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "Shell",
 	HandlerType: (*ShellServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSessions",
+			Handler:    _Shell_ListSessions_Handler,
+		},
+		{
+			MethodName: "KillSession",
+			Handler:    _Shell_KillSession_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Shell",
@@ -110,6 +213,12 @@ var Shell_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "AttachSession",
+			Handler:       _Shell_AttachSession_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "shell.proto",
 }
@@ -12,10 +12,24 @@ func InsecureClient() grpc.DialOption {
 	return grpc.WithTransportCredentials(insecure.NewCredentials())
 }
 
+// ContextDialer 建立到 address 的连接, 签名与 grpc.WithContextDialer 的参数相同
 type ContextDialer interface {
 	DialContext(ctx context.Context, address string) (net.Conn, error)
 }
 
+// SessionDialer 在多路复用会话上打开 stream 的 ContextDialer, 会话关闭时 Done 返回的 channel 被关闭
+type SessionDialer interface {
+	ContextDialer
+	Done() <-chan struct{}
+	Close() error
+}
+
+// Session 多路复用会话, 既可以接收对端打开的 stream, 也可以主动打开 stream
+type Session interface {
+	net.Listener
+	SessionDialer
+}
+
 // NewClientConn 在 quic.Session 上初始化 *grpc.ClientConn
 func NewClientConn(dialer ContextDialer, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	opts = append(opts, grpc.WithContextDialer(dialer.DialContext))
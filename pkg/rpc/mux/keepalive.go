@@ -0,0 +1,93 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+)
+
+// defaultMaxFailures 默认连续失败多少次后认为对端已断开
+const defaultMaxFailures = 3
+
+// KeepAlive 连接保活策略, Interval 为 0 时不启用
+type KeepAlive struct {
+	// Interval 心跳间隔
+	Interval time.Duration
+	// Timeout 等待心跳响应的最长时间
+	Timeout time.Duration
+	// MaxFailures 连续失败多少次后认为对端已断开, 默认 3
+	MaxFailures int
+}
+
+// Enabled 是否启用保活
+func (k KeepAlive) Enabled() bool {
+	return k.Interval > 0
+}
+
+func (k KeepAlive) timeout() time.Duration {
+	if k.Timeout > 0 {
+		return k.Timeout
+	}
+	return k.Interval
+}
+
+// DialOption gRPC 客户端 keepalive, 与 NewClientConn 一起使用, gRPC 要求间隔不小于 10s
+func (k KeepAlive) DialOption() grpc.DialOption {
+	return grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                k.Interval,
+		Timeout:             k.timeout(),
+		PermitWithoutStream: true,
+	})
+}
+
+// ServerOptions gRPC 服务端 keepalive, 允许对端按 Interval 发送 ping
+func (k KeepAlive) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    k.Interval,
+			Timeout: k.timeout(),
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             k.Interval / 2,
+			PermitWithoutStream: true,
+		}),
+	}
+}
+
+// Heartbeat 每隔 Interval 通过 grpc health 服务检查对端,
+// 连续失败 MaxFailures 次后返回最后一次的错误, ctx 结束时返回 nil
+func (k KeepAlive) Heartbeat(ctx context.Context, cc grpc.ClientConnInterface) error {
+	maxFailures := k.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = defaultMaxFailures
+	}
+	client := healthpb.NewHealthClient(cc)
+	ticker := time.NewTicker(k.Interval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, k.timeout())
+		_, err := client.Check(checkCtx, &healthpb.HealthCheckRequest{})
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			failures = 0
+			continue
+		}
+		failures++
+		if failures >= maxFailures {
+			return fmt.Errorf("heartbeat failed %d times: %w", failures, err)
+		}
+	}
+}
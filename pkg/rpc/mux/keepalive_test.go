@@ -0,0 +1,67 @@
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+func TestHeartbeat(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	k := KeepAlive{Interval: 20 * time.Millisecond, Timeout: 50 * time.Millisecond, MaxFailures: 2}
+
+	gs := grpc.NewServer(k.ServerOptions()...)
+	healthpb.RegisterHealthServer(gs, health.NewServer())
+	go func() {
+		conn, err := tls.Dial("tcp", addr.String(), cConf)
+		require.NoError(t, err)
+		session, err := SMuxConnectListener(conn)
+		require.NoError(t, err)
+		_ = gs.Serve(session)
+	}()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	cliConn, err := SMUXClientConn(conn, InsecureClient())
+	require.NoError(t, err)
+	defer cliConn.Close()
+
+	hbErr := make(chan error, 1)
+	go func() {
+		hbErr <- k.Heartbeat(context.Background(), cliConn)
+	}()
+	select {
+	case err = <-hbErr:
+		t.Fatalf("heartbeat failed: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// 服务端停止后心跳失败
+	gs.Stop()
+	select {
+	case err = <-hbErr:
+		require.ErrorContains(t, err, "heartbeat failed 2 times")
+	case <-time.After(5 * time.Second):
+		t.Fatal("heartbeat did not fail")
+	}
+}
+
+func TestHeartbeatCancel(t *testing.T) {
+	cliConn, err := grpc.NewClient("127.0.0.1:1", InsecureClient())
+	require.NoError(t, err)
+	defer cliConn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	k := KeepAlive{Interval: 10 * time.Millisecond, MaxFailures: 100}
+	require.NoError(t, k.Heartbeat(ctx, cliConn))
+}
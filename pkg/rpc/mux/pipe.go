@@ -0,0 +1,38 @@
+package mux
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// Pipe 在两个连接之间双向复制数据, 一个方向读取结束时关闭另一端的写方向, 两个方向都结束后关闭连接
+func Pipe(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	cp := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, err := io.Copy(dst, src)
+		if err != nil {
+			// 出错时两个方向都结束
+			_ = a.Close()
+			_ = b.Close()
+			return
+		}
+		CloseWrite(dst)
+	}
+	go cp(a, b)
+	go cp(b, a)
+	wg.Wait()
+	_ = a.Close()
+	_ = b.Close()
+}
+
+// CloseWrite 关闭连接的写方向, 不支持时关闭整个连接
+func CloseWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
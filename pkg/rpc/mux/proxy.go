@@ -0,0 +1,196 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// Dialer 建立 TCP 连接, net.Dialer 和 ProxyDialer 的返回值都实现了该接口
+type Dialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// ProxyDialer 依次经过 proxies 连接目标地址, proxies[0] 为第一跳.
+// 支持 http:// 和 https:// (HTTP CONNECT) 以及 socks5:// 代理, URL 中的用户名密码用于认证.
+// forward 为空时直接连接第一个代理
+func ProxyDialer(forward Dialer, proxies ...*url.URL) (Dialer, error) {
+	d := forward
+	if d == nil {
+		d = &net.Dialer{}
+	}
+	for _, u := range proxies {
+		switch u.Scheme {
+		case "http", "https":
+			d = &connectDialer{proxy: u, forward: d}
+		case "socks5", "socks5h":
+			var auth *proxy.Auth
+			if u.User != nil {
+				password, _ := u.User.Password()
+				auth = &proxy.Auth{User: u.User.Username(), Password: password}
+			}
+			s, err := proxy.SOCKS5("tcp", proxyAddr(u, "1080"), auth, forwardDialer{d})
+			if err != nil {
+				return nil, err
+			}
+			d = s.(Dialer)
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme: %q", u.Scheme)
+		}
+	}
+	return d, nil
+}
+
+// ProxyFromEnvironment 返回连接 addr 时应使用的代理, 依次读取 HTTPS_PROXY 和 ALL_PROXY
+// (及小写形式), 并排除 NO_PROXY 中的地址和回环地址. 不使用代理时返回 nil
+func ProxyFromEnvironment(addr string) (*url.URL, error) {
+	conf := httpproxy.FromEnvironment()
+	if conf.HTTPSProxy == "" {
+		conf.HTTPSProxy = getEnvAny("ALL_PROXY", "all_proxy")
+	}
+	return conf.ProxyFunc()(&url.URL{Scheme: "https", Host: addr})
+}
+
+// EnvironmentDialer 返回根据 ProxyFromEnvironment 为每个地址选择代理的 Dialer
+func EnvironmentDialer(forward Dialer) Dialer {
+	if forward == nil {
+		forward = &net.Dialer{}
+	}
+	return &envDialer{forward: forward}
+}
+
+type envDialer struct {
+	forward Dialer
+}
+
+func (d *envDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	u, err := ProxyFromEnvironment(addr)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return d.forward.DialContext(ctx, network, addr)
+	}
+	pd, err := ProxyDialer(d.forward, u)
+	if err != nil {
+		return nil, err
+	}
+	return pd.DialContext(ctx, network, addr)
+}
+
+func getEnvAny(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// proxyAddr 返回代理的 host:port, 未指定端口时使用 port
+func proxyAddr(u *url.URL, port string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// forwardDialer 将 Dialer 适配为 proxy.Dialer, proxy.SOCKS5 优先使用 DialContext
+type forwardDialer struct {
+	Dialer
+}
+
+func (d forwardDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// connectDialer 通过 HTTP CONNECT 代理连接
+type connectDialer struct {
+	proxy   *url.URL
+	forward Dialer
+}
+
+func (d *connectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, errors.New("proxy: unsupported network " + network)
+	}
+	port := "80"
+	if d.proxy.Scheme == "https" {
+		port = "443"
+	}
+	conn, err := d.forward.DialContext(ctx, "tcp", proxyAddr(d.proxy, port))
+	if err != nil {
+		return nil, err
+	}
+	if d.proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxy.Hostname()})
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	tunnel, err := httpConnect(ctx, conn, d.proxy, addr)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tunnel, nil
+}
+
+// bufferedConn 先读取 bufio.Reader 中已缓冲的数据
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// httpConnect 在到代理的连接上发送 CONNECT 请求, 返回到 addr 的隧道
+func httpConnect(ctx context.Context, conn net.Conn, proxy *url.URL, addr string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(noDeadline)
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	err := req.Write(conn)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy %s: CONNECT %s: %s", proxy.Host, addr, res.Status)
+	}
+	if r.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+	return conn, nil
+}
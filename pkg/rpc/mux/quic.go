@@ -9,32 +9,72 @@ import (
 	"google.golang.org/grpc"
 )
 
-// QuicConnectListener 包装 quic.quicStreamConnect 以实现  net.Listener
-func QuicConnectListener(connect *quic.Conn) net.Listener {
-	return listener{connect: connect}
+// QuicConnectListener 包装 quic.Conn 以实现 net.Listener
+//
+// Deprecated: 使用 NewSession(NewQUICTunnel(conn))
+func QuicConnectListener(connect *quic.Conn) Session {
+	return NewSession(NewQUICTunnel(connect))
 }
 
-type listener struct {
+// QuicConnectDialer 包装 quic.Conn 以打开 stream
+//
+// Deprecated: 使用 NewSession(NewQUICTunnel(conn))
+func QuicConnectDialer(conn *quic.Conn) Session {
+	return NewSession(NewQUICTunnel(conn))
+}
+
+// NewQUICTunnel 使用 QUIC 连接的 stream 实现 Tunnel
+func NewQUICTunnel(conn *quic.Conn) Tunnel {
+	return &quicTunnel{connect: conn}
+}
+
+// quicTunnel QUIC 连接实现的 Tunnel
+type quicTunnel struct {
 	connect *quic.Conn
+	stats   tunnelStats
 }
 
-// Accept implements net.Listener
-func (l listener) Accept() (net.Conn, error) {
-	st, err := l.connect.AcceptStream(l.connect.Context())
+// OpenStream implements Tunnel
+func (t *quicTunnel) OpenStream(ctx context.Context) (net.Conn, error) {
+	stream, err := t.connect.OpenStreamSync(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return quicStreamConnect(l.connect, st), err
+	return t.stats.track(quicStreamConnect(t.connect, stream), true), nil
+}
+
+// AcceptStream implements Tunnel
+func (t *quicTunnel) AcceptStream(ctx context.Context) (net.Conn, error) {
+	stream, err := t.connect.AcceptStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.stats.track(quicStreamConnect(t.connect, stream), false), nil
+}
+
+// Close implements Tunnel
+func (t *quicTunnel) Close() error {
+	return t.connect.CloseWithError(0, "tunnel closed")
+}
+
+// Done implements Tunnel
+func (t *quicTunnel) Done() <-chan struct{} {
+	return t.connect.Context().Done()
 }
 
-// Close implements net.Listener
-func (l listener) Close() error {
-	return l.connect.CloseWithError(0, "server close")
+// LocalAddr implements Tunnel
+func (t *quicTunnel) LocalAddr() net.Addr {
+	return t.connect.LocalAddr()
 }
 
-// Addr implements net.Listener
-func (l listener) Addr() net.Addr {
-	return l.connect.LocalAddr()
+// RemoteAddr implements Tunnel
+func (t *quicTunnel) RemoteAddr() net.Addr {
+	return t.connect.RemoteAddr()
+}
+
+// Stats implements Tunnel
+func (t *quicTunnel) Stats() TunnelStats {
+	return t.stats.snapshot()
 }
 
 // quicStreamConnect implements net.Conn
@@ -82,25 +122,7 @@ func (c warpQuicConnect) SetWriteDeadline(t time.Time) error {
 	return c.stream.SetWriteDeadline(t)
 }
 
-// quicConnectDialer 连接创建器封装
-type quicConnectDialer struct {
-	connect *quic.Conn
-}
-
-// DialContext dial with ctx
-func (d *quicConnectDialer) DialContext(ctx context.Context, _ string) (net.Conn, error) {
-	stream, err := d.connect.OpenStreamSync(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return quicStreamConnect(d.connect, stream), nil
-}
-
-func QuicConnectDialer(conn *quic.Conn) ContextDialer {
-	return &quicConnectDialer{connect: conn}
-}
-
-// QUIClientConn 创建一个quic.Conn
+// QUIClientConn 在 QUIC 连接上创建 *grpc.ClientConn
 func QUIClientConn(conn *quic.Conn, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-	return NewClientConn(QuicConnectDialer(conn), opts...)
+	return NewClientConn(NewSession(NewQUICTunnel(conn)), opts...)
 }
@@ -0,0 +1,129 @@
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// QUICOptions QUIC 连接参数, 为 0 的字段使用 quic-go 的默认值
+type QUICOptions struct {
+	HandshakeIdleTimeout time.Duration
+	// MaxIdleTimeout 超过该时间没有收到数据时关闭连接
+	MaxIdleTimeout time.Duration
+	// KeepAlivePeriod 发送 keepalive 的间隔, 应小于 MaxIdleTimeout
+	KeepAlivePeriod time.Duration
+	// MaxIncomingStreams 对端可以同时打开的 stream 数
+	MaxIncomingStreams int64
+	// 流量控制窗口, 窗口从 Initial 开始按需增长到 Max
+	InitialStreamReceiveWindow     uint64
+	MaxStreamReceiveWindow         uint64
+	InitialConnectionReceiveWindow uint64
+	MaxConnectionReceiveWindow     uint64
+	// EnableDatagrams 启用 QUIC datagram (RFC 9221)
+	EnableDatagrams bool
+	// Enable0RTT 服务端接受 0-RTT, 客户端恢复会话时在第一个往返中发送数据
+	Enable0RTT bool
+	// SessionCache 客户端保存会话票据的缓存, 多次连接使用同一个缓存才能恢复会话,
+	// 为空时使用 tls.Config 中的 ClientSessionCache
+	SessionCache tls.ClientSessionCache
+}
+
+// Validate 检查参数是否有效
+func (o QUICOptions) Validate() error {
+	if o.HandshakeIdleTimeout < 0 || o.MaxIdleTimeout < 0 || o.KeepAlivePeriod < 0 {
+		return errors.New("quic: timeout must not be negative")
+	}
+	if o.MaxIdleTimeout > 0 && o.KeepAlivePeriod >= o.MaxIdleTimeout {
+		return errors.New("quic: keepalive period must be less than max idle timeout")
+	}
+	if o.MaxIncomingStreams < 0 {
+		return errors.New("quic: max incoming streams must not be negative")
+	}
+	if o.MaxStreamReceiveWindow > 0 && o.InitialStreamReceiveWindow > o.MaxStreamReceiveWindow {
+		return errors.New("quic: initial stream receive window must not be larger than max stream receive window")
+	}
+	if o.MaxConnectionReceiveWindow > 0 && o.InitialConnectionReceiveWindow > o.MaxConnectionReceiveWindow {
+		return errors.New("quic: initial connection receive window must not be larger than max connection receive window")
+	}
+	return nil
+}
+
+// WithKeepAlive 未设置 KeepAlivePeriod 和 MaxIdleTimeout 时使用 k 的间隔
+func (o QUICOptions) WithKeepAlive(k KeepAlive) QUICOptions {
+	if !k.Enabled() || o.KeepAlivePeriod > 0 || o.MaxIdleTimeout > 0 {
+		return o
+	}
+	o.KeepAlivePeriod = k.Interval
+	o.MaxIdleTimeout = k.Interval + k.timeout()
+	return o
+}
+
+// Config 转换为 quic.Config
+func (o QUICOptions) Config() *quic.Config {
+	return &quic.Config{
+		HandshakeIdleTimeout:           o.HandshakeIdleTimeout,
+		MaxIdleTimeout:                 o.MaxIdleTimeout,
+		KeepAlivePeriod:                o.KeepAlivePeriod,
+		MaxIncomingStreams:             o.MaxIncomingStreams,
+		InitialStreamReceiveWindow:     o.InitialStreamReceiveWindow,
+		MaxStreamReceiveWindow:         o.MaxStreamReceiveWindow,
+		InitialConnectionReceiveWindow: o.InitialConnectionReceiveWindow,
+		MaxConnectionReceiveWindow:     o.MaxConnectionReceiveWindow,
+		EnableDatagrams:                o.EnableDatagrams,
+		Allow0RTT:                      o.Enable0RTT,
+	}
+}
+
+// DialQUIC 连接 QUIC 服务端, 不修改 tlsConf, tlsConf 为 nil 时使用默认配置.
+// Enable0RTT 时需要设置 opts.SessionCache 或 tlsConf.ClientSessionCache 才能在重连时恢复会话
+func DialQUIC(ctx context.Context, addr string, tlsConf *tls.Config, opts QUICOptions) (*quic.Conn, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConf == nil {
+		tlsConf = &tls.Config{}
+	} else {
+		tlsConf = tlsConf.Clone()
+	}
+	if opts.SessionCache != nil {
+		tlsConf.ClientSessionCache = opts.SessionCache
+	}
+	if !opts.Enable0RTT {
+		return quic.DialAddr(ctx, addr, tlsConf, opts.Config())
+	}
+	return quic.DialAddrEarly(ctx, addr, tlsConf, opts.Config())
+}
+
+// QUICListener *quic.Listener 或 *quic.EarlyListener
+type QUICListener interface {
+	Accept(ctx context.Context) (*quic.Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
+// ListenQUIC 监听 QUIC 连接, Enable0RTT 时 Accept 在握手完成前返回,
+// 需要对端证书时应等待 HandshakeComplete
+func ListenQUIC(addr string, tlsConf *tls.Config, opts QUICOptions) (QUICListener, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Enable0RTT {
+		l, err := quic.ListenAddrEarly(addr, tlsConf, opts.Config())
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	l, err := quic.ListenAddr(addr, tlsConf, opts.Config())
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
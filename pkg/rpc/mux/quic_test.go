@@ -2,10 +2,13 @@ package mux
 
 import (
 	"context"
+	"crypto/tls"
 	"testing"
+	"time"
 
 	"github.com/quic-go/quic-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -110,3 +113,78 @@ func TestBlankRes(t *testing.T) {
 	_, err = cli.Blank(ctx, &testdata.BlankMsg{})
 	assert.NoError(t, err)
 }
+
+func TestQUIC0RTT(t *testing.T) {
+	sConf, cConf := testutil.GetTLCConfig()
+	opts := QUICOptions{
+		MaxIdleTimeout:     5 * time.Second,
+		KeepAlivePeriod:    time.Second,
+		MaxIncomingStreams: 16,
+		EnableDatagrams:    true,
+		Enable0RTT:         true,
+		SessionCache:       tls.NewLRUClientSessionCache(0),
+	}
+	l, err := ListenQUIC("127.0.0.1:0", sConf, opts)
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept(ctx)
+			if err != nil {
+				return
+			}
+			go func() {
+				gs := grpc.NewServer()
+				testdata.RegisterFooServer(gs, &testServer{})
+				_ = gs.Serve(QuicConnectListener(conn))
+			}()
+		}
+	}()
+
+	call := func() quic.ConnectionState {
+		conn, err := DialQUIC(ctx, l.Addr().String(), cConf, opts)
+		require.NoError(t, err)
+		defer conn.CloseWithError(0, "")
+		cliConn, err := QUIClientConn(conn, InsecureClient())
+		require.NoError(t, err)
+		defer cliConn.Close()
+		res, err := testdata.NewFooClient(cliConn).Bar(ctx, &testdata.Msg{Data: "foo"})
+		require.NoError(t, err)
+		require.Equal(t, "foobar", res.Data)
+		<-conn.HandshakeComplete()
+		return conn.ConnectionState()
+	}
+	state := call()
+	require.False(t, state.Used0RTT)
+	require.True(t, state.SupportsDatagrams)
+	// 会话缓存保存在 opts 中, 不修改调用方的 tls.Config
+	require.Nil(t, cConf.ClientSessionCache)
+	// 复用会话缓存, 第二次连接使用 0-RTT
+	state = call()
+	require.True(t, state.Used0RTT)
+}
+
+func TestDialQUICNilConfig(t *testing.T) {
+	sConf, _ := testutil.GetTLCConfig()
+	l, err := ListenQUIC("127.0.0.1:0", sConf, QUICOptions{})
+	require.NoError(t, err)
+	defer l.Close()
+	// 未配置 TLS 时握手失败, 不能 panic
+	_, err = DialQUIC(ctx, l.Addr().String(), nil, QUICOptions{SessionCache: tls.NewLRUClientSessionCache(0)})
+	require.Error(t, err)
+}
+
+func TestQUICOptionsValidate(t *testing.T) {
+	require.NoError(t, QUICOptions{}.Validate())
+	require.Error(t, QUICOptions{MaxIdleTimeout: time.Second, KeepAlivePeriod: time.Second}.Validate())
+	require.Error(t, QUICOptions{InitialStreamReceiveWindow: 2 << 20, MaxStreamReceiveWindow: 1 << 20}.Validate())
+	require.Error(t, QUICOptions{InitialConnectionReceiveWindow: 2 << 20, MaxConnectionReceiveWindow: 1 << 20}.Validate())
+	require.Error(t, QUICOptions{MaxIncomingStreams: -1}.Validate())
+	_, err := ListenQUIC("127.0.0.1:0", nil, QUICOptions{HandshakeIdleTimeout: -time.Second})
+	require.Error(t, err)
+
+	o := QUICOptions{}.WithKeepAlive(KeepAlive{Interval: time.Second, Timeout: 2 * time.Second})
+	require.Equal(t, time.Second, o.KeepAlivePeriod)
+	require.Equal(t, 3*time.Second, o.MaxIdleTimeout)
+	require.NoError(t, o.Validate())
+}
@@ -2,7 +2,11 @@ package mux
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/xtaci/smux"
 	"google.golang.org/grpc"
@@ -14,58 +18,209 @@ func defaultSMuxConfig() *smux.Config {
 	return c
 }
 
-func SMuxConnectListener(conn net.Conn) (net.Listener, error) {
-	session, err := smux.Server(conn, defaultSMuxConfig())
+// SMuxOption smux 会话参数
+type SMuxOption struct {
+	apply func(*smux.Config)
+}
+
+// WithMaxReceiveBuffer 整个会话的接收缓冲区大小
+func WithMaxReceiveBuffer(n int) SMuxOption {
+	return SMuxOption{apply: func(c *smux.Config) { c.MaxReceiveBuffer = n }}
+}
+
+// WithMaxStreamBuffer 单个 stream 的接收缓冲区大小, 不能超过 MaxReceiveBuffer
+func WithMaxStreamBuffer(n int) SMuxOption {
+	return SMuxOption{apply: func(c *smux.Config) { c.MaxStreamBuffer = n }}
+}
+
+// WithMaxFrameSize 单个帧的最大字节数, 不能超过 65535
+func WithMaxFrameSize(n int) SMuxOption {
+	return SMuxOption{apply: func(c *smux.Config) { c.MaxFrameSize = n }}
+}
+
+// WithKeepAlive 发送 keepalive 的间隔, 以及超过多久没有收到数据时关闭会话, timeout 不能小于 interval
+func WithKeepAlive(interval, timeout time.Duration) SMuxOption {
+	return SMuxOption{apply: func(c *smux.Config) {
+		c.KeepAliveDisabled = false
+		c.KeepAliveInterval = interval
+		c.KeepAliveTimeout = timeout
+	}}
+}
+
+// smuxConfig 在默认配置上应用 opts 并校验
+func smuxConfig(opts []SMuxOption) (*smux.Config, error) {
+	c := defaultSMuxConfig()
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	err := smux.VerifyConfig(c)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("smux: %w", err)
 	}
-	return &smuxListener{
-		connect: conn,
-		session: session,
-	}, nil
+	return c, nil
 }
 
-type smuxListener struct {
-	connect net.Conn
-	session *smux.Session
+// VerifySMuxOptions 检查 opts 是否为有效的 smux 配置
+func VerifySMuxOptions(opts ...SMuxOption) error {
+	_, err := smuxConfig(opts)
+	return err
 }
 
-func (s *smuxListener) Accept() (net.Conn, error) {
-	return s.session.AcceptStream()
+// SMuxConnectListener 在连接上创建 smux 服务端会话
+//
+// Deprecated: 使用 NewSession(NewSMuxTunnel(conn, true, opts...))
+func SMuxConnectListener(conn net.Conn, opts ...SMuxOption) (Session, error) {
+	t, err := NewSMuxTunnel(conn, true, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewSession(t), nil
 }
 
-func (s *smuxListener) Close() error {
-	return s.connect.Close()
+// SMUXConnectDialer 在连接上创建 smux 客户端会话
+//
+// Deprecated: 使用 NewSession(NewSMuxTunnel(conn, false, opts...))
+func SMUXConnectDialer(conn net.Conn, opts ...SMuxOption) (Session, error) {
+	t, err := NewSMuxTunnel(conn, false, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewSession(t), nil
 }
 
-func (s *smuxListener) Addr() net.Addr {
-	return s.connect.LocalAddr()
+// NewSMuxTunnel 在可靠连接上创建 smux Tunnel, 两端的 server 必须不同, 用于区分 stream ID
+func NewSMuxTunnel(conn net.Conn, server bool, opts ...SMuxOption) (Tunnel, error) {
+	c, err := smuxConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	rc := &readErrConn{Conn: conn, failed: make(chan struct{})}
+	var session *smux.Session
+	if server {
+		session, err = smux.Server(rc, c)
+	} else {
+		session, err = smux.Client(rc, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &smuxTunnel{connect: conn, session: session, streams: make(chan net.Conn)}
+	go func() {
+		select {
+		case <-rc.failed:
+			_ = session.Close()
+		case <-session.CloseChan():
+		}
+	}()
+	go t.acceptLoop()
+	return t, nil
 }
 
-// quicConnectDialer 连接创建器封装
-type smuxConnectDialer struct {
+// smuxTunnel smux 会话实现的 Tunnel
+type smuxTunnel struct {
 	connect net.Conn
 	session *smux.Session
+	stats   tunnelStats
+	// streams acceptLoop 接收的 stream, 结束后 acceptErr 为接收失败的原因
+	streams   chan net.Conn
+	acceptErr error
 }
 
-// DialContext dial with ctx
-func (d *smuxConnectDialer) DialContext(ctx context.Context, _ string) (net.Conn, error) {
-	return d.session.OpenStream()
+// acceptLoop smux.Session.AcceptStream 不支持 context, 在后台接收 stream
+func (t *smuxTunnel) acceptLoop() {
+	defer close(t.streams)
+	for {
+		st, err := t.session.AcceptStream()
+		if err != nil {
+			t.acceptErr = err
+			return
+		}
+		select {
+		case t.streams <- st:
+		case <-t.session.CloseChan():
+			_ = st.Close()
+			t.acceptErr = io.ErrClosedPipe
+			return
+		}
+	}
 }
 
-func SMUXConnectDialer(conn net.Conn) (ContextDialer, error) {
-	session, err := smux.Client(conn, defaultSMuxConfig())
+// OpenStream implements Tunnel
+func (t *smuxTunnel) OpenStream(ctx context.Context) (net.Conn, error) {
+	err := ctx.Err()
 	if err != nil {
 		return nil, err
 	}
-	return &smuxConnectDialer{connect: conn, session: session}, nil
+	st, err := t.session.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return t.stats.track(st, true), nil
+}
+
+// AcceptStream implements Tunnel
+func (t *smuxTunnel) AcceptStream(ctx context.Context) (net.Conn, error) {
+	select {
+	case st, ok := <-t.streams:
+		if !ok {
+			return nil, t.acceptErr
+		}
+		return t.stats.track(st, false), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close 关闭会话和底层连接
+func (t *smuxTunnel) Close() error {
+	return t.session.Close()
+}
+
+// Done implements Tunnel
+func (t *smuxTunnel) Done() <-chan struct{} {
+	return t.session.CloseChan()
+}
+
+// LocalAddr implements Tunnel
+func (t *smuxTunnel) LocalAddr() net.Addr {
+	return t.connect.LocalAddr()
+}
+
+// RemoteAddr implements Tunnel
+func (t *smuxTunnel) RemoteAddr() net.Addr {
+	return t.connect.RemoteAddr()
+}
+
+// Stats implements Tunnel
+func (t *smuxTunnel) Stats() TunnelStats {
+	return t.stats.snapshot()
+}
+
+// readErrConn 读取出错时关闭 failed, smux 会话在底层连接断开时不会自动关闭
+type readErrConn struct {
+	net.Conn
+	once   sync.Once
+	failed chan struct{}
+}
+
+func (c *readErrConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil {
+		c.once.Do(func() { close(c.failed) })
+	}
+	return n, err
 }
 
-// SMUXClientConn 创建一个quic.Conn
+// SMUXClientConn 在连接上使用默认参数创建 smux 客户端会话和 *grpc.ClientConn
 func SMUXClientConn(conn net.Conn, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-	dialer, err := SMUXConnectDialer(conn)
+	return NewSMuxClientConn(conn, nil, opts...)
+}
+
+// NewSMuxClientConn 在连接上按 smuxOpts 创建 smux 客户端会话和 *grpc.ClientConn
+func NewSMuxClientConn(conn net.Conn, smuxOpts []SMuxOption, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	t, err := NewSMuxTunnel(conn, false, smuxOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return NewClientConn(dialer, opts...)
+	return NewClientConn(NewSession(t), opts...)
 }
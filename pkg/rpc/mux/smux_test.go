@@ -3,7 +3,10 @@ package mux
 import (
 	"context"
 	"crypto/tls"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -38,3 +41,57 @@ func TestGRPCWithSMuxOverTLS(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "foobar", res.Data)
 }
+
+func TestSMuxOptions(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr, err := testutil.RandomLocalListenTLS()
+	require.NoError(t, err)
+	defer l.Close()
+	opts := []SMuxOption{
+		WithMaxReceiveBuffer(1 << 20),
+		WithMaxStreamBuffer(1 << 18),
+		WithMaxFrameSize(16 << 10),
+		WithKeepAlive(time.Second, 5*time.Second),
+	}
+	go func() {
+		conn, err := tls.Dial("tcp", addr.String(), cConf)
+		require.NoError(t, err)
+		gs := grpc.NewServer()
+		testdata.RegisterFooServer(gs, &testServer{})
+		l, err := SMuxConnectListener(conn, opts...)
+		require.NoError(t, err)
+		_ = gs.Serve(l)
+	}()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	cliConn, err := NewSMuxClientConn(conn, opts, InsecureClient())
+	require.NoError(t, err)
+	defer cliConn.Close()
+	// 大于 MaxFrameSize 的消息被拆分为多个帧
+	data := strings.Repeat("a", 100<<10)
+	res, err := testdata.NewFooClient(cliConn).Bar(context.Background(), &testdata.Msg{Data: data})
+	require.NoError(t, err)
+	require.Equal(t, data+"bar", res.Data)
+}
+
+func TestSMuxOptionValidation(t *testing.T) {
+	cases := map[string][]SMuxOption{
+		"stream buffer larger than receive buffer": {WithMaxReceiveBuffer(1024), WithMaxStreamBuffer(2048)},
+		"frame size too large":                     {WithMaxFrameSize(1 << 16)},
+		"zero receive buffer":                      {WithMaxReceiveBuffer(0)},
+		"timeout less than interval":               {WithKeepAlive(10*time.Second, time.Second)},
+	}
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			defer c2.Close()
+			_, err := SMuxConnectListener(c1, opts...)
+			require.ErrorContains(t, err, "smux:")
+			_, err = SMUXConnectDialer(c2, opts...)
+			require.Error(t, err)
+			_, err = NewSMuxClientConn(c2, opts, InsecureClient())
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package mux
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// Tunnel 到对端的多路复用连接, 两端都可以打开和接收 stream.
+// QUIC 连接使用 NewQUICTunnel, TLS 和 WebSocket 等可靠连接使用 NewSMuxTunnel
+type Tunnel interface {
+	// OpenStream 打开一个新的 stream
+	OpenStream(ctx context.Context) (net.Conn, error)
+	// AcceptStream 等待对端打开的 stream
+	AcceptStream(ctx context.Context) (net.Conn, error)
+	// Close 关闭 tunnel 和所有 stream
+	Close() error
+	// Done tunnel 关闭或底层连接断开时关闭
+	Done() <-chan struct{}
+	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
+	// Stats 返回 stream 和流量统计
+	Stats() TunnelStats
+}
+
+// TunnelStats Tunnel 的统计信息
+type TunnelStats struct {
+	// ActiveStreams 当前未关闭的 stream 数量
+	ActiveStreams int64
+	// OpenedStreams 本端打开的 stream 总数
+	OpenedStreams uint64
+	// AcceptedStreams 对端打开的 stream 总数
+	AcceptedStreams uint64
+	// BytesSent 所有 stream 写入的字节数
+	BytesSent uint64
+	// BytesReceived 所有 stream 读取的字节数
+	BytesReceived uint64
+}
+
+// tunnelStats 各 Tunnel 实现共用的计数器
+type tunnelStats struct {
+	active   atomic.Int64
+	opened   atomic.Uint64
+	accepted atomic.Uint64
+	sent     atomic.Uint64
+	received atomic.Uint64
+}
+
+func (s *tunnelStats) snapshot() TunnelStats {
+	return TunnelStats{
+		ActiveStreams:   s.active.Load(),
+		OpenedStreams:   s.opened.Load(),
+		AcceptedStreams: s.accepted.Load(),
+		BytesSent:       s.sent.Load(),
+		BytesReceived:   s.received.Load(),
+	}
+}
+
+// track 统计 stream, opened 为 true 表示本端打开
+func (s *tunnelStats) track(conn net.Conn, opened bool) net.Conn {
+	if opened {
+		s.opened.Add(1)
+	} else {
+		s.accepted.Add(1)
+	}
+	s.active.Add(1)
+	return &countedConn{Conn: conn, stats: s}
+}
+
+// countedConn 统计读写字节数, 关闭时减少 ActiveStreams
+type countedConn struct {
+	net.Conn
+	stats *tunnelStats
+	once  sync.Once
+}
+
+func (c *countedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.stats.received.Add(uint64(n))
+	return n, err
+}
+
+func (c *countedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.stats.sent.Add(uint64(n))
+	return n, err
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { c.stats.active.Add(-1) })
+	return c.Conn.Close()
+}
+
+// NewSession 将 Tunnel 适配为 Session, 用于 grpc.Server.Serve 和 NewClientConn
+func NewSession(t Tunnel) Session {
+	return tunnelSession{t}
+}
+
+type tunnelSession struct {
+	Tunnel
+}
+
+// Accept implements net.Listener
+func (s tunnelSession) Accept() (net.Conn, error) {
+	return s.AcceptStream(context.Background())
+}
+
+// Addr implements net.Listener
+func (s tunnelSession) Addr() net.Addr {
+	return s.LocalAddr()
+}
+
+// DialContext implements ContextDialer
+func (s tunnelSession) DialContext(ctx context.Context, _ string) (net.Conn, error) {
+	return s.OpenStream(ctx)
+}
@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+// smuxTunnels 在 net.Pipe 两端创建 smux Tunnel
+func smuxTunnels(t *testing.T) (Tunnel, Tunnel) {
+	c1, c2 := net.Pipe()
+	server, err := NewSMuxTunnel(c1, true)
+	require.NoError(t, err)
+	client, err := NewSMuxTunnel(c2, false)
+	require.NoError(t, err)
+	return server, client
+}
+
+// quicTunnels 通过本地 QUIC 连接创建两端的 Tunnel
+func quicTunnels(t *testing.T) (Tunnel, Tunnel) {
+	sConf, cConf := testutil.GetTLCConfig()
+	l, err := ListenQUIC("127.0.0.1:0", sConf, QUICOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	conn, err := DialQUIC(ctx, l.Addr().String(), cConf, QUICOptions{})
+	require.NoError(t, err)
+	accepted, err := l.Accept(ctx)
+	require.NoError(t, err)
+	return NewQUICTunnel(accepted), NewQUICTunnel(conn)
+}
+
+// pingStream 在 from 上打开 stream, 在 to 上接收并回显
+func pingStream(t *testing.T, from, to Tunnel) {
+	st, err := from.OpenStream(ctx)
+	require.NoError(t, err)
+	defer st.Close()
+	// QUIC stream 在写入数据后对端才能接收
+	_, err = st.Write([]byte("ping"))
+	require.NoError(t, err)
+	peer, err := to.AcceptStream(ctx)
+	require.NoError(t, err)
+	defer peer.Close()
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(peer, buf)
+	require.NoError(t, err)
+	_, err = peer.Write(buf)
+	require.NoError(t, err)
+	_, err = io.ReadFull(st, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+}
+
+func testTunnel(t *testing.T, newTunnels func(*testing.T) (Tunnel, Tunnel)) {
+	server, client := newTunnels(t)
+	require.NotNil(t, client.RemoteAddr())
+	require.NotNil(t, client.LocalAddr())
+
+	// 两端都可以打开 stream
+	pingStream(t, client, server)
+	pingStream(t, server, client)
+	stats := client.Stats()
+	require.EqualValues(t, 1, stats.OpenedStreams)
+	require.EqualValues(t, 1, stats.AcceptedStreams)
+	require.EqualValues(t, 0, stats.ActiveStreams)
+	require.EqualValues(t, 8, stats.BytesSent)
+	require.EqualValues(t, 8, stats.BytesReceived)
+
+	acceptCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := server.AcceptStream(acceptCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, client.Close())
+	for _, tunnel := range []Tunnel{client, server} {
+		select {
+		case <-tunnel.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("tunnel not closed")
+		}
+	}
+	_, err = server.AcceptStream(ctx)
+	require.Error(t, err)
+}
+
+func TestSMuxTunnel(t *testing.T) {
+	testTunnel(t, smuxTunnels)
+}
+
+func TestQUICTunnel(t *testing.T) {
+	testTunnel(t, quicTunnels)
+}
@@ -0,0 +1,213 @@
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+var noDeadline time.Time
+
+// wsConn 将 WebSocket 连接适配为 net.Conn, 数据使用二进制帧传输
+type wsConn struct {
+	*websocket.Conn
+	local, remote net.Addr
+	tlsState      *tls.ConnectionState
+	once          sync.Once
+	closed        chan struct{}
+}
+
+func newWSConn(ws *websocket.Conn, local, remote net.Addr, state *tls.ConnectionState) *wsConn {
+	ws.PayloadType = websocket.BinaryFrame
+	return &wsConn{Conn: ws, local: local, remote: remote, tlsState: state, closed: make(chan struct{})}
+}
+
+// LocalAddr 返回底层连接的地址, websocket.Conn 返回的是 URL
+func (c *wsConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr 返回底层连接的地址
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// tcpAddr http.Request.RemoteAddr
+type tcpAddr string
+
+func (a tcpAddr) Network() string {
+	return "tcp"
+}
+
+func (a tcpAddr) String() string {
+	return string(a)
+}
+
+// Close 关闭连接
+func (c *wsConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+// ConnectionState 返回 WebSocket 所在 TLS 连接的状态, 未使用 TLS 时返回空值
+func (c *wsConn) ConnectionState() tls.ConnectionState {
+	if c.tlsState == nil {
+		return tls.ConnectionState{}
+	}
+	return *c.tlsState
+}
+
+// DialWebSocket 连接 ws:// 或 wss:// 地址, 使用 dialer 建立 TCP 连接, dialer 为空时直接连接.
+// tlsConf 的 ALPN 被替换为 http/1.1
+func DialWebSocket(ctx context.Context, rawURL string, tlsConf *tls.Config, dialer Dialer) (net.Conn, error) {
+	config, err := websocket.NewConfig(rawURL, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	addr := config.Location.Host
+	if config.Location.Port() == "" {
+		port := "80"
+		if config.Location.Scheme == "wss" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(config.Location.Hostname(), port)
+	}
+
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var state *tls.ConnectionState
+	if config.Location.Scheme == "wss" {
+		conf := &tls.Config{}
+		if tlsConf != nil {
+			conf = tlsConf.Clone()
+		}
+		conf.NextProtos = []string{"http/1.1"}
+		if conf.ServerName == "" {
+			conf.ServerName = config.Location.Hostname()
+		}
+		tlsConn := tls.Client(conn, conf)
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		s := tlsConn.ConnectionState()
+		state = &s
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(noDeadline)
+	return newWSConn(ws, conn.LocalAddr(), conn.RemoteAddr(), state), nil
+}
+
+// DialWebSocketTunnel 连接 WebSocket 地址并在连接上创建 smux Tunnel, agent 侧使用.
+// 控制端使用 NewSMuxTunnel(conn, false, opts...) 处理 WebSocketListener 接收的连接
+func DialWebSocketTunnel(ctx context.Context, rawURL string, tlsConf *tls.Config, dialer Dialer, opts ...SMuxOption) (Tunnel, error) {
+	conn, err := DialWebSocket(ctx, rawURL, tlsConf, dialer)
+	if err != nil {
+		return nil, err
+	}
+	t, err := NewSMuxTunnel(conn, true, opts...)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// WebSocketConnectListener 连接 WebSocket 地址并在连接上创建 smux 服务端会话, agent 侧使用
+//
+// Deprecated: 使用 NewSession(DialWebSocketTunnel(ctx, rawURL, tlsConf, dialer, opts...))
+func WebSocketConnectListener(ctx context.Context, rawURL string, tlsConf *tls.Config, dialer Dialer, opts ...SMuxOption) (Session, error) {
+	t, err := DialWebSocketTunnel(ctx, rawURL, tlsConf, dialer, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewSession(t), nil
+}
+
+// WebSocketConnectDialer 在接收的 WebSocket 连接上创建 smux 客户端会话, 控制端侧使用
+//
+// Deprecated: 使用 NewSession(NewSMuxTunnel(conn, false, opts...))
+func WebSocketConnectDialer(conn net.Conn, opts ...SMuxOption) (Session, error) {
+	return SMUXConnectDialer(conn, opts...)
+}
+
+// WebSocketListener 作为 http.Handler 接收 WebSocket 连接, 并以 net.Listener 的方式返回
+type WebSocketListener struct {
+	addr   net.Addr
+	conns  chan net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+// NewWebSocketListener 创建 WebSocketListener, addr 为 Addr 的返回值
+func NewWebSocketListener(addr net.Addr) *WebSocketListener {
+	return &WebSocketListener{
+		addr:   addr,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+// ServeHTTP 升级为 WebSocket 连接, 直到连接关闭才返回
+func (l *WebSocketListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := websocket.Server{
+		// 不检查 Origin, 客户端不是浏览器
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			local, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+			conn := newWSConn(ws, local, tcpAddr(r.RemoteAddr), r.TLS)
+			select {
+			case l.conns <- conn:
+			case <-l.closed:
+				return
+			}
+			select {
+			case <-conn.closed:
+			case <-l.closed:
+			}
+		},
+	}
+	s.ServeHTTP(w, r)
+}
+
+// Accept implements net.Listener
+func (l *WebSocketListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close 停止接收新的连接, 并关闭所有连接
+func (l *WebSocketListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+// Addr implements net.Listener
+func (l *WebSocketListener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,130 @@
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/lyp256/tianmen/pkg/rpc/mux/testdata"
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+// startWebSocketServer 启动接收 WebSocket 连接的 HTTPS 服务
+func startWebSocketServer(t *testing.T) (*WebSocketListener, string) {
+	sConf, _ := testutil.GetTLCConfig()
+	sConf.NextProtos = nil
+	l := NewWebSocketListener(nil)
+	s := httptest.NewUnstartedServer(l)
+	s.TLS = sConf
+	s.StartTLS()
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { _ = l.Close() })
+	return l, "wss://" + s.Listener.Addr().String() + "/tianmen"
+}
+
+// startConnectProxy 启动 HTTP CONNECT 代理, 要求 Basic 认证
+func startConnectProxy(t *testing.T, user, password string) (*url.URL, *atomic.Int32) {
+	var count atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := parseProxyAuth(r.Header.Get("Proxy-Authorization"))
+		if r.Method != http.MethodConnect || !ok || u != user || p != password {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		count.Add(1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			_ = target.Close()
+			return
+		}
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go func() {
+			_, _ = io.Copy(target, conn)
+			_ = target.Close()
+		}()
+		_, _ = io.Copy(conn, target)
+		_ = conn.Close()
+	}))
+	t.Cleanup(s.Close)
+	proxy, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	proxy.User = url.UserPassword(user, password)
+	return proxy, &count
+}
+
+func parseProxyAuth(auth string) (string, string, bool) {
+	r := &http.Request{Header: http.Header{"Authorization": {auth}}}
+	return r.BasicAuth()
+}
+
+func wsEcho(t *testing.T, l *WebSocketListener, addr string, cConf *tls.Config, dialer Dialer) {
+	go func() {
+		tunnel, err := DialWebSocketTunnel(ctx, addr, cConf, dialer)
+		if err != nil {
+			return
+		}
+		gs := grpc.NewServer()
+		testdata.RegisterFooServer(gs, &testServer{})
+		_ = gs.Serve(NewSession(tunnel))
+	}()
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	// 控制端可以从 WebSocket 连接获取客户端证书
+	state := conn.(interface{ ConnectionState() tls.ConnectionState }).ConnectionState()
+	require.Len(t, state.PeerCertificates, 1)
+
+	tunnel, err := NewSMuxTunnel(conn, false)
+	require.NoError(t, err)
+	defer tunnel.Close()
+	cliConn, err := NewClientConn(NewSession(tunnel), InsecureClient())
+	require.NoError(t, err)
+	defer cliConn.Close()
+	data := strings.Repeat("a", 64<<10)
+	res, err := testdata.NewFooClient(cliConn).Bar(context.Background(), &testdata.Msg{Data: data})
+	require.NoError(t, err)
+	require.Equal(t, data+"bar", res.Data)
+}
+
+func TestWebSocket(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr := startWebSocketServer(t)
+	wsEcho(t, l, addr, cConf, nil)
+}
+
+func TestWebSocketProxy(t *testing.T) {
+	_, cConf := testutil.GetTLCConfig()
+	l, addr := startWebSocketServer(t)
+	proxy, count := startConnectProxy(t, "user", "secret")
+	dialer, err := ProxyDialer(nil, proxy)
+	require.NoError(t, err)
+	wsEcho(t, l, addr, cConf, dialer)
+	require.EqualValues(t, 1, count.Load())
+
+	proxy.User = url.UserPassword("user", "wrong")
+	dialer, err = ProxyDialer(nil, proxy)
+	require.NoError(t, err)
+	_, err = DialWebSocket(ctx, addr, cConf, dialer)
+	require.ErrorContains(t, err, "407")
+}
+
+func TestWebSocketListenerClose(t *testing.T) {
+	l := NewWebSocketListener(nil)
+	require.NoError(t, l.Close())
+	_, err := l.Accept()
+	require.ErrorIs(t, err, net.ErrClosed)
+}
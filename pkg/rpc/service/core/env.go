@@ -0,0 +1,131 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+const (
+	termEnv     = "TERM"
+	defaultTerm = "xterm-256color"
+)
+
+// environ 根据 Cmd.EnvMode 计算子进程的环境变量
+func (s Server) environ(c *core.Cmd) ([]string, error) {
+	// exec.Cmd.Env 为 nil 时会继承当前进程的环境变量
+	envs := []string{}
+	if c.GetEnvMode() != core.EnvMode_ENV_MODE_CLEAR {
+		envs = os.Environ()
+	}
+	for _, e := range c.GetEnvs() {
+		name := e.GetName()
+		if name == "" || strings.ContainsAny(name, "=\x00") || strings.ContainsRune(e.GetValue(), 0) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid environment variable: %q", name)
+		}
+		// INHERIT 模式下仅保留客户端的 TERM
+		if c.GetEnvMode() == core.EnvMode_ENV_MODE_INHERIT && name != termEnv {
+			continue
+		}
+		if !s.envAllowed(name) {
+			return nil, status.Errorf(codes.PermissionDenied, "environment variable not allowed: %s", name)
+		}
+		envs = setEnv(envs, name, e.GetValue())
+	}
+	if !c.GetDisablePty() && lookupEnv(envs, termEnv) == "" {
+		term := s.DefaultTerm
+		if term == "" {
+			term = defaultTerm
+		}
+		envs = setEnv(envs, termEnv, term)
+	}
+	return envs, nil
+}
+
+// envAllowed 检查客户端是否可以设置环境变量 name, EnvDeny 优先于 EnvAllow
+func (s Server) envAllowed(name string) bool {
+	if matchEnv(s.EnvDeny, name) {
+		return false
+	}
+	if name == termEnv {
+		return true
+	}
+	return len(s.EnvAllow) == 0 || matchEnv(s.EnvAllow, name)
+}
+
+// matchEnv 匹配环境变量名, 以 * 结尾的模式按前缀匹配
+func matchEnv(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func setEnv(envs []string, name, value string) []string {
+	kv := name + "=" + value
+	for i, e := range envs {
+		if strings.HasPrefix(e, name+"=") {
+			envs[i] = kv
+			return envs
+		}
+	}
+	return append(envs, kv)
+}
+
+func lookupEnv(envs []string, name string) string {
+	for _, e := range envs {
+		if v, ok := strings.CutPrefix(e, name+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// workDir 计算并校验子进程的工作目录, 相对路径基于 DefaultDir
+func (s Server) workDir(c *core.Cmd) (string, error) {
+	dir := c.GetDir()
+	if dir == "" {
+		dir = s.DefaultDir
+	}
+	if dir == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(dir) {
+		if !filepath.IsAbs(s.DefaultDir) {
+			return "", status.Errorf(codes.InvalidArgument, "working directory must be absolute: %s", dir)
+		}
+		dir = filepath.Join(s.DefaultDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	// chroot 后的目录需要在 chroot 内检查
+	info, err := os.Stat(filepath.Join(c.GetLinux().GetChroot(), dir))
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "working directory: %v", err)
+	}
+	if !info.IsDir() {
+		return "", status.Errorf(codes.InvalidArgument, "working directory is not a directory: %s", dir)
+	}
+	return dir, nil
+}
+
+// LocalEnvs 读取本地已设置的环境变量, 用于传递给远端, 如 TERM
+func LocalEnvs(names ...string) []*core.Env {
+	var envs []*core.Env
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			envs = append(envs, &core.Env{Name: name, Value: v})
+		}
+	}
+	return envs
+}
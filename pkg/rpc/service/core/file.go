@@ -0,0 +1,461 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+const (
+	// fileChunkSize 传输文件时单条 FileMsg 携带的数据量
+	fileChunkSize = 32 << 10
+	// partSuffix 传输中的临时文件后缀, 完成后重命名为目标路径
+	partSuffix = ".part"
+)
+
+// FileServer 实现 core.FileTransfer, 在 agent 上读写 Allow 匹配的文件
+type FileServer struct {
+	core.UnimplementedFileTransferServer
+	// Allow 允许读写的路径, 支持 filepath.Match 通配, 匹配目录时包括目录下的所有文件,
+	// 如 /etc/app、/tmp/*.log. 只接受绝对路径, 为空时拒绝所有路径.
+	// 路径中的符号链接解析后也需匹配
+	Allow []string
+}
+
+// Upload 接收客户端上传的文件, 数据写入 <Path>.part, 校验后设置属性并重命名为 Path.
+// Resume 时保留已存在的 <Path>.part, 客户端从其末尾继续发送
+func (s *FileServer) Upload(stream grpc.BidiStreamingServer[core.FileMsg, core.FileMsg]) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if msg.GetType() != core.FileMsgType_FILE_MSG_TYPE_UPLOAD {
+		return status.Errorf(codes.InvalidArgument, "unexpected message type: %v", msg.GetType())
+	}
+	upload := msg.GetUpload()
+	info := upload.GetInfo()
+	path := info.GetPath()
+	if info.GetSize() < 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid size %d", info.GetSize())
+	}
+	real, err := resolveFilePath(s.Allow, path)
+	if err != nil {
+		return err
+	}
+	part := real + partSuffix
+	f, h, offset, err := openPart(part, upload.GetResume(), info.GetSize())
+	if err != nil {
+		return fileError(err)
+	}
+	defer f.Close()
+	err = stream.Send(&core.FileMsg{
+		Type: core.FileMsgType_FILE_MSG_TYPE_OFFSET,
+		Data: &core.FileMsg_Offset{Offset: &core.FileOffset{Offset: offset}},
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 中断时保留已接收的数据
+			return err
+		}
+		data := msg.GetPayload()
+		if offset+int64(len(data)) > info.GetSize() {
+			_ = os.Remove(part)
+			return status.Errorf(codes.InvalidArgument, "received more than %d bytes", info.GetSize())
+		}
+		_, err = f.Write(data)
+		if err != nil {
+			return fileError(err)
+		}
+		_, _ = h.Write(data)
+		offset += int64(len(data))
+	}
+	if offset != info.GetSize() {
+		_ = os.Remove(part)
+		return status.Errorf(codes.InvalidArgument, "received %d of %d bytes", offset, info.GetSize())
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if sum != info.GetSha256() {
+		_ = os.Remove(part)
+		return status.Errorf(codes.DataLoss, "sha256 mismatch: got %s, want %s", sum, info.GetSha256())
+	}
+	err = finishPart(f, part, real, info, upload.GetChown())
+	if err != nil {
+		return fileError(err)
+	}
+	fi, err := os.Lstat(real)
+	if err != nil {
+		return fileError(err)
+	}
+	return stream.Send(&core.FileMsg{
+		Type: core.FileMsgType_FILE_MSG_TYPE_INFO,
+		Data: &core.FileMsg_Info{Info: fileInfo(path, fi, sum)},
+	})
+}
+
+// Download 发送文件属性和从 Offset 开始的内容, 属性中包含整个文件的 SHA-256
+func (s *FileServer) Download(req *core.FileDownload, stream grpc.ServerStreamingServer[core.FileMsg]) error {
+	path := req.GetPath()
+	real, err := resolveFilePath(s.Allow, path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(real, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return fileError(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fileError(err)
+	}
+	if !fi.Mode().IsRegular() {
+		return status.Errorf(codes.InvalidArgument, "%s is not a regular file", path)
+	}
+	if req.GetOffset() < 0 || req.GetOffset() > fi.Size() {
+		return status.Errorf(codes.OutOfRange, "offset %d out of range", req.GetOffset())
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return fileError(err)
+	}
+	err = stream.Send(&core.FileMsg{
+		Type: core.FileMsgType_FILE_MSG_TYPE_INFO,
+		Data: &core.FileMsg_Info{Info: fileInfo(path, fi, hex.EncodeToString(h.Sum(nil)))},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Seek(req.GetOffset(), io.SeekStart)
+	if err != nil {
+		return fileError(err)
+	}
+	return sendFileData(stream.Send, f)
+}
+
+// sendFileData 将 r 的内容分块发送, 直到 EOF
+func sendFileData(send func(*core.FileMsg) error, r io.Reader) error {
+	buf := make([]byte, fileChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			sendErr := send(&core.FileMsg{
+				Type: core.FileMsgType_FILE_MSG_TYPE_DATA,
+				Data: &core.FileMsg_Payload{Payload: buf[:n]},
+			})
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fileError(err)
+		}
+	}
+}
+
+// matchFilePath 检查路径或其所在的任意一级目录是否匹配 patterns, 只接受已清理的绝对路径
+func matchFilePath(patterns []string, path string) bool {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return false
+	}
+	for p := path; ; p = filepath.Dir(p) {
+		if matchPath(patterns, p) {
+			return true
+		}
+		if filepath.Dir(p) == p {
+			return false
+		}
+	}
+}
+
+// resolveFilePath 检查 path 并解析其中的符号链接, 解析后的路径同样需要匹配 patterns,
+// 防止通过允许目录中的符号链接读写其他路径
+func resolveFilePath(patterns []string, path string) (string, error) {
+	if !matchFilePath(patterns, path) {
+		return "", status.Errorf(codes.PermissionDenied, "path not allowed: %s", path)
+	}
+	real, err := evalSymlinks(path)
+	if err != nil {
+		return "", fileError(err)
+	}
+	if !matchFilePath(patterns, real) {
+		return "", status.Errorf(codes.PermissionDenied, "path not allowed: %s -> %s", path, real)
+	}
+	return real, nil
+}
+
+// evalSymlinks 解析 path 中已存在的部分的符号链接, 不存在的部分原样保留.
+// 指向不存在路径的符号链接返回错误
+func evalSymlinks(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if !errors.Is(err, fs.ErrNotExist) || filepath.Dir(path) == path {
+		return real, err
+	}
+	if _, lerr := os.Lstat(path); !errors.Is(lerr, fs.ErrNotExist) {
+		return "", err
+	}
+	dir, err := evalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(path)), nil
+}
+
+// fileError 将文件操作的错误转换为 gRPC 状态
+func fileError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, syscall.ELOOP):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// fileInfo 返回 path 的属性, sum 为文件内容的 SHA-256
+func fileInfo(path string, fi os.FileInfo, sum string) *core.FileInfo {
+	info := &core.FileInfo{
+		Path:   path,
+		Size:   fi.Size(),
+		Mode:   uint32(fi.Mode().Perm()),
+		Mtime:  fi.ModTime().UnixNano(),
+		Sha256: sum,
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		info.Uid = st.Uid
+		info.Gid = st.Gid
+	}
+	return info
+}
+
+// openPart 打开临时文件, resume 时返回已有数据的长度和哈希, 否则清空文件.
+// 已有数据超过 size 时说明源文件已变化, 从头开始传输. 不跟随符号链接
+func openPart(part string, resume bool, size int64) (*os.File, hash.Hash, int64, error) {
+	flag := os.O_RDWR | os.O_CREATE | syscall.O_NOFOLLOW
+	if !resume {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(part, flag, 0o600)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	h := sha256.New()
+	offset, err := io.Copy(h, f)
+	if err == nil && offset > size {
+		h.Reset()
+		offset = 0
+		err = f.Truncate(0)
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, 0, err
+	}
+	return f, h, offset, nil
+}
+
+// finishPart 设置临时文件的权限、属主和修改时间, 关闭后重命名为 path
+func finishPart(f *os.File, part, path string, info *core.FileInfo, chown bool) error {
+	err := f.Chmod(os.FileMode(info.GetMode()).Perm())
+	if err != nil {
+		return err
+	}
+	if chown {
+		err = f.Chown(int(info.GetUid()), int(info.GetGid()))
+		if err != nil {
+			return err
+		}
+	}
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	if info.GetMtime() != 0 {
+		mtime := time.Unix(0, info.GetMtime())
+		err = os.Chtimes(part, mtime, mtime)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Rename(part, path)
+}
+
+// FileOptions UploadFile 和 DownloadFile 的选项
+type FileOptions struct {
+	// Resume 保留上次中断时已传输的数据, 从其末尾继续传输
+	Resume bool
+	// Owner 保留文件属主, 通常需要 root 权限
+	Owner bool
+}
+
+// UploadFile 将本地文件 src 上传到 agent 上的 dst, 保留权限和修改时间, 返回上传后 dst 的属性
+func UploadFile(ctx context.Context, cli core.FileTransferClient, src, dst string, opt FileOptions) (*core.FileInfo, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", src)
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := cli.Upload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&core.FileMsg{
+		Type: core.FileMsgType_FILE_MSG_TYPE_UPLOAD,
+		Data: &core.FileMsg_Upload{Upload: &core.FileUpload{
+			Info:   fileInfo(dst, fi, hex.EncodeToString(h.Sum(nil))),
+			Resume: opt.Resume,
+			Chown:  opt.Owner,
+		}},
+	})
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	msg, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if msg.GetType() != core.FileMsgType_FILE_MSG_TYPE_OFFSET {
+		return nil, status.Errorf(codes.Internal, "unexpected message type: %v", msg.GetType())
+	}
+	offset := msg.GetOffset().GetOffset()
+	if offset < 0 || offset > fi.Size() {
+		return nil, fmt.Errorf("offset %d out of range", offset)
+	}
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	err = sendFileData(stream.Send, io.LimitReader(f, fi.Size()-offset))
+	// io.EOF 表示 agent 已结束 stream, 错误由 Recv 返回
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	err = stream.CloseSend()
+	if err != nil {
+		return nil, err
+	}
+	msg, err = stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if msg.GetType() != core.FileMsgType_FILE_MSG_TYPE_INFO {
+		return nil, status.Errorf(codes.Internal, "unexpected message type: %v", msg.GetType())
+	}
+	return msg.GetInfo(), nil
+}
+
+// DownloadFile 将 agent 上的 src 下载到本地 dst, 数据先写入 <dst>.part, 校验 SHA-256 后
+// 设置权限和修改时间并重命名为 dst, 返回 src 的属性. Resume 时 <dst>.part 比 src 长则重新下载
+func DownloadFile(ctx context.Context, cli core.FileTransferClient, src, dst string, opt FileOptions) (*core.FileInfo, error) {
+	part := dst + partSuffix
+	f, h, offset, err := openPart(part, opt.Resume, math.MaxInt64)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	download := func(offset int64) (grpc.ServerStreamingClient[core.FileMsg], *core.FileMsg, error) {
+		stream, err := cli.Download(ctx, &core.FileDownload{Path: src, Offset: offset})
+		if err != nil {
+			return nil, nil, err
+		}
+		msg, err := stream.Recv()
+		return stream, msg, err
+	}
+	stream, msg, err := download(offset)
+	if status.Code(err) == codes.OutOfRange && offset > 0 {
+		// src 已变为更小的文件, 丢弃已下载的部分
+		err = f.Truncate(0)
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		h.Reset()
+		offset = 0
+		stream, msg, err = download(0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if msg.GetType() != core.FileMsgType_FILE_MSG_TYPE_INFO {
+		return nil, status.Errorf(codes.Internal, "unexpected message type: %v", msg.GetType())
+	}
+	info := msg.GetInfo()
+	for {
+		msg, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Write(msg.GetPayload())
+		if err != nil {
+			return nil, err
+		}
+		_, _ = h.Write(msg.GetPayload())
+		offset += int64(len(msg.GetPayload()))
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if offset != info.GetSize() || sum != info.GetSha256() {
+		_ = os.Remove(part)
+		return nil, fmt.Errorf("%s: sha256 mismatch: got %s, want %s", src, sum, info.GetSha256())
+	}
+	err = finishPart(f, part, dst, info, opt.Owner)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
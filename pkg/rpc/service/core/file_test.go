@@ -0,0 +1,200 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+func newFileClient(t *testing.T, srv *FileServer) core.FileTransferClient {
+	cc := newLoopbackConn(t, func(gs *grpc.Server) {
+		core.RegisterFileTransferServer(gs, srv)
+	})
+	return core.NewFileTransferClient(cc)
+}
+
+// writeTestFile 写入 size 字节的文件, 设置权限和修改时间
+func writeTestFile(t *testing.T, path string, size int, mode os.FileMode, mtime time.Time) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	require.NoError(t, os.WriteFile(path, data, mode))
+	require.NoError(t, os.Chmod(path, mode))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	return data
+}
+
+func requireFile(t *testing.T, path string, data []byte, mode os.FileMode, mtime time.Time) {
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(data, got))
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, mode, fi.Mode().Perm())
+	require.True(t, mtime.Equal(fi.ModTime()), "mtime %v, want %v", fi.ModTime(), mtime)
+}
+
+func TestFileTransfer(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	cli := newFileClient(t, &FileServer{Allow: []string{remote}})
+	mtime := time.Unix(1700000000, 123456789)
+	src := filepath.Join(local, "src")
+	data := writeTestFile(t, src, 100<<10+1, 0o640, mtime)
+
+	dst := filepath.Join(remote, "dst")
+	info, err := UploadFile(ctx, cli, src, dst, FileOptions{Owner: true})
+	require.NoError(t, err)
+	require.Equal(t, dst, info.GetPath())
+	require.EqualValues(t, len(data), info.GetSize())
+	requireFile(t, dst, data, 0o640, mtime)
+	_, err = os.Stat(dst + partSuffix)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	back := filepath.Join(local, "back")
+	downloaded, err := DownloadFile(ctx, cli, dst, back, FileOptions{})
+	require.NoError(t, err)
+	require.Equal(t, info.GetSha256(), downloaded.GetSha256())
+	requireFile(t, back, data, 0o640, mtime)
+
+	// 空文件
+	empty := filepath.Join(local, "empty")
+	writeTestFile(t, empty, 0, 0o600, mtime)
+	_, err = UploadFile(ctx, cli, empty, filepath.Join(remote, "empty"), FileOptions{})
+	require.NoError(t, err)
+	_, err = DownloadFile(ctx, cli, filepath.Join(remote, "empty"), filepath.Join(local, "empty2"), FileOptions{})
+	require.NoError(t, err)
+	requireFile(t, filepath.Join(local, "empty2"), []byte{}, 0o600, mtime)
+
+	_, err = DownloadFile(ctx, cli, filepath.Join(remote, "missing"), filepath.Join(local, "missing"), FileOptions{})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = DownloadFile(ctx, cli, remote, filepath.Join(local, "dir"), FileOptions{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFileTransferResume(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	cli := newFileClient(t, &FileServer{Allow: []string{remote}})
+	mtime := time.Unix(1700000000, 0)
+	src := filepath.Join(local, "src")
+	data := writeTestFile(t, src, 200<<10, 0o600, mtime)
+
+	// 上次中断时已上传一半
+	dst := filepath.Join(remote, "dst")
+	require.NoError(t, os.WriteFile(dst+partSuffix, data[:100<<10], 0o600))
+	_, err := UploadFile(ctx, cli, src, dst, FileOptions{Resume: true})
+	require.NoError(t, err)
+	requireFile(t, dst, data, 0o600, mtime)
+
+	// 已接收的数据与源文件不一致时校验失败, 删除临时文件
+	require.NoError(t, os.WriteFile(dst+partSuffix, make([]byte, 100<<10), 0o600))
+	_, err = UploadFile(ctx, cli, src, dst, FileOptions{Resume: true})
+	require.Equal(t, codes.DataLoss, status.Code(err))
+	_, err = os.Stat(dst + partSuffix)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// 不续传时忽略已有的临时文件
+	require.NoError(t, os.WriteFile(dst+partSuffix, make([]byte, 100<<10), 0o600))
+	_, err = UploadFile(ctx, cli, src, dst, FileOptions{})
+	require.NoError(t, err)
+	requireFile(t, dst, data, 0o600, mtime)
+
+	back := filepath.Join(local, "back")
+	require.NoError(t, os.WriteFile(back+partSuffix, data[:50<<10], 0o600))
+	_, err = DownloadFile(ctx, cli, dst, back, FileOptions{Resume: true})
+	require.NoError(t, err)
+	requireFile(t, back, data, 0o600, mtime)
+
+	// 已下载的部分比源文件长时重新下载
+	require.NoError(t, os.WriteFile(back+partSuffix, make([]byte, 300<<10), 0o600))
+	_, err = DownloadFile(ctx, cli, dst, back, FileOptions{Resume: true})
+	require.NoError(t, err)
+	requireFile(t, back, data, 0o600, mtime)
+
+	require.NoError(t, os.WriteFile(back+partSuffix, make([]byte, 50<<10), 0o600))
+	_, err = DownloadFile(ctx, cli, dst, back, FileOptions{Resume: true})
+	require.ErrorContains(t, err, "sha256 mismatch")
+	_, err = os.Stat(back + partSuffix)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFileTransferDenied(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	cli := newFileClient(t, &FileServer{Allow: []string{filepath.Join(remote, "*.txt")}})
+	src := filepath.Join(local, "src")
+	writeTestFile(t, src, 10, 0o600, time.Now())
+
+	_, err := UploadFile(ctx, cli, src, filepath.Join(remote, "a.txt"), FileOptions{})
+	require.NoError(t, err)
+	for _, dst := range []string{
+		filepath.Join(remote, "a.bin"),
+		"a.txt",
+		remote + "/sub/../a.txt",
+	} {
+		_, err = UploadFile(ctx, cli, src, dst, FileOptions{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err), dst)
+		_, err = DownloadFile(ctx, cli, dst, filepath.Join(local, "dst"), FileOptions{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err), dst)
+	}
+}
+
+func TestFileTransferSymlink(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	allowed, outside := filepath.Join(remote, "app"), filepath.Join(remote, "outside")
+	require.NoError(t, os.MkdirAll(allowed, 0o755))
+	require.NoError(t, os.MkdirAll(outside, 0o755))
+	cli := newFileClient(t, &FileServer{Allow: []string{allowed}})
+	src := filepath.Join(local, "src")
+	data := writeTestFile(t, src, 10, 0o600, time.Now())
+	writeTestFile(t, filepath.Join(outside, "secret"), 10, 0o600, time.Now())
+	require.NoError(t, os.Symlink(outside, filepath.Join(allowed, "dir")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(allowed, "secret")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "part"), filepath.Join(allowed, "a"+partSuffix)))
+
+	// 符号链接指向允许的路径之外
+	for _, p := range []string{
+		filepath.Join(allowed, "dir", "secret"),
+		filepath.Join(allowed, "secret"),
+	} {
+		_, err := UploadFile(ctx, cli, src, p, FileOptions{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err), p)
+		_, err = DownloadFile(ctx, cli, p, filepath.Join(local, "dst"), FileOptions{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err), p)
+	}
+	// 临时文件是符号链接
+	_, err := UploadFile(ctx, cli, src, filepath.Join(allowed, "a"), FileOptions{Resume: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.NoFileExists(t, filepath.Join(outside, "part"))
+	require.NoFileExists(t, filepath.Join(outside, "a"))
+
+	// 指向允许的路径内的符号链接
+	_, err = UploadFile(ctx, cli, src, filepath.Join(allowed, "b"), FileOptions{})
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink("b", filepath.Join(allowed, "c")))
+	_, err = DownloadFile(ctx, cli, filepath.Join(allowed, "c"), filepath.Join(local, "dst"), FileOptions{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(filepath.Join(local, "dst"))
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestMatchFilePath(t *testing.T) {
+	allow := []string{"/etc/app", "/tmp/*.log"}
+	require.True(t, matchFilePath(allow, "/etc/app"))
+	require.True(t, matchFilePath(allow, "/etc/app/conf.d/a.conf"))
+	require.True(t, matchFilePath(allow, "/tmp/a.log"))
+	require.False(t, matchFilePath(allow, "/etc/app2"))
+	require.False(t, matchFilePath(allow, "/etc/app/../passwd"))
+	require.False(t, matchFilePath(allow, "/tmp/a.txt"))
+	require.False(t, matchFilePath(nil, "/etc/app"))
+	require.True(t, matchFilePath([]string{"/"}, "/etc/passwd"))
+}
@@ -0,0 +1,404 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+const (
+	// defaultForwardDialTimeout 默认的 ForwardServer.DialTimeout
+	defaultForwardDialTimeout = 10 * time.Second
+	// forwardBufferSize 单条 ForwardMsg 携带的最大数据量, 不小于 UDP 数据报的最大长度
+	forwardBufferSize = 64 << 10
+)
+
+// ForwardServer 实现 core.Forward, 在 agent 上连接客户端请求的目标
+type ForwardServer struct {
+	core.UnimplementedForwardServer
+	// Allow 允许连接的目标, 格式为 host:port, 同时用于 tcp 和 udp, 为空时拒绝所有目标.
+	// host 可以是 *、*.example.com、IP 或 CIDR, port 可以是 *、端口号或 1000-2000 范围.
+	// 使用 CIDR 时只匹配以 IP 地址请求的目标
+	Allow []string
+	// DialTimeout 连接目标的超时时间, 默认 10s
+	DialTimeout time.Duration
+	// ListenAllow 允许 Listen 监听的地址, 格式与 Allow 相同, 为空时拒绝所有监听
+	ListenAllow []string
+	// AcceptTimeout Listen 接收的连接等待客户端 Accept 的最长时间, 默认 10s
+	AcceptTimeout time.Duration
+	// AllowUnix 允许连接的 unix socket 路径, 支持 filepath.Match 通配, 如 /var/run/docker.sock、/run/postgresql/*.
+	// 只接受绝对路径, 为空时拒绝所有 unix socket
+	AllowUnix []string
+	// ListenAllowUnix 允许 Listen 监听的 unix socket 路径, 格式与 AllowUnix 相同
+	ListenAllowUnix []string
+
+	mu      sync.Mutex
+	pending map[string]*pendingConn
+}
+
+// Forward 连接第一条消息中的目标, 并在 stream 和目标连接之间转发数据
+func (s *ForwardServer) Forward(stream grpc.BidiStreamingServer[core.ForwardMsg, core.ForwardMsg]) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if msg.GetType() != core.ForwardMsgType_FORWARD_MSG_TYPE_DIAL {
+		return status.Errorf(codes.InvalidArgument, "unexpected message type: %v", msg.GetType())
+	}
+	network := msg.GetDial().GetNetwork()
+	if network == "" {
+		network = "tcp"
+	}
+	addr := msg.GetDial().GetAddress()
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		if !matchTarget(s.Allow, addr) {
+			return status.Errorf(codes.PermissionDenied, "target not allowed: %s", addr)
+		}
+	case "unix":
+		if !matchPath(s.AllowUnix, addr) {
+			return status.Errorf(codes.PermissionDenied, "unix socket not allowed: %s", addr)
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported network: %s", network)
+	}
+	timeout := s.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultForwardDialTimeout
+	}
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(stream.Context(), network, addr)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer conn.Close()
+	err = stream.Send(connectedMsg(conn))
+	if err != nil {
+		return err
+	}
+	return serveForwardConn(stream, conn)
+}
+
+func connectedMsg(conn net.Conn) *core.ForwardMsg {
+	return &core.ForwardMsg{
+		Type: core.ForwardMsgType_FORWARD_MSG_TYPE_CONNECTED,
+		Data: &core.ForwardMsg_Connected{Connected: &core.ForwardConnected{
+			LocalAddr:  addrString(conn.LocalAddr()),
+			RemoteAddr: addrString(conn.RemoteAddr()),
+		}},
+	}
+}
+
+// serveForwardConn 在 stream 和 conn 之间转发数据, conn 读取结束时返回.
+// conn 为 UDP 连接时一条消息对应一个数据报
+func serveForwardConn(stream grpc.BidiStreamingServer[core.ForwardMsg, core.ForwardMsg], conn net.Conn) error {
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				// 客户端不再写入
+				mux.CloseWrite(conn)
+				return
+			}
+			_, err = conn.Write(msg.GetPayload())
+			if err != nil {
+				_ = conn.Close()
+				return
+			}
+		}
+	}()
+	buf := make([]byte, forwardBufferSize)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			sendErr := stream.Send(&core.ForwardMsg{
+				Type: core.ForwardMsgType_FORWARD_MSG_TYPE_DATA,
+				Data: &core.ForwardMsg_Payload{Payload: buf[:n]},
+			})
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF || errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+	}
+}
+
+// matchTarget 检查 addr 是否匹配 patterns 中的任意一个
+func matchTarget(patterns []string, addr string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	for _, p := range patterns {
+		pHost, pPort, err := net.SplitHostPort(p)
+		if err != nil {
+			continue
+		}
+		if matchHost(pHost, host) && matchPort(pPort, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 检查 unix socket 路径是否匹配 patterns, 只接受已清理的绝对路径
+func matchPath(patterns []string, path string) bool {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return false
+	}
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// addrString 未绑定的 unix socket 地址可能为 nil
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+func matchHost(pattern, host string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(suffix))
+	}
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+	if ip := net.ParseIP(pattern); ip != nil {
+		return ip.Equal(net.ParseIP(host))
+	}
+	return strings.EqualFold(pattern, host)
+}
+
+func matchPort(pattern, port string) bool {
+	if pattern == "*" {
+		return true
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return false
+	}
+	low, high, ok := strings.Cut(pattern, "-")
+	if !ok {
+		high = low
+	}
+	l, err1 := strconv.Atoi(low)
+	h, err2 := strconv.Atoi(high)
+	return err1 == nil && err2 == nil && l <= p && p <= h
+}
+
+// DialForward 通过 Forward 服务连接 agent 网络中的 addr, 返回的连接在 ctx 结束时关闭
+func DialForward(ctx context.Context, cli core.ForwardClient, network, addr string) (net.Conn, error) {
+	return openForward(ctx, cli.Forward, network, &core.ForwardMsg{
+		Type: core.ForwardMsgType_FORWARD_MSG_TYPE_DIAL,
+		Data: &core.ForwardMsg_Dial{Dial: &core.ForwardDial{Network: network, Address: addr}},
+	})
+}
+
+// ForwardDialer 经 agent 的 Forward 服务建立连接, 与 net.Dialer 相同, ctx 只用于建立连接.
+// 实现了 mux.ContextDialer
+type ForwardDialer struct {
+	Client core.ForwardClient
+	// Network tcp 或 udp, 为空时为 tcp
+	Network string
+}
+
+// DialContext 在 agent 上连接 addr
+func (d ForwardDialer) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	network := d.Network
+	if network == "" {
+		network = "tcp"
+	}
+	// streamCtx 只在建立连接前随 ctx 取消, 之后 stream 由 conn.Close 结束
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	conn, err := DialForward(streamCtx, d.Client, network, addr)
+	if !stop() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		cancel()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// openForward 调用 open 并发送 first, 等待 CONNECTED 后返回转发数据的连接
+func openForward(
+	ctx context.Context,
+	open func(context.Context, ...grpc.CallOption) (grpc.BidiStreamingClient[core.ForwardMsg, core.ForwardMsg], error),
+	network string,
+	first *core.ForwardMsg,
+) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := open(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	err = stream.Send(first)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	msg, err := stream.Recv()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if msg.GetType() != core.ForwardMsgType_FORWARD_MSG_TYPE_CONNECTED {
+		cancel()
+		return nil, fmt.Errorf("unexpected message type: %v", msg.GetType())
+	}
+	return &forwardConn{
+		stream: stream,
+		cancel: cancel,
+		local:  forwardAddr{network: network, addr: msg.GetConnected().GetLocalAddr()},
+		remote: forwardAddr{network: network, addr: msg.GetConnected().GetRemoteAddr()},
+	}, nil
+}
+
+// forwardAddr agent 上连接目标的地址
+type forwardAddr struct {
+	network, addr string
+}
+
+func (a forwardAddr) Network() string {
+	return a.network
+}
+
+func (a forwardAddr) String() string {
+	return a.addr
+}
+
+// forwardConn 将 Forward stream 适配为 net.Conn, 不支持超时
+type forwardConn struct {
+	stream        grpc.BidiStreamingClient[core.ForwardMsg, core.ForwardMsg]
+	cancel        context.CancelFunc
+	local, remote net.Addr
+	buf           []byte
+	writeMu       sync.Mutex
+}
+
+func (c *forwardConn) Read(b []byte) (int, error) {
+	for len(c.buf) == 0 {
+		msg, err := c.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		c.buf = msg.GetPayload()
+	}
+	n := copy(b, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func (c *forwardConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	written := 0
+	for len(b) > 0 {
+		n := min(len(b), forwardBufferSize)
+		err := c.stream.Send(&core.ForwardMsg{
+			Type: core.ForwardMsgType_FORWARD_MSG_TYPE_DATA,
+			Data: &core.ForwardMsg_Payload{Payload: b[:n]},
+		})
+		if err != nil {
+			return written, err
+		}
+		written += n
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// CloseWrite 通知 agent 不再写入
+func (c *forwardConn) CloseWrite() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.stream.CloseSend()
+}
+
+// Close 结束 stream
+func (c *forwardConn) Close() error {
+	c.cancel()
+	return nil
+}
+
+func (c *forwardConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *forwardConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *forwardConn) SetDeadline(time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (c *forwardConn) SetReadDeadline(time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (c *forwardConn) SetWriteDeadline(time.Time) error {
+	return os.ErrNoDeadline
+}
+
+// ServeForward 接收 l 上的连接, 通过 dial 建立的连接转发, 直到 l 关闭.
+// dial 失败时关闭接收的连接, 并将错误传给 errLog
+func ServeForward(l net.Listener, dial func(ctx context.Context) (net.Conn, error), errLog func(error)) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			remote, err := dial(context.Background())
+			if err != nil {
+				_ = conn.Close()
+				if errLog != nil {
+					errLog(err)
+				}
+				return
+			}
+			mux.Pipe(conn, remote)
+		}()
+	}
+}
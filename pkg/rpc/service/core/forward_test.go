@@ -0,0 +1,172 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+// newLoopbackConn 在 smux 回环连接上启动注册了服务的 grpc.Server, 返回客户端连接
+func newLoopbackConn(t *testing.T, register func(gs *grpc.Server)) *grpc.ClientConn {
+	c1, c2 := net.Pipe()
+	server, err := mux.NewSMuxTunnel(c1, true)
+	require.NoError(t, err)
+	client, err := mux.NewSMuxTunnel(c2, false)
+	require.NoError(t, err)
+	gs := grpc.NewServer()
+	register(gs)
+	go func() { _ = gs.Serve(mux.NewSession(server)) }()
+	t.Cleanup(gs.Stop)
+	cc, err := mux.NewClientConn(mux.NewSession(client), mux.InsecureClient())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+	return cc
+}
+
+func newForwardClient(t *testing.T, srv *ForwardServer) core.ForwardClient {
+	cc := newLoopbackConn(t, func(gs *grpc.Server) {
+		core.RegisterForwardServer(gs, srv)
+	})
+	return core.NewForwardClient(cc)
+}
+
+func TestForward(t *testing.T) {
+	echo := testutil.StartTCPEcho(t)
+	cli := newForwardClient(t, &ForwardServer{Allow: []string{"127.0.0.1:*"}})
+
+	conn, err := DialForward(ctx, cli, "tcp", echo)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, echo, conn.RemoteAddr().String())
+	data := make([]byte, 100<<10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	go func() {
+		_, _ = conn.Write(data)
+		_ = conn.(interface{ CloseWrite() error }).CloseWrite()
+	}()
+	// agent 关闭目标连接的写方向后, echo 服务关闭连接, 读取结束
+	got, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestForwardDenied(t *testing.T) {
+	echo := testutil.StartTCPEcho(t)
+	cli := newForwardClient(t, &ForwardServer{Allow: []string{"db.internal:5432"}})
+	_, err := DialForward(ctx, cli, "tcp", echo)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	cli = newForwardClient(t, &ForwardServer{})
+	_, err = DialForward(ctx, cli, "tcp", echo)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	cli = newForwardClient(t, &ForwardServer{Allow: []string{"*:*"}})
+	_, err = DialForward(ctx, cli, "ip", echo)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestForwardUDP(t *testing.T) {
+	echo := testutil.StartUDPEcho(t)
+	cli := newForwardClient(t, &ForwardServer{Allow: []string{"127.0.0.1:*"}})
+	conn, err := DialForward(ctx, cli, "udp", echo)
+	require.NoError(t, err)
+	defer conn.Close()
+	// 每条消息是一个数据报
+	for _, msg := range []string{"a", "bb", strings.Repeat("c", 40<<10)} {
+		_, err = conn.Write([]byte(msg))
+		require.NoError(t, err)
+		buf := make([]byte, 64<<10)
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, msg, string(buf[:n]))
+	}
+}
+
+func TestMatchTarget(t *testing.T) {
+	allow := []string{"db.internal:5432", "*.svc.local:*", "10.0.0.0/8:8000-8100", "[::1]:22"}
+	for addr, ok := range map[string]bool{
+		"db.internal:5432":      true,
+		"DB.Internal:5432":      true,
+		"db.internal:5433":      false,
+		"api.svc.local:443":     true,
+		"svc.local:443":         false,
+		"10.1.2.3:8080":         true,
+		"10.1.2.3:8101":         false,
+		"11.1.2.3:8080":         false,
+		"[::1]:22":              true,
+		"[0:0::1]:22":           true,
+		"db.internal":           false,
+		"evil.db.internal:5432": false,
+	} {
+		require.Equal(t, ok, matchTarget(allow, addr), addr)
+	}
+	require.True(t, matchTarget([]string{"*:*"}, "example.com:1"))
+}
+
+func TestServeForward(t *testing.T) {
+	echo := testutil.StartTCPEcho(t)
+	cli := newForwardClient(t, &ForwardServer{Allow: []string{"127.0.0.1:*"}})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = ServeForward(l, func(ctx context.Context) (net.Conn, error) {
+			return DialForward(ctx, cli, "tcp", echo)
+		}, nil)
+	}()
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+		got, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(got))
+		_ = conn.Close()
+	}
+}
+
+func TestForwardUnix(t *testing.T) {
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "echo.sock")
+	testutil.StartUnixEcho(t, sock)
+	cli := newForwardClient(t, &ForwardServer{
+		Allow:     []string{"*:*"},
+		AllowUnix: []string{filepath.Join(dir, "*.sock")},
+	})
+	conn, err := DialForward(ctx, cli, "unix", sock)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(interface{ CloseWrite() error }).CloseWrite())
+	got, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(got))
+
+	// 不在允许列表中或不是已清理的绝对路径
+	for _, path := range []string{
+		filepath.Join(dir, "echo.socket"),
+		dir + "/sub/../echo.sock",
+		"echo.sock",
+	} {
+		_, err = DialForward(ctx, cli, "unix", path)
+		require.Equal(t, codes.PermissionDenied, status.Code(err), path)
+	}
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+// defaultAcceptTimeout 默认的 ForwardServer.AcceptTimeout
+const defaultAcceptTimeout = 10 * time.Second
+
+// pendingConn Listen 接收的等待客户端 Accept 的连接
+type pendingConn struct {
+	conn net.Conn
+	// ctx Listen stream 的 context, 结束时关闭连接
+	ctx   context.Context
+	taken chan struct{}
+}
+
+// Listen 在 agent 上监听, 将接收的连接通知客户端, stream 结束时关闭监听和所有接收的连接
+func (s *ForwardServer) Listen(req *core.ForwardListen, stream grpc.ServerStreamingServer[core.ForwardMsg]) error {
+	network := req.GetNetwork()
+	if network == "" {
+		network = "tcp"
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		if !matchTarget(s.ListenAllow, req.GetAddress()) {
+			return status.Errorf(codes.PermissionDenied, "listen address not allowed: %s", req.GetAddress())
+		}
+	case "unix":
+		if !matchPath(s.ListenAllowUnix, req.GetAddress()) {
+			return status.Errorf(codes.PermissionDenied, "unix socket not allowed: %s", req.GetAddress())
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported network: %s", network)
+	}
+	l, err := net.Listen(network, req.GetAddress())
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer l.Close()
+	ctx := stream.Context()
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	err = stream.Send(&core.ForwardMsg{
+		Type: core.ForwardMsgType_FORWARD_MSG_TYPE_LISTENING,
+		Data: &core.ForwardMsg_Listening{Listening: &core.ForwardListening{Address: l.Addr().String()}},
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		id := s.addPending(ctx, conn)
+		err = stream.Send(&core.ForwardMsg{
+			Type: core.ForwardMsgType_FORWARD_MSG_TYPE_ACCEPTED,
+			Data: &core.ForwardMsg_Accepted{Accepted: &core.ForwardAccepted{
+				Id:         id,
+				RemoteAddr: addrString(conn.RemoteAddr()),
+				LocalAddr:  addrString(conn.LocalAddr()),
+			}},
+		})
+		if err != nil {
+			if p := s.takePending(id); p != nil {
+				_ = p.conn.Close()
+			}
+			return err
+		}
+	}
+}
+
+// Accept 认领 Listen 接收的连接并转发数据, Listen 结束时连接被关闭
+func (s *ForwardServer) Accept(stream grpc.BidiStreamingServer[core.ForwardMsg, core.ForwardMsg]) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if msg.GetType() != core.ForwardMsgType_FORWARD_MSG_TYPE_ATTACH {
+		return status.Errorf(codes.InvalidArgument, "unexpected message type: %v", msg.GetType())
+	}
+	p := s.takePending(msg.GetAttach().GetId())
+	if p == nil {
+		return status.Errorf(codes.NotFound, "connection %s not found", msg.GetAttach().GetId())
+	}
+	defer p.conn.Close()
+	go func() {
+		select {
+		case <-p.ctx.Done():
+			_ = p.conn.Close()
+		case <-stream.Context().Done():
+		}
+	}()
+	err = stream.Send(connectedMsg(p.conn))
+	if err != nil {
+		return err
+	}
+	return serveForwardConn(stream, p.conn)
+}
+
+// addPending 记录等待 Accept 的连接, 超过 AcceptTimeout 或 ctx 结束时关闭
+func (s *ForwardServer) addPending(ctx context.Context, conn net.Conn) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+	p := &pendingConn{conn: conn, ctx: ctx, taken: make(chan struct{})}
+	s.mu.Lock()
+	if s.pending == nil {
+		s.pending = make(map[string]*pendingConn)
+	}
+	s.pending[id] = p
+	s.mu.Unlock()
+
+	timeout := s.AcceptTimeout
+	if timeout <= 0 {
+		timeout = defaultAcceptTimeout
+	}
+	go func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-p.taken:
+			return
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		if s.takePending(id) != nil {
+			_ = conn.Close()
+		}
+	}()
+	return id
+}
+
+// takePending 取出等待 Accept 的连接, 不存在时返回 nil
+func (s *ForwardServer) takePending(id string) *pendingConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pending[id]
+	if !ok {
+		return nil
+	}
+	delete(s.pending, id)
+	close(p.taken)
+	return p
+}
+
+// RemoteListener 在 agent 上监听的 net.Listener, Accept 返回的连接经 agent 转发
+type RemoteListener struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cli     core.ForwardClient
+	stream  grpc.ServerStreamingClient[core.ForwardMsg]
+	network string
+	addr    net.Addr
+}
+
+// ListenRemote 请求 agent 监听 addr, Close 或 ctx 结束时 agent 停止监听并关闭所有连接
+func ListenRemote(ctx context.Context, cli core.ForwardClient, network, addr string) (*RemoteListener, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := cli.Listen(ctx, &core.ForwardListen{Network: network, Address: addr})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	msg, err := stream.Recv()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if msg.GetType() != core.ForwardMsgType_FORWARD_MSG_TYPE_LISTENING {
+		cancel()
+		return nil, status.Errorf(codes.Internal, "unexpected message type: %v", msg.GetType())
+	}
+	return &RemoteListener{
+		ctx:     ctx,
+		cancel:  cancel,
+		cli:     cli,
+		stream:  stream,
+		network: network,
+		addr:    forwardAddr{network: network, addr: msg.GetListening().GetAddress()},
+	}, nil
+}
+
+// Accept 等待 agent 接收的连接, Close 后返回 net.ErrClosed
+func (l *RemoteListener) Accept() (net.Conn, error) {
+	for {
+		msg, err := l.stream.Recv()
+		if err != nil {
+			if l.ctx.Err() != nil {
+				return nil, net.ErrClosed
+			}
+			return nil, err
+		}
+		if msg.GetType() != core.ForwardMsgType_FORWARD_MSG_TYPE_ACCEPTED {
+			continue
+		}
+		conn, err := openForward(l.ctx, l.cli.Accept, l.network, &core.ForwardMsg{
+			Type: core.ForwardMsgType_FORWARD_MSG_TYPE_ATTACH,
+			Data: &core.ForwardMsg_Attach{Attach: &core.ForwardAttach{Id: msg.GetAccepted().GetId()}},
+		})
+		if err != nil {
+			if l.ctx.Err() != nil {
+				return nil, net.ErrClosed
+			}
+			// 连接已超时或已断开, 不影响后续的连接
+			continue
+		}
+		return conn, nil
+	}
+}
+
+// Close 停止 agent 上的监听, 并关闭所有经该监听接收的连接
+func (l *RemoteListener) Close() error {
+	l.cancel()
+	return nil
+}
+
+// Addr 返回 agent 实际监听的地址
+func (l *RemoteListener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+func TestRemoteForward(t *testing.T) {
+	echo := testutil.StartTCPEcho(t)
+	cli := newForwardClient(t, &ForwardServer{ListenAllow: []string{"127.0.0.1:*"}})
+	l, err := ListenRemote(ctx, cli, "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		_ = ServeForward(l, func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", echo)
+		}, nil)
+	}()
+
+	// 连接 agent 上的监听地址, 经客户端转发到 echo
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(buf))
+
+	// 关闭后 agent 停止监听并关闭已有连接
+	require.NoError(t, l.Close())
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(buf)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, os.ErrDeadlineExceeded)
+	require.Eventually(t, func() bool {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return true
+		}
+		_ = c.Close()
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = l.Accept()
+	require.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestRemoteForwardDenied(t *testing.T) {
+	cli := newForwardClient(t, &ForwardServer{Allow: []string{"*:*"}})
+	_, err := ListenRemote(ctx, cli, "tcp", "127.0.0.1:0")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestRemoteForwardAcceptTimeout(t *testing.T) {
+	cli := newForwardClient(t, &ForwardServer{
+		ListenAllow:   []string{"127.0.0.1:*"},
+		AcceptTimeout: 50 * time.Millisecond,
+	})
+	l, err := ListenRemote(ctx, cli, "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	// 客户端没有 Accept 的连接超时后被关闭
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestRemoteForwardUnix(t *testing.T) {
+	dir := t.TempDir()
+	echo := testutil.StartTCPEcho(t)
+	cli := newForwardClient(t, &ForwardServer{ListenAllowUnix: []string{dir + "/*"}})
+	_, err := ListenRemote(ctx, cli, "unix", "/tmp/other.sock")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	sock := filepath.Join(dir, "agent.sock")
+	l, err := ListenRemote(ctx, cli, "unix", sock)
+	require.NoError(t, err)
+	require.Equal(t, sock, l.Addr().String())
+	go func() {
+		_ = ServeForward(l, func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", echo)
+		}, nil)
+	}()
+	conn, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(buf))
+
+	// 停止监听后 socket 文件被删除
+	require.NoError(t, l.Close())
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(sock)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,742 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/record"
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+const (
+	// defaultScrollback 会话默认保留的输出字节数
+	defaultScrollback = 256 * 1024
+	// clientQueueSize 每个客户端待发送消息的上限, 超出时断开该客户端
+	clientQueueSize = 1024
+	// clientDrainTimeout 客户端分离时等待队列中的消息发送完毕的最长时间
+	clientDrainTimeout = 5 * time.Second
+)
+
+// errDetach 客户端主动断开会话
+var errDetach = errors.New("detach")
+
+// errSlowClient 客户端接收输出过慢, 待发送的消息超出 clientQueueSize
+var errSlowClient = status.Error(codes.ResourceExhausted, "client is too slow to receive output")
+
+// SessionRegistry 保存可以在 stream 断开后重新连接的会话
+type SessionRegistry struct {
+	// Scrollback 每个会话保留的输出字节数, 为 0 时使用 defaultScrollback
+	Scrollback int
+	// DetachTimeout 会话没有客户端连接时保留的时长, 超时后结束进程, 为 0 时一直保留
+	DetachTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func (r *SessionRegistry) add(s *session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sessions == nil {
+		r.sessions = make(map[string]*session)
+	}
+	r.sessions[s.id] = s
+	s.registry = r
+}
+
+func (r *SessionRegistry) get(id string) (*session, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	return s, ok
+}
+
+func (r *SessionRegistry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+func (r *SessionRegistry) list() []*session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := make([]*session, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].created.Before(sessions[j].created)
+	})
+	return sessions
+}
+
+func (r *SessionRegistry) scrollbackSize() int {
+	if r == nil || r.Scrollback <= 0 {
+		return defaultScrollback
+	}
+	return r.Scrollback
+}
+
+// session 一个运行中的进程及其输出缓冲, 进程的生命周期与 stream 无关
+type session struct {
+	id       string
+	cmd      *core.Cmd
+	proc     *process
+	created  time.Time
+	cancel   context.CancelFunc
+	registry *SessionRegistry
+	// recording 不为空时录制会话的输入输出
+	recording *record.Recording
+
+	mu         sync.Mutex
+	scrollback *scrollback
+	clients    map[string]*attachment
+	writer     *attachment
+	timer      *time.Timer
+	exit       *core.ExitStatus
+	done       chan struct{}
+
+	// inputMu 重新连接时新旧 stream 可能同时写入 stdin
+	inputMu sync.Mutex
+}
+
+// attachment 连接到会话的客户端, 同一时间只有一个客户端拥有写入权.
+// 发给客户端的消息先放入 queue, 由单独的 goroutine 发送, 慢客户端不会阻塞会话
+type attachment struct {
+	id     string
+	stream MsgStream
+	// queue 分离时关闭, 只在持有 session.mu 时写入和关闭
+	queue chan *core.ShellMsg
+	// kicked 因接收过慢被断开时关闭
+	kicked chan struct{}
+	// sent 发送 goroutine 退出时关闭, 之后可以读取 err
+	sent chan struct{}
+	err  error
+}
+
+// send 发送 queue 中的消息直到 queue 关闭, 出错后丢弃剩余的消息
+func (a *attachment) send() {
+	defer close(a.sent)
+	for msg := range a.queue {
+		if a.err == nil {
+			a.err = a.stream.Send(msg)
+		}
+	}
+}
+
+// wait 在分离后等待队列中的消息发送完毕, 返回发送错误
+func (a *attachment) wait() error {
+	select {
+	case <-a.kicked:
+		return errSlowClient
+	default:
+	}
+	select {
+	case <-a.sent:
+		return a.err
+	case <-time.After(clientDrainTimeout):
+		return errSlowClient
+	}
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// startSession 启动进程并开始读取输出
+func (s Server) startSession(c *core.Cmd) (*session, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	proc, err := s.processCommand(ctx, c)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sess := &session{
+		id:         newSessionID(),
+		cmd:        c,
+		proc:       proc,
+		created:    time.Now(),
+		cancel:     cancel,
+		scrollback: newScrollback(s.Sessions.scrollbackSize()),
+		clients:    make(map[string]*attachment),
+		done:       make(chan struct{}),
+	}
+	if s.Recorder != nil {
+		sess.recording, err = s.Recorder.Start(sess.id, record.Header{
+			Width:   defaultCols,
+			Height:  defaultRows,
+			Command: strings.Join(append([]string{c.GetPath()}, c.GetArgs()...), " "),
+			Title:   sess.id,
+			Env: map[string]string{
+				termEnv: lookupEnv(proc.Env, termEnv),
+				"SHELL": proc.Path,
+			},
+		})
+		if err != nil {
+			_ = proc.Close()
+			cancel()
+			return nil, err
+		}
+	}
+	proc.Stderr = sess.output(core.IODataType_Stderr)
+	if proc.pty == nil {
+		proc.Stdout = sess.output(core.IODataType_Stdout)
+	}
+	err = proc.Start()
+	if err != nil {
+		_ = proc.Close()
+		_ = sess.recording.Close()
+		cancel()
+		return nil, err
+	}
+
+	outDone := make(chan struct{})
+	if proc.pty != nil {
+		// 父进程不再持有 tty, 子进程退出后读取 ptmx 会返回 EIO
+		_ = proc.tty.Close()
+		proc.tty = nil
+		go func() {
+			defer close(outDone)
+			_, _ = io.Copy(sess.output(core.IODataType_Stdout), proc.pty)
+		}()
+	} else {
+		// 管道模式下 Wait 会等待 stdout 和 stderr 复制完成
+		close(outDone)
+	}
+	go sess.wait(outDone)
+	return sess, nil
+}
+
+func (s *session) wait(outDone <-chan struct{}) {
+	err := s.proc.Wait()
+	select {
+	case <-outDone:
+	case <-time.After(outputDrainTimeout):
+	}
+	_ = s.proc.Close()
+	_ = s.recording.Close()
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exit = exitStatus(s.proc.ProcessState, err)
+	close(s.done)
+	// 有客户端连接时由 serveSession 在发送退出状态后移除
+	if len(s.clients) == 0 && s.registry != nil {
+		s.registry.remove(s.id)
+	}
+}
+
+// kill 结束会话的整个进程组
+func (s *session) kill() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	_ = syscall.Kill(-s.proc.Process.Pid, syscall.SIGKILL)
+	s.cancel()
+}
+
+// output 返回写入会话输出的 io.Writer, 输出同时写入缓冲区和所有连接的客户端
+func (s *session) output(t core.IODataType) io.Writer {
+	return sessionOutput{session: s, t: t}
+}
+
+type sessionOutput struct {
+	session *session
+	t       core.IODataType
+}
+
+func (w sessionOutput) Write(p []byte) (int, error) {
+	s := w.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := s.scrollback.write(w.t, p)
+	if s.recording != nil {
+		_ = s.recording.Output(p)
+	}
+	for _, c := range s.clients {
+		s.push(c, ioMsg(data))
+	}
+	return len(p), nil
+}
+
+// push 将消息放入客户端的发送队列, 队列已满时断开该客户端, 需持有 s.mu
+func (s *session) push(a *attachment, msg *core.ShellMsg) {
+	select {
+	case a.queue <- msg:
+	default:
+		close(a.kicked)
+		s.remove(a)
+	}
+}
+
+func ioMsg(data *core.IoData) *core.ShellMsg {
+	return &core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_IO,
+		Data: &core.ShellMsg_IO{IO: data},
+	}
+}
+
+// attach 连接客户端并从 offset 开始回放输出.
+// 非只读的客户端在没有写入者时获取写入权, 否则作为观察者连接, 需由写入者移交
+func (s *session) attach(stream MsgStream, offset int64, readOnly bool) *attachment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writer := !readOnly && s.writer == nil
+	start, chunks := s.scrollback.since(offset)
+	// 回放的输出不计入队列上限
+	a := &attachment{
+		id:     newSessionID(),
+		stream: stream,
+		queue:  make(chan *core.ShellMsg, len(chunks)+1+clientQueueSize),
+		kicked: make(chan struct{}),
+		sent:   make(chan struct{}),
+	}
+	go a.send()
+	if s.registry != nil {
+		a.queue <- &core.ShellMsg{
+			Type: core.ShellMsgType_SHELL_MSG_TYPE_SESSION,
+			Data: &core.ShellMsg_Session{
+				Session: &core.Session{
+					Id:       s.id,
+					Offset:   start,
+					ClientId: a.id,
+					Writer:   writer,
+				},
+			},
+		}
+	}
+	for _, data := range chunks {
+		a.queue <- ioMsg(data)
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	// 新客户端已通过 Session 消息得知自己是否为写入者, 只需通知其他客户端
+	if writer {
+		s.setWriter(a)
+	}
+	s.clients[a.id] = a
+	return a
+}
+
+// setWriter 变更写入者并通知所有客户端, 需持有 s.mu
+func (s *session) setWriter(a *attachment) {
+	s.writer = a
+	var id string
+	if a != nil {
+		id = a.id
+	}
+	msg := controlMsg(core.ControlAction_CONTROL_ACTION_WRITER, id)
+	for _, c := range s.clients {
+		s.push(c, msg)
+	}
+}
+
+func (s *session) isWriter(a *attachment) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer == a
+}
+
+// control 处理客户端发送的写入权变更
+func (s *session) control(a *attachment, c *core.Control) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch c.GetAction() {
+	case core.ControlAction_CONTROL_ACTION_HANDOVER:
+		target, ok := s.clients[c.GetClientId()]
+		if s.writer != a || !ok {
+			s.reject(a, c.GetClientId())
+			return
+		}
+		s.setWriter(target)
+	case core.ControlAction_CONTROL_ACTION_TAKE:
+		if s.writer != nil {
+			s.reject(a, a.id)
+			return
+		}
+		s.setWriter(a)
+	}
+}
+
+// reject 通知客户端请求被拒绝, 需持有 s.mu
+func (s *session) reject(a *attachment, clientID string) {
+	if _, ok := s.clients[a.id]; ok {
+		s.push(a, controlMsg(core.ControlAction_CONTROL_ACTION_REJECTED, clientID))
+	}
+}
+
+func controlMsg(action core.ControlAction, clientID string) *core.ShellMsg {
+	return &core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_CONTROL,
+		Data: &core.ShellMsg_Control{
+			Control: &core.Control{Action: action, ClientId: clientID},
+		},
+	}
+}
+
+// detach 断开客户端, last 不为空时作为最后一条消息发送
+func (s *session) detach(a *attachment, last *core.ShellMsg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[a.id]; !ok {
+		return
+	}
+	if last != nil {
+		s.push(a, last)
+	}
+	s.remove(a)
+}
+
+// remove 移除客户端并关闭其发送队列, 没有客户端连接时会话在 DetachTimeout 后结束, 需持有 s.mu
+func (s *session) remove(a *attachment) {
+	if _, ok := s.clients[a.id]; !ok {
+		return
+	}
+	delete(s.clients, a.id)
+	close(a.queue)
+	if s.writer == a {
+		s.setWriter(nil)
+	}
+	if len(s.clients) > 0 || s.registry == nil {
+		return
+	}
+	select {
+	case <-s.done:
+		// 进程已退出且没有客户端连接
+		s.registry.remove(s.id)
+		return
+	default:
+	}
+	if s.registry.DetachTimeout > 0 {
+		s.timer = time.AfterFunc(s.registry.DetachTimeout, s.expire)
+	}
+}
+
+func (s *session) expire() {
+	s.mu.Lock()
+	attached := len(s.clients) > 0
+	s.mu.Unlock()
+	if attached {
+		return
+	}
+	s.kill()
+	s.registry.remove(s.id)
+}
+
+func (s *session) info() *core.SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &core.SessionInfo{
+		Id:       s.id,
+		Path:     s.cmd.GetPath(),
+		Args:     s.cmd.GetArgs(),
+		Pid:      int32(s.proc.Process.Pid),
+		Created:  s.created.Unix(),
+		Attached: len(s.clients) > 0,
+		Offset:   s.scrollback.end,
+		Exit:     s.exit,
+		Clients:  int32(len(s.clients)),
+	}
+}
+
+// input 处理客户端发送的 stdin、resize、信号等消息
+func (s *session) input(a *attachment, stream MsgStream, errOutput io.Writer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		switch msg.GetType() {
+		case core.ShellMsgType_SHELL_MSG_TYPE_DETACH:
+			return errDetach
+		case core.ShellMsgType_SHELL_MSG_TYPE_CONTROL:
+			s.control(a, msg.GetControl())
+		case core.ShellMsgType_SHELL_MSG_TYPE_RESIZE:
+			// 只读观察者的窗口大小不影响 pty
+			if !s.isWriter(a) {
+				continue
+			}
+			err = s.handleInput(msg, errOutput)
+		default:
+			if !s.isWriter(a) {
+				s.mu.Lock()
+				s.reject(a, a.id)
+				s.mu.Unlock()
+				continue
+			}
+			err = s.handleInput(msg, errOutput)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *session) handleInput(msg *core.ShellMsg, errOutput io.Writer) error {
+	s.inputMu.Lock()
+	defer s.inputMu.Unlock()
+	proc := s.proc
+	switch msg.GetType() {
+	case core.ShellMsgType_SHELL_MSG_TYPE_IO:
+		if proc.stdinClosed {
+			return nil
+		}
+		if s.recording != nil {
+			_ = s.recording.Input(msg.GetIO().GetData())
+		}
+		_, err := proc.stdin.Write(msg.GetIO().GetData())
+		return err
+	case core.ShellMsgType_SHELL_MSG_TYPE_RESIZE:
+		if proc.pty == nil {
+			return nil
+		}
+		err := pty.Setsize(proc.pty, &pty.Winsize{
+			Rows: uint16(msg.GetResize().GetRows()),
+			Cols: uint16(msg.GetResize().GetCols()),
+		})
+		if err != nil {
+			_, _ = fmt.Fprintf(errOutput, "resize terminal: %v\n", err)
+			return nil
+		}
+		if s.recording != nil {
+			_ = s.recording.Resize(int(msg.GetResize().GetCols()), int(msg.GetResize().GetRows()))
+		}
+	case core.ShellMsgType_SHELL_MSG_TYPE_SIGNAL:
+		err := proc.signal(msg.GetSignal().GetName())
+		if err != nil {
+			_, _ = fmt.Fprintf(errOutput, "signal: %v\n", err)
+		}
+	case core.ShellMsgType_SHELL_MSG_TYPE_STDIN_EOF:
+		err := proc.closeStdin()
+		if err != nil {
+			_, _ = fmt.Fprintf(errOutput, "close stdin: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// serveSession 将 stream 连接到会话, 直到进程退出、stream 断开或客户端主动分离
+func (s Server) serveSession(stream grpc.BidiStreamingServer[core.ShellMsg, core.ShellMsg], sess *session, offset int64, readOnly bool) error {
+	sender := SyncStream(stream)
+	a := sess.attach(sender, offset, readOnly)
+	defer func() {
+		sess.detach(a, nil)
+		_ = a.wait()
+	}()
+
+	rpcerr := StreamWriter(sender, core.IODataType_Stderr)
+	inputErr := make(chan error, 1)
+	go func() {
+		inputErr <- sess.input(a, stream, rpcerr)
+	}()
+	// closed 客户端关闭发送端后不会再从 inputErr 返回, 改为等待 stream 结束
+	var closed <-chan struct{}
+	for {
+		select {
+		case <-closed:
+			return stream.Context().Err()
+		case <-a.kicked:
+			return errSlowClient
+		case <-sess.done:
+			if sess.registry != nil {
+				sess.registry.remove(sess.id)
+			}
+			sess.detach(a, &core.ShellMsg{
+				Type: core.ShellMsgType_SHELL_MSG_TYPE_EXIT,
+				Data: &core.ShellMsg_Exit{Exit: sess.exit},
+			})
+			return a.wait()
+		case err := <-inputErr:
+			if errors.Is(err, errDetach) {
+				return nil
+			}
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			// 写入者关闭发送端: 管道模式下关闭 stdin 并等待进程退出, pty 模式下结束进程
+			switch {
+			case !sess.isWriter(a):
+				// 只读观察者关闭发送端后继续接收输出
+			case sess.proc.pty == nil:
+				sess.inputMu.Lock()
+				_ = sess.proc.closeStdin()
+				sess.inputMu.Unlock()
+			default:
+				sess.kill()
+			}
+			inputErr = nil
+			closed = stream.Context().Done()
+		}
+	}
+}
+
+func (s Server) AttachSession(stream grpc.BidiStreamingServer[core.ShellMsg, core.ShellMsg]) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if msg.GetType() != core.ShellMsgType_SHELL_MSG_TYPE_ATTACH {
+		return fmt.Errorf("unexpected message type: %v", msg.GetType())
+	}
+	sess, err := s.session(msg.GetAttach().GetId())
+	if err != nil {
+		return err
+	}
+	return s.serveSession(stream, sess, msg.GetAttach().GetOffset(), msg.GetAttach().GetReadOnly())
+}
+
+func (s Server) ListSessions(context.Context, *core.ListSessionsRequest) (*core.ListSessionsResponse, error) {
+	res := &core.ListSessionsResponse{}
+	if s.Sessions == nil {
+		return res, nil
+	}
+	for _, sess := range s.Sessions.list() {
+		res.Sessions = append(res.Sessions, sess.info())
+	}
+	return res, nil
+}
+
+func (s Server) KillSession(_ context.Context, req *core.KillSessionRequest) (*core.KillSessionResponse, error) {
+	sess, err := s.session(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	sess.kill()
+	s.Sessions.remove(sess.id)
+	return &core.KillSessionResponse{}, nil
+}
+
+func (s Server) session(id string) (*session, error) {
+	if s.Sessions == nil {
+		return nil, status.Error(codes.FailedPrecondition, "sessions are not enabled")
+	}
+	sess, ok := s.Sessions.get(id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "session not found: %s", id)
+	}
+	return sess, nil
+}
+
+// scrollback 保存最近输出的缓冲区, 超出容量时丢弃最早的数据.
+// start 和 end 为累计输出的字节偏移
+type scrollback struct {
+	size   int
+	bytes  int
+	start  int64
+	end    int64
+	chunks []*core.IoData
+}
+
+func newScrollback(size int) *scrollback {
+	return &scrollback{size: size}
+}
+
+// write 复制 p 并追加到缓冲区
+func (b *scrollback) write(t core.IODataType, p []byte) *core.IoData {
+	data := &core.IoData{Type: t, Data: append([]byte(nil), p...)}
+	b.chunks = append(b.chunks, data)
+	b.bytes += len(p)
+	b.end += int64(len(p))
+	for b.bytes > b.size {
+		first := b.chunks[0]
+		over := b.bytes - b.size
+		if over < len(first.Data) {
+			b.chunks[0] = &core.IoData{Type: first.Type, Data: first.Data[over:]}
+			b.bytes -= over
+			b.start += int64(over)
+			break
+		}
+		b.chunks[0] = nil
+		b.chunks = b.chunks[1:]
+		b.bytes -= len(first.Data)
+		b.start += int64(len(first.Data))
+	}
+	return data
+}
+
+// since 返回 offset 之后的输出, offset 早于缓冲区起点时从起点开始
+func (b *scrollback) since(offset int64) (int64, []*core.IoData) {
+	if offset < b.start {
+		offset = b.start
+	}
+	if offset > b.end {
+		offset = b.end
+	}
+	var chunks []*core.IoData
+	pos := b.start
+	for _, c := range b.chunks {
+		next := pos + int64(len(c.Data))
+		if next > offset {
+			if pos < offset {
+				c = &core.IoData{Type: c.Type, Data: c.Data[offset-pos:]}
+			}
+			chunks = append(chunks, c)
+		}
+		pos = next
+	}
+	return offset, chunks
+}
+
+// SessionState 客户端记录的会话 ID 和已接收的输出偏移, 断线后用于重新连接
+type SessionState struct {
+	ID     string
+	Offset int64
+	// ReadOnly 以只读观察者身份连接
+	ReadOnly bool
+	// ClientID 当前客户端在会话中的 ID, Writer 当前客户端是否拥有写入权
+	ClientID string
+	Writer   bool
+}
+
+// StreamOutput 同包级函数 StreamOutput, 同时更新会话 ID 和输出偏移
+func (st *SessionState) StreamOutput(stream MsgStream, stdout, stderr io.Writer) (*core.ExitStatus, error) {
+	return streamOutput(stream, stdout, stderr, st)
+}
+
+// Attach 发送 AttachSession 的第一条消息, 从已接收的偏移处继续
+func (st *SessionState) Attach(stream MsgStream) error {
+	return stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_ATTACH,
+		Data: &core.ShellMsg_Attach{
+			Attach: &core.Attach{Id: st.ID, Offset: st.Offset, ReadOnly: st.ReadOnly},
+		},
+	})
+}
+
+// Detach 通知服务端断开会话, 进程继续运行
+func Detach(stream MsgStream) error {
+	return stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_DETACH,
+	})
+}
+
+// Handover 将写入权交给会话中的另一个客户端
+func Handover(stream MsgStream, clientID string) error {
+	return stream.Send(controlMsg(core.ControlAction_CONTROL_ACTION_HANDOVER, clientID))
+}
+
+// TakeControl 在会话没有写入者时获取写入权
+func TakeControl(stream MsgStream) error {
+	return stream.Send(controlMsg(core.ControlAction_CONTROL_ACTION_TAKE, ""))
+}
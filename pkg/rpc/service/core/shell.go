@@ -9,23 +9,44 @@ import (
 	"os/exec"
 	"os/user"
 	"strconv"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/creack/pty"
+	"golang.org/x/sys/unix"
 	"google.golang.org/grpc"
 
+	"github.com/lyp256/tianmen/pkg/record"
 	"github.com/lyp256/tianmen/pkg/rpc/api/core"
 )
 
 type Server struct {
 	core.UnimplementedShellServer
 	DefaultCommand string
+	// DefaultDir Cmd.Dir 为空时的工作目录, 为空则使用 agent 的工作目录
+	DefaultDir string
+	// DefaultTerm pty 模式下客户端未设置 TERM 时使用的值
+	DefaultTerm string
+	// EnvAllow 允许客户端设置的环境变量, 支持以 * 结尾的前缀匹配, 为空表示全部允许
+	EnvAllow []string
+	// EnvDeny 禁止客户端设置的环境变量, 优先于 EnvAllow
+	EnvDeny []string
+	// Sessions 不为空时 stream 断开后保留会话, 客户端可以通过 AttachSession 重新连接
+	Sessions *SessionRegistry
+	// Recorder 不为空时以 asciinema v2 格式录制所有会话
+	Recorder *record.Recorder
 }
 
-func (s Server) Shell(stream grpc.BidiStreamingServer[core.ShellMsg, core.ShellMsg]) error {
-	rpcout := StreamWriter(stream, core.IODataType_Stdout)
-	rpcerr := StreamWriter(stream, core.IODataType_Stderr)
+const (
+	// outputDrainTimeout 进程退出后等待 pty 输出读取完毕的最长时间
+	outputDrainTimeout = time.Second
+	// pty 的初始窗口大小
+	defaultRows = 24
+	defaultCols = 80
+)
 
+func (s Server) Shell(stream grpc.BidiStreamingServer[core.ShellMsg, core.ShellMsg]) error {
 	cmdMsg, err := stream.Recv()
 	if err != nil {
 		return err
@@ -34,101 +55,80 @@ func (s Server) Shell(stream grpc.BidiStreamingServer[core.ShellMsg, core.ShellM
 	if cmdMsg.GetType() != core.ShellMsgType_SHELL_MSG_TYPE_COMMAND {
 		return fmt.Errorf("unexpected message type: %v", cmdMsg.GetType())
 	}
-	proc, ptmx, err := s.processCommand(stream.Context(), cmdMsg.GetCmd())
-	if err != nil {
-		return err
-	}
-	stderrReader, stderrWriter := io.Pipe()
-	defer func() {
-		_ = stderrWriter.Close()
-		_ = stderrWriter.Close()
-	}()
-	proc.Stderr = stderrWriter
-
-	errCh := make(chan error)
-	// stdout
-	go func() {
-		_, err := io.Copy(rpcout, ptmx)
-		select {
-		case errCh <- err:
-		default:
-		}
-	}()
-	// stderr
-	go func() {
-		_, err := io.Copy(rpcerr, stderrReader)
-		select {
-		case errCh <- err:
-		default:
-		}
-	}()
-	// stdin/resize
-	go func() {
-		err = streamInput(stream, ptmx, rpcerr)
-		select {
-		case errCh <- err:
-		default:
-		}
-	}()
-
-	err = proc.Start()
+	sess, err := s.startSession(cmdMsg.GetCmd())
 	if err != nil {
 		return err
 	}
-	go func() {
-		err = proc.Wait()
-		select {
-		case errCh <- err:
-		default:
-		}
-	}()
-
-	err = <-errCh
-	if errors.Is(err, io.EOF) {
-		err = nil
+	if s.Sessions == nil {
+		// 未启用会话时进程随 stream 结束
+		defer sess.kill()
+	} else {
+		s.Sessions.add(sess)
 	}
-	return err
+	return s.serveSession(stream, sess, 0, false)
 }
 
-func (s Server) processCommand(ctx context.Context, c *core.Cmd) (*process, *os.File, error) {
+func (s Server) processCommand(ctx context.Context, c *core.Cmd) (*process, error) {
 	if c.Path == "" {
 		c.Path = s.DefaultCommand
 	}
 	cmdPath, err := exec.LookPath(c.Path)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	envs, err := s.environ(c)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.workDir(c)
+	if err != nil {
+		return nil, err
 	}
 	p := exec.CommandContext(ctx, cmdPath, c.Args...)
+	p.Env = envs
+	p.Dir = dir
 	sysProcAttr := c.GetLinux()
 	p.SysProcAttr = &syscall.SysProcAttr{
 		Chroot:     sysProcAttr.GetChroot(),
 		Credential: credentials(sysProcAttr),
 		Setsid:     true,
-		Setctty:    true,
+		Setctty:    !c.GetDisablePty(),
 	}
+	if c.GetDisablePty() {
+		stdin, err := p.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		return &process{
+			Cmd:   p,
+			stdin: stdin,
+		}, nil
+	}
+
 	ptmx, tty, err := pty.Open()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	p.Stdout = tty
 	p.Stdin = tty
 	p.Stderr = tty
 
 	err = pty.Setsize(ptmx, &pty.Winsize{
-		Rows: 24,
-		Cols: 80,
+		Rows: defaultRows,
+		Cols: defaultCols,
 	})
 	if err != nil {
 		_ = ptmx.Close()
 		_ = tty.Close()
-		return nil, nil, err
+		return nil, err
 	}
 
 	return &process{
-		Cmd: p,
-		pty: ptmx,
-		tty: tty,
-	}, ptmx, nil
+		Cmd:   p,
+		pty:   ptmx,
+		tty:   tty,
+		stdin: ptmx,
+	}, nil
 }
 
 func credentials(attr *core.SysProcAttrLinux) *syscall.Credential {
@@ -164,6 +164,27 @@ type process struct {
 	*exec.Cmd
 	pty *os.File
 	tty *os.File
+	// stdin 子进程的输入, pty 模式下为 ptmx
+	stdin       io.WriteCloser
+	stdinClosed bool
+}
+
+// closeStdin 结束子进程的输入, 管道模式下关闭 stdin, pty 模式下写入 VEOF 字符.
+// VEOF 仅在行首时使 read 返回 0, 因此 pty 模式的输入应以换行结尾
+func (c *process) closeStdin() error {
+	if c.pty == nil {
+		if c.stdinClosed {
+			return nil
+		}
+		c.stdinClosed = true
+		return c.stdin.Close()
+	}
+	termios, err := unix.IoctlGetTermios(int(c.pty.Fd()), unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	_, err = c.pty.Write([]byte{termios.Cc[unix.VEOF]})
+	return err
 }
 
 func (c *process) Close() error {
@@ -178,35 +199,42 @@ func (c *process) Close() error {
 			_ = c.tty.Close()
 		}
 	}()
-	if c.Cmd != nil {
+	if c.Cmd != nil && c.Cmd.Process != nil && c.Cmd.ProcessState == nil {
 		_ = c.Cmd.Process.Kill()
 		return c.Cmd.Wait()
 	}
 	return nil
 }
 
-func streamInput(stream grpc.BidiStreamingServer[core.ShellMsg, core.ShellMsg], ptmx *os.File, errOutput io.Writer) error {
-	for {
-		msg, err := stream.Recv()
-		if err != nil {
-			return err
-		}
-		switch msg.GetType() {
-		case core.ShellMsgType_SHELL_MSG_TYPE_IO:
-			_, err = ptmx.Write(msg.GetIO().GetData())
-			if err != nil {
-				return err
-			}
-		case core.ShellMsgType_SHELL_MSG_TYPE_RESIZE:
-			err = pty.Setsize(ptmx, &pty.Winsize{
-				Rows: uint16(msg.GetResize().GetRows()),
-				Cols: uint16(msg.GetResize().GetCols()),
-			})
-			if err != nil {
-				_, _ = fmt.Fprintf(errOutput, "resize terminal: %v\n", err)
-			}
+// exitStatus 将进程退出状态转换为 core.ExitStatus
+func exitStatus(state *os.ProcessState, err error) *core.ExitStatus {
+	status := &core.ExitStatus{Code: -1}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		status.Error = err.Error()
+	}
+	if state == nil {
+		return status
+	}
+	status.Code = int32(state.ExitCode())
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		status.Signal = int32(ws.Signal())
+		status.CoreDumped = ws.CoreDump()
+	}
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		status.Rusage = &core.Rusage{
+			Utime:   ru.Utime.Nano() / int64(time.Microsecond),
+			Stime:   ru.Stime.Nano() / int64(time.Microsecond),
+			Maxrss:  ru.Maxrss,
+			Minflt:  ru.Minflt,
+			Majflt:  ru.Majflt,
+			Inblock: ru.Inblock,
+			Oublock: ru.Oublock,
+			Nvcsw:   ru.Nvcsw,
+			Nivcsw:  ru.Nivcsw,
 		}
 	}
+	return status
 }
 
 type MsgStream interface {
@@ -214,6 +242,22 @@ type MsgStream interface {
 	Recv() (*core.ShellMsg, error)
 }
 
+// SyncStream 包装 MsgStream, 使 Send 可以在多个 goroutine 中并发调用
+func SyncStream(stream MsgStream) MsgStream {
+	return &syncStream{MsgStream: stream}
+}
+
+type syncStream struct {
+	MsgStream
+	mu sync.Mutex
+}
+
+func (s *syncStream) Send(msg *core.ShellMsg) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MsgStream.Send(msg)
+}
+
 func StreamWriter(stream MsgStream, t core.IODataType) io.Writer {
 	return &streamWriter{
 		sender: stream,
@@ -257,3 +301,92 @@ func ReflushWindowsSize(stream MsgStream, terminal *os.File) error {
 		},
 	})
 }
+
+// ExitError 远程进程以非 0 状态退出
+type ExitError struct {
+	Status *core.ExitStatus
+}
+
+func (e *ExitError) Error() string {
+	if e.Status.GetSignal() != 0 {
+		return fmt.Sprintf("remote process killed by signal: %v", syscall.Signal(e.Status.GetSignal()))
+	}
+	return fmt.Sprintf("remote process exit status %d", e.Status.GetCode())
+}
+
+// ExitCode 按 shell 的约定返回本地进程的退出码, 进程被信号终止时为 128+信号
+func ExitCode(status *core.ExitStatus) int {
+	if status.GetSignal() != 0 {
+		return 128 + int(status.GetSignal())
+	}
+	return int(status.GetCode())
+}
+
+// ExitStatusError 进程正常退出时返回 nil, 否则返回 *ExitError
+func ExitStatusError(status *core.ExitStatus) error {
+	if status.GetCode() == 0 && status.GetSignal() == 0 {
+		return nil
+	}
+	return &ExitError{Status: status}
+}
+
+// StreamOutput 将 stream 中的输出写入 stdout 和 stderr, 直到收到进程退出状态
+func StreamOutput(stream MsgStream, stdout, stderr io.Writer) (*core.ExitStatus, error) {
+	return streamOutput(stream, stdout, stderr, nil)
+}
+
+func streamOutput(stream MsgStream, stdout, stderr io.Writer, state *SessionState) (*core.ExitStatus, error) {
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch msg.GetType() {
+		case core.ShellMsgType_SHELL_MSG_TYPE_IO:
+			data := msg.GetIO()
+			w := stdout
+			if data.GetType() == core.IODataType_Stderr {
+				w = stderr
+			}
+			_, err = w.Write(data.GetData())
+			if err != nil {
+				return nil, err
+			}
+			if state != nil {
+				state.Offset += int64(len(data.GetData()))
+			}
+		case core.ShellMsgType_SHELL_MSG_TYPE_SESSION:
+			if state != nil {
+				state.ID = msg.GetSession().GetId()
+				state.Offset = msg.GetSession().GetOffset()
+				state.ClientID = msg.GetSession().GetClientId()
+				state.Writer = msg.GetSession().GetWriter()
+			}
+		case core.ShellMsgType_SHELL_MSG_TYPE_CONTROL:
+			if state != nil && msg.GetControl().GetAction() == core.ControlAction_CONTROL_ACTION_WRITER {
+				state.Writer = state.ClientID != "" && msg.GetControl().GetClientId() == state.ClientID
+			}
+		case core.ShellMsgType_SHELL_MSG_TYPE_EXIT:
+			return msg.GetExit(), nil
+		}
+	}
+}
+
+// CloseStdin 通知远端 stdin 输入结束, 不影响后续的 resize 等消息
+func CloseStdin(stream MsgStream) error {
+	return stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_STDIN_EOF,
+	})
+}
+
+// SendStdin 将 r 的内容作为 stdin 发送到远端, 读取到 EOF 后调用 CloseStdin
+func SendStdin(stream MsgStream, r io.Reader) error {
+	_, err := io.Copy(StreamWriter(stream, core.IODataType_Stdin), r)
+	if err != nil {
+		return err
+	}
+	return CloseStdin(stream)
+}
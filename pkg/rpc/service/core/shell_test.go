@@ -1,13 +1,22 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 
+	"github.com/lyp256/tianmen/pkg/record"
 	"github.com/lyp256/tianmen/pkg/rpc/api/core"
 	"github.com/lyp256/tianmen/pkg/rpc/mux"
 	"github.com/lyp256/tianmen/pkg/testutil"
@@ -15,32 +24,607 @@ import (
 
 var ctx = context.Background()
 
-func TestGRPCWithSMuxOverTLS(t *testing.T) {
+// newShellClient 在 TLS + smux 回环连接上启动 srv, 返回对应的客户端
+func newShellClient(t *testing.T, srv *Server) core.ShellClient {
 	_, cConf := testutil.GetTLCConfig()
 	l, addr, err := testutil.RandomLocalListenTLS()
 	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
 	go func() {
 		dial := tls.Dialer{
 			Config: cConf,
 		}
 		conn, err := dial.DialContext(ctx, "tcp", addr.String())
-		require.NoError(t, err)
+		if err != nil {
+			return
+		}
 		gs := grpc.NewServer()
-		core.RegisterShellServer(gs, &Server{})
+		core.RegisterShellServer(gs, srv)
 		l, err := mux.SMuxConnectListener(conn)
-		require.NoError(t, err)
-		err = gs.Serve(l)
-		require.NoError(t, err)
+		if err != nil {
+			return
+		}
+		_ = gs.Serve(l)
 	}()
 	conn, err := l.Accept()
 	require.NoError(t, err)
 	cliConn, err := mux.SMUXClientConn(conn, mux.InsecureClient())
 	require.NoError(t, err)
-	cli := core.NewShellClient(cliConn)
-	stream, err := cli.Shell(context.Background())
+	t.Cleanup(func() {
+		_ = cliConn.Close()
+	})
+	return core.NewShellClient(cliConn)
+}
+
+func runCommand(t *testing.T, srv *Server, cmd *core.Cmd) (*core.ExitStatus, string) {
+	stream, err := newShellClient(t, srv).Shell(ctx)
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: cmd},
+	})
+	require.NoError(t, err)
+	out := &bytes.Buffer{}
+	status, err := StreamOutput(stream, out, out)
+	require.NoError(t, err)
+	return status, out.String()
+}
+
+func TestShellOutput(t *testing.T) {
+	status, out := runCommand(t, &Server{}, &core.Cmd{
+		Path: "sh",
+		Args: []string{"-c", "echo hello"},
+	})
+	require.Contains(t, out, "hello")
+	require.EqualValues(t, 0, status.GetCode())
+	require.NoError(t, ExitStatusError(status))
+	require.NotNil(t, status.GetRusage())
+}
+
+func TestShellExitCode(t *testing.T) {
+	status, _ := runCommand(t, &Server{}, &core.Cmd{
+		Path: "sh",
+		Args: []string{"-c", "exit 3"},
+	})
+	require.EqualValues(t, 3, status.GetCode())
+	require.Zero(t, status.GetSignal())
+	var exitErr *ExitError
+	require.ErrorAs(t, ExitStatusError(status), &exitErr)
+	require.Equal(t, 3, ExitCode(status))
+}
+
+func TestShellExitSignal(t *testing.T) {
+	status, _ := runCommand(t, &Server{}, &core.Cmd{
+		Path: "sh",
+		Args: []string{"-c", "kill -TERM $$"},
+	})
+	require.EqualValues(t, -1, status.GetCode())
+	require.EqualValues(t, syscall.SIGTERM, status.GetSignal())
+	require.Equal(t, 128+int(syscall.SIGTERM), ExitCode(status))
+}
+
+func TestShellPipeMode(t *testing.T) {
+	stream, err := newShellClient(t, &Server{}).Shell(ctx)
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "cat; echo err >&2"},
+			DisablePty: true,
+		}},
+	})
+	require.NoError(t, err)
+	_, err = StreamWriter(stream, core.IODataType_Stdin).Write([]byte("foo\nbar\n"))
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	status, err := StreamOutput(stream, stdout, stderr)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, status.GetCode())
+	require.Equal(t, "foo\nbar\n", stdout.String())
+	require.Equal(t, "err\n", stderr.String())
+}
+
+func TestShellEnviron(t *testing.T) {
+	t.Setenv("TIANMEN_AGENT_ENV", "agent")
+	t.Setenv(termEnv, "")
+	srv := Server{
+		EnvAllow: []string{"FOO", "APP_*"},
+		EnvDeny:  []string{"APP_SECRET"},
+	}
+	envs, err := srv.environ(&core.Cmd{
+		Envs: []*core.Env{{Name: "FOO", Value: "1"}, {Name: "APP_NAME", Value: "x"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "agent", lookupEnv(envs, "TIANMEN_AGENT_ENV"))
+	require.Equal(t, "1", lookupEnv(envs, "FOO"))
+	require.Equal(t, "x", lookupEnv(envs, "APP_NAME"))
+	require.Equal(t, defaultTerm, lookupEnv(envs, termEnv))
+
+	_, err = srv.environ(&core.Cmd{Envs: []*core.Env{{Name: "APP_SECRET", Value: "1"}}})
+	require.Error(t, err)
+	_, err = srv.environ(&core.Cmd{Envs: []*core.Env{{Name: "BAR", Value: "1"}}})
+	require.Error(t, err)
+	_, err = srv.environ(&core.Cmd{Envs: []*core.Env{{Name: "A=B", Value: "1"}}})
+	require.Error(t, err)
+
+	envs, err = srv.environ(&core.Cmd{
+		EnvMode:    core.EnvMode_ENV_MODE_CLEAR,
+		DisablePty: true,
+		Envs:       []*core.Env{{Name: "FOO", Value: "2"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"FOO=2"}, envs)
+
+	envs, err = srv.environ(&core.Cmd{
+		EnvMode: core.EnvMode_ENV_MODE_INHERIT,
+		Envs:    []*core.Env{{Name: "FOO", Value: "3"}, {Name: termEnv, Value: "vt100"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "agent", lookupEnv(envs, "TIANMEN_AGENT_ENV"))
+	require.Empty(t, lookupEnv(envs, "FOO"))
+	require.Equal(t, "vt100", lookupEnv(envs, termEnv))
+}
+
+func TestShellEnvAndDir(t *testing.T) {
+	dir := t.TempDir()
+	status, out := runCommand(t, &Server{DefaultDir: dir}, &core.Cmd{
+		Path:       "sh",
+		Args:       []string{"-c", "echo $FOO; pwd"},
+		Envs:       []*core.Env{{Name: "FOO", Value: "bar"}},
+		EnvMode:    core.EnvMode_ENV_MODE_CLEAR,
+		DisablePty: true,
+	})
+	require.EqualValues(t, 0, status.GetCode())
+	require.Equal(t, "bar\n"+dir+"\n", out)
+}
+
+func TestShellWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0o644))
+
+	srv := Server{DefaultDir: dir}
+	d, err := srv.workDir(&core.Cmd{})
+	require.NoError(t, err)
+	require.Equal(t, dir, d)
+	d, err = srv.workDir(&core.Cmd{Dir: "sub"})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "sub"), d)
+	_, err = srv.workDir(&core.Cmd{Dir: "file"})
+	require.Error(t, err)
+	_, err = srv.workDir(&core.Cmd{Dir: "missing"})
+	require.Error(t, err)
+	_, err = Server{}.workDir(&core.Cmd{Dir: "relative"})
+	require.Error(t, err)
+}
+
+func TestShellStdinEOF(t *testing.T) {
+	for name, cmd := range map[string]*core.Cmd{
+		"pipe": {Path: "sh", Args: []string{"-c", "sort"}, DisablePty: true},
+		"pty":  {Path: "sh", Args: []string{"-c", "stty -echo; sort"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stream, err := newShellClient(t, &Server{}).Shell(ctx)
+			require.NoError(t, err)
+			err = stream.Send(&core.ShellMsg{
+				Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+				Data: &core.ShellMsg_Cmd{Cmd: cmd},
+			})
+			require.NoError(t, err)
+			require.NoError(t, SendStdin(stream, strings.NewReader("b\na\n")))
+
+			out := &bytes.Buffer{}
+			status, err := StreamOutput(stream, out, out)
+			require.NoError(t, err)
+			require.EqualValues(t, 0, status.GetCode())
+			require.Contains(t, strings.ReplaceAll(out.String(), "\r\n", "\n"), "a\nb\n")
+		})
+	}
+}
+
+func TestShellSignal(t *testing.T) {
+	stream, err := newShellClient(t, &Server{}).Shell(ctx)
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "trap 'echo usr1' USR1; echo ready; while :; do sleep 0.1; done"},
+			DisablePty: true,
+		}},
+	})
+	require.NoError(t, err)
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "ready\n", string(msg.GetIO().GetData()))
+
+	// 本地收到的信号经 ForwardSignals 发送到整个进程组, sleep 被终止时 sh 会向 stderr 输出提示.
+	// 测试进程先注册 SIGUSR1, 避免 ForwardSignals 注册前收到信号时退出
+	local := make(chan os.Signal, 1)
+	signal.Notify(local, syscall.SIGUSR1)
+	defer signal.Stop(local)
+	sender := SyncStream(stream)
+	fwdCtx, cancel := context.WithCancel(ctx)
+	fwdErr := make(chan error, 1)
+	go func() {
+		fwdErr <- ForwardSignals(fwdCtx, sender, syscall.SIGUSR1)
+	}()
+	stdout := make(chan string, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			if msg.GetIO().GetType() == core.IODataType_Stdout {
+				stdout <- string(msg.GetIO().GetData())
+				return
+			}
+		}
+	}()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	var out string
+	for out == "" {
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+		select {
+		case out = <-stdout:
+		case <-ticker.C:
+		case <-timeout:
+			t.Fatal("signal not forwarded")
+		}
+	}
+	require.Equal(t, "usr1\n", out)
+	cancel()
+	require.NoError(t, <-fwdErr)
+
+	require.NoError(t, SendSignal(sender, syscall.SIGKILL))
+	status, err := StreamOutput(stream, io.Discard, io.Discard)
+	require.NoError(t, err)
+	require.EqualValues(t, syscall.SIGKILL, status.GetSignal())
+}
+
+func TestSessionReattach(t *testing.T) {
+	srv := &Server{Sessions: &SessionRegistry{}}
+	cli := newShellClient(t, srv)
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := cli.Shell(streamCtx)
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "echo one; read x; sleep 0.2; echo two $x"},
+			DisablePty: true,
+		}},
+	})
+	require.NoError(t, err)
+
+	state := &SessionState{}
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, core.ShellMsgType_SHELL_MSG_TYPE_SESSION, msg.GetType())
+	state.ID = msg.GetSession().GetId()
+	msg, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(msg.GetIO().GetData()))
+	state.Offset += int64(len(msg.GetIO().GetData()))
+
+	_, err = StreamWriter(stream, core.IODataType_Stdin).Write([]byte("foo\n"))
+	require.NoError(t, err)
+	// 模拟连接断开, 进程继续运行
+	cancel()
+
+	require.Eventually(t, func() bool {
+		res, err := cli.ListSessions(ctx, &core.ListSessionsRequest{})
+		return err == nil && len(res.GetSessions()) == 1 && !res.GetSessions()[0].GetAttached()
+	}, time.Second, 10*time.Millisecond)
+
+	stream, err = cli.AttachSession(ctx)
+	require.NoError(t, err)
+	require.NoError(t, state.Attach(stream))
+	out := &bytes.Buffer{}
+	status, err := state.StreamOutput(stream, out, out)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, status.GetCode())
+	require.Equal(t, "two foo\n", out.String())
+
+	res, err := cli.ListSessions(ctx, &core.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Empty(t, res.GetSessions())
+}
+
+func TestSessionDetachAndKill(t *testing.T) {
+	srv := &Server{Sessions: &SessionRegistry{}}
+	cli := newShellClient(t, srv)
+	stream, err := cli.Shell(ctx)
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path: "sh",
+			Args: []string{"-c", "echo hello; sleep 10"},
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, Detach(stream))
+	state := &SessionState{}
+	_, err = state.StreamOutput(stream, io.Discard, io.Discard)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.NotEmpty(t, state.ID)
+
+	// 从头回放全部输出
+	stream, err = cli.AttachSession(ctx)
+	require.NoError(t, err)
+	require.NoError(t, (&SessionState{ID: state.ID}).Attach(stream))
+	out := &bytes.Buffer{}
+	for !strings.Contains(out.String(), "hello") {
+		msg, err := stream.Recv()
+		require.NoError(t, err)
+		out.Write(msg.GetIO().GetData())
+	}
+
+	_, err = cli.KillSession(ctx, &core.KillSessionRequest{Id: state.ID})
+	require.NoError(t, err)
+	status, err := StreamOutput(stream, io.Discard, io.Discard)
+	require.NoError(t, err)
+	require.EqualValues(t, syscall.SIGKILL, status.GetSignal())
+
+	_, err = cli.KillSession(ctx, &core.KillSessionRequest{Id: state.ID})
+	require.Error(t, err)
+}
+
+func TestScrollback(t *testing.T) {
+	b := newScrollback(8)
+	b.write(core.IODataType_Stdout, []byte("0123"))
+	b.write(core.IODataType_Stderr, []byte("4567"))
+	b.write(core.IODataType_Stdout, []byte("89"))
+	require.EqualValues(t, 2, b.start)
+	require.EqualValues(t, 10, b.end)
+
+	start, chunks := b.since(0)
+	require.EqualValues(t, 2, start)
+	require.Len(t, chunks, 3)
+	require.Equal(t, "23", string(chunks[0].GetData()))
+	require.Equal(t, core.IODataType_Stderr, chunks[1].GetType())
+
+	start, chunks = b.since(5)
+	require.EqualValues(t, 5, start)
+	require.Len(t, chunks, 2)
+	require.Equal(t, "567", string(chunks[0].GetData()))
+
+	_, chunks = b.since(10)
+	require.Empty(t, chunks)
+}
+
+// recvType 接收消息直到出现类型为 t 的消息
+func recvType(t *testing.T, stream MsgStream, typ core.ShellMsgType) *core.ShellMsg {
+	for {
+		msg, err := stream.Recv()
+		require.NoError(t, err)
+		if msg.GetType() == typ {
+			return msg
+		}
+	}
+}
+
+func TestSessionViewers(t *testing.T) {
+	srv := &Server{Sessions: &SessionRegistry{}}
+	cli := newShellClient(t, srv)
+	writer, err := cli.Shell(ctx)
+	require.NoError(t, err)
+	err = writer.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "echo ready; read x; echo got $x"},
+			DisablePty: true,
+		}},
+	})
+	require.NoError(t, err)
+	session := recvType(t, writer, core.ShellMsgType_SHELL_MSG_TYPE_SESSION).GetSession()
+	require.True(t, session.GetWriter())
+
+	viewer, err := cli.AttachSession(ctx)
+	require.NoError(t, err)
+	viewerState := &SessionState{ID: session.GetId(), ReadOnly: true}
+	require.NoError(t, viewerState.Attach(viewer))
+	viewerSession := recvType(t, viewer, core.ShellMsgType_SHELL_MSG_TYPE_SESSION).GetSession()
+	require.False(t, viewerSession.GetWriter())
+	require.Equal(t, "ready\n", string(recvType(t, viewer, core.ShellMsgType_SHELL_MSG_TYPE_IO).GetIO().GetData()))
+
+	// 只读观察者的输入被拒绝
+	_, err = StreamWriter(viewer, core.IODataType_Stdin).Write([]byte("bad\n"))
+	require.NoError(t, err)
+	control := recvType(t, viewer, core.ShellMsgType_SHELL_MSG_TYPE_CONTROL).GetControl()
+	require.Equal(t, core.ControlAction_CONTROL_ACTION_REJECTED, control.GetAction())
+
+	// 移交写入权
+	require.NoError(t, Handover(writer, viewerSession.GetClientId()))
+	control = recvType(t, viewer, core.ShellMsgType_SHELL_MSG_TYPE_CONTROL).GetControl()
+	require.Equal(t, core.ControlAction_CONTROL_ACTION_WRITER, control.GetAction())
+	require.Equal(t, viewerSession.GetClientId(), control.GetClientId())
+
+	_, err = StreamWriter(viewer, core.IODataType_Stdin).Write([]byte("ok\n"))
 	require.NoError(t, err)
+	for _, stream := range []MsgStream{writer, viewer} {
+		out := &bytes.Buffer{}
+		status, err := StreamOutput(stream, out, out)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, status.GetCode())
+		require.Contains(t, out.String(), "got ok\n")
+	}
+}
 
-	stream.Send(&core.ShellRequest{
-		Command: "echo hello",
+func TestSessionAttachKeepsWriter(t *testing.T) {
+	srv := &Server{Sessions: &SessionRegistry{}}
+	cli := newShellClient(t, srv)
+	writer, err := cli.Shell(ctx)
+	require.NoError(t, err)
+	err = writer.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "echo ready; read x; echo got $x"},
+			DisablePty: true,
+		}},
 	})
+	require.NoError(t, err)
+	session := recvType(t, writer, core.ShellMsgType_SHELL_MSG_TYPE_SESSION).GetSession()
+
+	// 已有写入者时非只读连接也只能观察
+	other, err := cli.AttachSession(ctx)
+	require.NoError(t, err)
+	require.NoError(t, (&SessionState{ID: session.GetId()}).Attach(other))
+	otherSession := recvType(t, other, core.ShellMsgType_SHELL_MSG_TYPE_SESSION).GetSession()
+	require.False(t, otherSession.GetWriter())
+	_, err = StreamWriter(other, core.IODataType_Stdin).Write([]byte("bad\n"))
+	require.NoError(t, err)
+	control := recvType(t, other, core.ShellMsgType_SHELL_MSG_TYPE_CONTROL).GetControl()
+	require.Equal(t, core.ControlAction_CONTROL_ACTION_REJECTED, control.GetAction())
+
+	_, err = StreamWriter(writer, core.IODataType_Stdin).Write([]byte("ok\n"))
+	require.NoError(t, err)
+	for _, stream := range []MsgStream{writer, other} {
+		out := &bytes.Buffer{}
+		status, err := StreamOutput(stream, out, out)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, status.GetCode())
+		require.Contains(t, out.String(), "got ok\n")
+	}
+}
+
+// blockingStream 的 Send 阻塞到 block 关闭, 模拟接收过慢的客户端
+type blockingStream struct {
+	MsgStream
+	block chan struct{}
+}
+
+func (s blockingStream) Send(*core.ShellMsg) error {
+	<-s.block
+	return nil
+}
+
+func TestSessionSlowClient(t *testing.T) {
+	sess := &session{
+		scrollback: newScrollback(defaultScrollback),
+		clients:    make(map[string]*attachment),
+		done:       make(chan struct{}),
+	}
+	block := make(chan struct{})
+	defer close(block)
+	slow := sess.attach(blockingStream{block: block}, 0, false)
+
+	// 慢客户端不阻塞输出, 队列满后被断开
+	out := sess.output(core.IODataType_Stdout)
+	for range clientQueueSize + 3 {
+		_, err := out.Write([]byte("x"))
+		require.NoError(t, err)
+	}
+	select {
+	case <-slow.kicked:
+	default:
+		t.Fatal("slow client is not kicked")
+	}
+	require.Equal(t, errSlowClient, slow.wait())
+	require.Empty(t, sess.clients)
+	require.Nil(t, sess.writer)
+}
+
+func TestSessionCleanup(t *testing.T) {
+	srv := &Server{Sessions: &SessionRegistry{}}
+	cli := newShellClient(t, srv)
+	writer, err := cli.Shell(ctx)
+	require.NoError(t, err)
+	err = writer.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path:       "sh",
+			Args:       []string{"-c", "echo ready; read x; sleep 0.2"},
+			DisablePty: true,
+		}},
+	})
+	require.NoError(t, err)
+	session := recvType(t, writer, core.ShellMsgType_SHELL_MSG_TYPE_SESSION).GetSession()
+	// clients 返回会话连接的客户端数, 会话已移除时返回 -1
+	clients := func() int32 {
+		res, err := cli.ListSessions(ctx, &core.ListSessionsRequest{})
+		require.NoError(t, err)
+		if len(res.GetSessions()) == 0 {
+			return -1
+		}
+		return res.GetSessions()[0].GetClients()
+	}
+
+	// 观察者关闭发送端后断开
+	viewerCtx, cancel := context.WithCancel(ctx)
+	viewer, err := cli.AttachSession(viewerCtx)
+	require.NoError(t, err)
+	require.NoError(t, (&SessionState{ID: session.GetId(), ReadOnly: true}).Attach(viewer))
+	recvType(t, viewer, core.ShellMsgType_SHELL_MSG_TYPE_IO)
+	require.NoError(t, viewer.CloseSend())
+	require.EqualValues(t, 2, clients())
+	cancel()
+	require.Eventually(t, func() bool {
+		return clients() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// 没有客户端连接时进程退出, 会话从列表中移除
+	_, err = StreamWriter(writer, core.IODataType_Stdin).Write([]byte("go\n"))
+	require.NoError(t, err)
+	require.NoError(t, Detach(writer))
+	require.Eventually(t, func() bool {
+		return clients() == -1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestShellRecord(t *testing.T) {
+	dir := t.TempDir()
+	srv := &Server{Recorder: &record.Recorder{Dir: dir}}
+	stream, err := newShellClient(t, srv).Shell(ctx)
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
+		Data: &core.ShellMsg_Cmd{Cmd: &core.Cmd{
+			Path: "sh",
+			Args: []string{"-c", "read x; echo got $x"},
+		}},
+	})
+	require.NoError(t, err)
+	err = stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_RESIZE,
+		Data: &core.ShellMsg_Resize{Resize: &core.WinSize{Cols: 100, Rows: 30}},
+	})
+	require.NoError(t, err)
+	_, err = StreamWriter(stream, core.IODataType_Stdin).Write([]byte("foo\n"))
+	require.NoError(t, err)
+	_, err = StreamOutput(stream, io.Discard, io.Discard)
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.cast"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := record.NewReader(f)
+	require.NoError(t, err)
+	require.Equal(t, 80, r.Header.Width)
+	types := map[string]string{}
+	for {
+		e, err := r.Next()
+		if err != nil {
+			break
+		}
+		types[e.Type] += e.Data
+	}
+	require.Equal(t, "foo\n", types[record.EventInput])
+	require.Equal(t, "100x30", types[record.EventResize])
+	require.Contains(t, types[record.EventOutput], "got foo")
 }
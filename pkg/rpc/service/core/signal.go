@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+// signals 允许客户端发送的信号
+var signals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// ForwardedSignals ForwardSignals 默认转发的本地信号
+var ForwardedSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// parseSignal 解析信号名, 支持省略 SIG 前缀
+func parseSignal(name string) (syscall.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, ok := signals[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported signal: %s", name)
+	}
+	return sig, nil
+}
+
+func signalName(sig syscall.Signal) (string, error) {
+	for name, s := range signals {
+		if s == sig {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported signal: %v", sig)
+}
+
+// signal 向进程所在的进程组发送信号, 进程以 Setsid 启动, 进程组 ID 即为其 pid
+func (c *process) signal(name string) error {
+	sig, err := parseSignal(name)
+	if err != nil {
+		return err
+	}
+	return syscall.Kill(-c.Process.Pid, sig)
+}
+
+// SendSignal 向远端进程组发送信号
+func SendSignal(stream MsgStream, sig syscall.Signal) error {
+	name, err := signalName(sig)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&core.ShellMsg{
+		Type: core.ShellMsgType_SHELL_MSG_TYPE_SIGNAL,
+		Data: &core.ShellMsg_Signal{
+			Signal: &core.Signal{Name: name},
+		},
+	})
+}
+
+// ForwardSignals 将本地收到的信号转发到远端, 直到 ctx 结束.
+// 终端处于 raw 模式时 Ctrl+C 等按键由远端 pty 处理, 无需转发
+func ForwardSignals(ctx context.Context, stream MsgStream, sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = ForwardedSignals
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-ch:
+			s, ok := sig.(syscall.Signal)
+			if !ok {
+				continue
+			}
+			err := SendSignal(stream, s)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,765 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+const (
+	// minSyncBlockSize 差异传输的最小块大小
+	minSyncBlockSize = 1 << 10
+	// maxSyncBlocks 单个文件的最大块数, 限制 SyncRequest 的大小
+	maxSyncBlocks = 64 << 10
+	// strongSumSize BlockSum.Strong 的长度
+	strongSumSize = 16
+)
+
+// syncBlockSize 按已有文件的大小选择块大小, 约为大小的平方根
+func syncBlockSize(size int64) int64 {
+	bs := int64(math.Sqrt(float64(size)))
+	return max(bs, minSyncBlockSize, (size+maxSyncBlocks-1)/maxSyncBlocks)
+}
+
+// rollsum rsync 的滚动校验和, 窗口滑动一个字节时可以 O(1) 更新
+type rollsum struct {
+	a, b uint32
+	n    uint32
+}
+
+func (r *rollsum) init(p []byte) {
+	r.a, r.b, r.n = 0, 0, uint32(len(p))
+	for i, c := range p {
+		r.a += uint32(c)
+		r.b += uint32(len(p)-i) * uint32(c)
+	}
+}
+
+// roll 移出窗口的第一个字节 out, 移入 in
+func (r *rollsum) roll(out, in byte) {
+	r.a += uint32(in) - uint32(out)
+	r.b += r.a - r.n*uint32(out)
+}
+
+func (r *rollsum) sum() uint32 {
+	return r.a&0xffff | r.b<<16
+}
+
+func weakSum(p []byte) uint32 {
+	var r rollsum
+	r.init(p)
+	return r.sum()
+}
+
+func strongSum(p []byte) []byte {
+	sum := sha256.Sum256(p)
+	return sum[:strongSumSize]
+}
+
+// blockSums 计算 r 中每个块的校验和, 最后一个块可能不足 bs
+func blockSums(r io.Reader, bs int64) ([]*core.BlockSum, error) {
+	var sums []*core.BlockSum
+	buf := make([]byte, bs)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			sums = append(sums, &core.BlockSum{Weak: weakSum(buf[:n]), Strong: strongSum(buf[:n])})
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return sums, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// deltaSender 按对端已有文件的块校验和发送 r 的差异, 连续的块合并为一条 COPY
+type deltaSender struct {
+	send  func(*core.SyncMsg) error
+	bs    int
+	sums  []*core.BlockSum
+	index map[uint32][]int
+	// lastSize 最后一个块的大小, 不足 bs 的块只在文件末尾匹配
+	lastSize int
+	// copyBlock 和 copyCount 尚未发送的 COPY
+	copyBlock, copyCount int64
+}
+
+func newDeltaSender(send func(*core.SyncMsg) error, req *core.SyncRequest) *deltaSender {
+	sums := req.GetBlocks()
+	d := &deltaSender{send: send, bs: int(req.GetBlockSize()), sums: sums, index: make(map[uint32][]int, len(sums))}
+	for i, s := range sums {
+		d.index[s.GetWeak()] = append(d.index[s.GetWeak()], i)
+	}
+	if len(sums) > 0 {
+		d.lastSize = int(req.GetSize() - int64(len(sums)-1)*req.GetBlockSize())
+	}
+	return d
+}
+
+// match 查找与 p 相同的块, 不存在时返回 -1
+func (d *deltaSender) match(weak uint32, p []byte) int {
+	var strong []byte
+	for _, i := range d.index[weak] {
+		if i == len(d.sums)-1 && len(p) != d.lastSize || i < len(d.sums)-1 && len(p) != d.bs {
+			continue
+		}
+		if strong == nil {
+			strong = strongSum(p)
+		}
+		if bytes.Equal(strong, d.sums[i].GetStrong()) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *deltaSender) flushCopy() error {
+	if d.copyCount == 0 {
+		return nil
+	}
+	err := d.send(&core.SyncMsg{
+		Type: core.SyncMsgType_SYNC_MSG_TYPE_COPY,
+		Data: &core.SyncMsg_Copy{Copy: &core.SyncCopy{Block: d.copyBlock, Count: d.copyCount}},
+	})
+	d.copyCount = 0
+	return err
+}
+
+func (d *deltaSender) sendCopy(block int) error {
+	if d.copyCount > 0 && d.copyBlock+d.copyCount == int64(block) {
+		d.copyCount++
+		return nil
+	}
+	err := d.flushCopy()
+	if err != nil {
+		return err
+	}
+	d.copyBlock, d.copyCount = int64(block), 1
+	return nil
+}
+
+func (d *deltaSender) sendData(p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+	err := d.flushCopy()
+	if err != nil {
+		return err
+	}
+	return d.send(&core.SyncMsg{
+		Type: core.SyncMsgType_SYNC_MSG_TYPE_DATA,
+		Data: &core.SyncMsg_Payload{Payload: p},
+	})
+}
+
+// Send 读取 r 直到 EOF, 发送 DATA 和 COPY 组成的差异, 不发送 FILE_END
+func (d *deltaSender) Send(r io.Reader) error {
+	if len(d.sums) == 0 || d.bs <= 0 || d.lastSize <= 0 || d.lastSize > d.bs {
+		return sendSyncData(d.send, r)
+	}
+	return d.sendDelta(r)
+}
+
+// sendDelta 滑动窗口查找匹配的块. buf[lit:pos] 为尚未发送的新数据, buf[pos:pos+bs] 为当前窗口
+func (d *deltaSender) sendDelta(r io.Reader) error {
+	bs := d.bs
+	buf := make([]byte, 0, 2*bs+fileChunkSize)
+	lit, pos := 0, 0
+	eof := false
+	// fill 读取数据直到窗口已满或 r 结束
+	fill := func() error {
+		for !eof && len(buf)-pos < bs {
+			if lit > 0 {
+				n := copy(buf, buf[lit:])
+				buf = buf[:n]
+				pos -= lit
+				lit = 0
+			}
+			n, err := r.Read(buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var rs rollsum
+	rolling := false
+	for {
+		err := fill()
+		if err != nil {
+			return err
+		}
+		n := min(bs, len(buf)-pos)
+		if n == 0 {
+			break
+		}
+		if n < bs {
+			// 文件末尾只可能匹配最后一个块
+			if n > d.lastSize {
+				pos = len(buf) - d.lastSize
+				n = d.lastSize
+			}
+			if n == d.lastSize {
+				if i := d.match(weakSum(buf[pos:]), buf[pos:]); i >= 0 {
+					err = d.sendData(buf[lit:pos])
+					if err == nil {
+						err = d.sendCopy(i)
+					}
+					if err != nil {
+						return err
+					}
+					lit, pos = len(buf), len(buf)
+				}
+			}
+			break
+		}
+		if !rolling {
+			rs.init(buf[pos : pos+bs])
+			rolling = true
+		}
+		if i := d.match(rs.sum(), buf[pos:pos+bs]); i >= 0 {
+			err = d.sendData(buf[lit:pos])
+			if err == nil {
+				err = d.sendCopy(i)
+			}
+			if err != nil {
+				return err
+			}
+			pos += bs
+			lit = pos
+			rolling = false
+			continue
+		}
+		out := buf[pos]
+		pos++
+		if pos-lit >= fileChunkSize {
+			err = d.sendData(buf[lit:pos])
+			if err != nil {
+				return err
+			}
+			lit = pos
+		}
+		err = fill()
+		if err != nil {
+			return err
+		}
+		if len(buf)-pos >= bs {
+			rs.roll(out, buf[pos+bs-1])
+		} else {
+			rolling = false
+		}
+	}
+	err := d.sendData(buf[lit:])
+	if err != nil {
+		return err
+	}
+	return d.flushCopy()
+}
+
+// sendSyncData 将 r 的内容分块以 DATA 发送
+func sendSyncData(send func(*core.SyncMsg) error, r io.Reader) error {
+	buf := make([]byte, fileChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			sendErr := send(&core.SyncMsg{
+				Type: core.SyncMsgType_SYNC_MSG_TYPE_DATA,
+				Data: &core.SyncMsg_Payload{Payload: buf[:n]},
+			})
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// syncer 处理一次 Sync 调用
+type syncer struct {
+	stream grpc.BidiStreamingServer[core.SyncMsg, core.SyncMsg]
+	root   string
+	start  *core.SyncStart
+}
+
+// Sync 将客户端的目录同步到 agent 上的 Path. 客户端先发送清单, agent 按清单顺序对比本地文件,
+// 大小和修改时间相同的文件视为未变化, 内容变化的文件按已有文件的块校验和只接收差异.
+// 单个路径的失败在 RESULT 中报告, 不影响其他路径
+func (s *FileServer) Sync(stream grpc.BidiStreamingServer[core.SyncMsg, core.SyncMsg]) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if msg.GetType() != core.SyncMsgType_SYNC_MSG_TYPE_START {
+		return status.Errorf(codes.InvalidArgument, "unexpected message type: %v", msg.GetType())
+	}
+	root, err := resolveFilePath(s.Allow, msg.GetStart().GetPath())
+	if err != nil {
+		return err
+	}
+	sy := &syncer{stream: stream, root: root, start: msg.GetStart()}
+	var entries []*core.FileInfo
+	names := make(map[string]bool)
+	for {
+		msg, err = stream.Recv()
+		if err != nil {
+			return err
+		}
+		if msg.GetType() == core.SyncMsgType_SYNC_MSG_TYPE_MANIFEST_END {
+			break
+		}
+		if msg.GetType() != core.SyncMsgType_SYNC_MSG_TYPE_ENTRY {
+			return status.Errorf(codes.InvalidArgument, "unexpected message type: %v", msg.GetType())
+		}
+		name := filepath.FromSlash(msg.GetEntry().GetPath())
+		if !filepath.IsLocal(name) || filepath.Clean(name) != name {
+			return status.Errorf(codes.InvalidArgument, "invalid path %q", msg.GetEntry().GetPath())
+		}
+		entries = append(entries, msg.GetEntry())
+		names[name] = true
+	}
+	err = os.MkdirAll(sy.root, 0o755)
+	if err != nil {
+		return fileError(err)
+	}
+	// 处理失败的目录, 跳过其下的所有路径
+	failed := make(map[string]bool)
+	for _, e := range entries {
+		var res *core.SyncResult
+		name := filepath.FromSlash(e.GetPath())
+		switch {
+		case failed[filepath.Dir(name)]:
+			res = syncFailed(e, fmt.Errorf("directory %s failed", filepath.ToSlash(filepath.Dir(name))))
+		case e.GetDir():
+			res = sy.syncDir(e)
+		default:
+			res, err = sy.syncFile(e)
+			if err != nil {
+				return err
+			}
+		}
+		if e.GetDir() && res.GetAction() == core.SyncAction_SYNC_ACTION_FAILED {
+			failed[name] = true
+		}
+		err = sy.sendResult(res)
+		if err != nil {
+			return err
+		}
+	}
+	if sy.start.GetDelete() {
+		return sy.deleteExtraneous(names)
+	}
+	return nil
+}
+
+func (sy *syncer) sendResult(res *core.SyncResult) error {
+	return sy.stream.Send(&core.SyncMsg{
+		Type: core.SyncMsgType_SYNC_MSG_TYPE_RESULT,
+		Data: &core.SyncMsg_Result{Result: res},
+	})
+}
+
+func syncFailed(e *core.FileInfo, err error) *core.SyncResult {
+	return &core.SyncResult{Path: e.GetPath(), Action: core.SyncAction_SYNC_ACTION_FAILED, Error: err.Error()}
+}
+
+// target 返回条目在 root 下的路径, 路径中的上级目录必须是目录而不是符号链接,
+// 防止写入 root 之外
+func (sy *syncer) target(e *core.FileInfo) (string, error) {
+	name := filepath.FromSlash(e.GetPath())
+	dir := sy.root
+	for _, elem := range strings.Split(filepath.Dir(name), string(filepath.Separator)) {
+		if elem == "." {
+			break
+		}
+		dir = filepath.Join(dir, elem)
+		fi, err := os.Lstat(dir)
+		if err != nil {
+			return "", err
+		}
+		if !fi.IsDir() {
+			return "", fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+	return filepath.Join(sy.root, name), nil
+}
+
+// syncDir 创建目录或更新已有目录的权限和属主
+func (sy *syncer) syncDir(e *core.FileInfo) *core.SyncResult {
+	target, err := sy.target(e)
+	if err != nil {
+		return syncFailed(e, err)
+	}
+	fi, err := os.Lstat(target)
+	switch {
+	case err == nil && fi.IsDir():
+		return sy.syncAttrs(e, target, fi)
+	case err == nil && sy.start.GetDelete():
+		err = os.Remove(target)
+	case err == nil:
+		err = fmt.Errorf("%s is not a directory", target)
+	case errors.Is(err, fs.ErrNotExist):
+		err = nil
+	}
+	if err == nil {
+		err = os.Mkdir(target, 0o700)
+	}
+	if err == nil {
+		fi, err = os.Lstat(target)
+	}
+	if err != nil {
+		return syncFailed(e, err)
+	}
+	res := sy.syncAttrs(e, target, fi)
+	if res.GetAction() != core.SyncAction_SYNC_ACTION_FAILED {
+		res.Action = core.SyncAction_SYNC_ACTION_CREATED
+	}
+	return res
+}
+
+// syncAttrs 更新已有路径的权限、属主和文件的修改时间
+func (sy *syncer) syncAttrs(e *core.FileInfo, target string, fi os.FileInfo) *core.SyncResult {
+	res := &core.SyncResult{Path: e.GetPath()}
+	changed := false
+	if fi.Mode().Perm() != os.FileMode(e.GetMode()).Perm() {
+		err := os.Chmod(target, os.FileMode(e.GetMode()).Perm())
+		if err != nil {
+			return syncFailed(e, err)
+		}
+		changed = true
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && sy.start.GetChown() && (st.Uid != e.GetUid() || st.Gid != e.GetGid()) {
+		err := os.Lchown(target, int(e.GetUid()), int(e.GetGid()))
+		if err != nil {
+			return syncFailed(e, err)
+		}
+		changed = true
+	}
+	if !e.GetDir() && fi.ModTime().UnixNano() != e.GetMtime() {
+		mtime := time.Unix(0, e.GetMtime())
+		err := os.Chtimes(target, mtime, mtime)
+		if err != nil {
+			return syncFailed(e, err)
+		}
+		changed = true
+	}
+	if changed {
+		res.Action = core.SyncAction_SYNC_ACTION_ATTRS
+	}
+	return res
+}
+
+// syncFile 对比已有文件, 内容变化时请求差异并写入. 返回的 error 表示 stream 已不可用
+func (sy *syncer) syncFile(e *core.FileInfo) (*core.SyncResult, error) {
+	target, err := sy.target(e)
+	if err != nil {
+		return syncFailed(e, err), nil
+	}
+	action := core.SyncAction_SYNC_ACTION_CREATED
+	var old *os.File
+	fi, err := os.Lstat(target)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return syncFailed(e, err), nil
+	case fi.Mode().IsRegular():
+		// 与 rsync 相同, 大小和修改时间都相同时不比较内容
+		if fi.Size() == e.GetSize() && fi.ModTime().UnixNano() == e.GetMtime() {
+			return sy.syncAttrs(e, target, fi), nil
+		}
+		if fi.Size() == e.GetSize() {
+			if sum, err := fileSum(target); err == nil && sum == e.GetSha256() {
+				return sy.syncAttrs(e, target, fi), nil
+			}
+		}
+		action = core.SyncAction_SYNC_ACTION_UPDATED
+		old, err = os.OpenFile(target, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+		if err != nil {
+			return syncFailed(e, err), nil
+		}
+		defer old.Close()
+	case sy.start.GetDelete():
+		err = os.RemoveAll(target)
+		if err != nil {
+			return syncFailed(e, err), nil
+		}
+	default:
+		return syncFailed(e, fmt.Errorf("%s is not a regular file", target)), nil
+	}
+
+	req := &core.SyncRequest{Path: e.GetPath()}
+	if old != nil {
+		req.Size = fi.Size()
+		req.BlockSize = syncBlockSize(fi.Size())
+		req.Blocks, err = blockSums(old, req.GetBlockSize())
+		if err != nil {
+			return syncFailed(e, err), nil
+		}
+	}
+	err = sy.stream.Send(&core.SyncMsg{
+		Type: core.SyncMsgType_SYNC_MSG_TYPE_REQUEST,
+		Data: &core.SyncMsg_Request{Request: req},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	part := target + partSuffix
+	f, writeErr := os.OpenFile(part, os.O_RDWR|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, 0o600)
+	if writeErr == nil {
+		defer f.Close()
+	}
+	h := sha256.New()
+	res := &core.SyncResult{Path: e.GetPath(), Action: action}
+	// 写入失败时继续接收差异直到 FILE_END
+	for {
+		msg, err := sy.stream.Recv()
+		if err == io.EOF {
+			return nil, status.Error(codes.InvalidArgument, "unexpected end of stream")
+		}
+		if err != nil {
+			return nil, err
+		}
+		var n int64
+		switch msg.GetType() {
+		case core.SyncMsgType_SYNC_MSG_TYPE_FILE_END:
+		case core.SyncMsgType_SYNC_MSG_TYPE_DATA:
+			res.Literal += int64(len(msg.GetPayload()))
+			if writeErr == nil {
+				_, writeErr = f.Write(msg.GetPayload())
+				_, _ = h.Write(msg.GetPayload())
+			}
+			continue
+		case core.SyncMsgType_SYNC_MSG_TYPE_COPY:
+			c := msg.GetCopy()
+			if c.GetBlock() < 0 || c.GetCount() <= 0 || c.GetBlock()+c.GetCount() > int64(len(req.GetBlocks())) {
+				return nil, status.Errorf(codes.InvalidArgument, "block %d+%d out of range", c.GetBlock(), c.GetCount())
+			}
+			if writeErr == nil {
+				r := io.NewSectionReader(old, c.GetBlock()*req.GetBlockSize(), c.GetCount()*req.GetBlockSize())
+				n, writeErr = io.Copy(io.MultiWriter(f, h), r)
+				res.Matched += n
+			}
+			continue
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unexpected message type: %v", msg.GetType())
+		}
+		break
+	}
+	if writeErr == nil {
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != e.GetSha256() {
+			writeErr = fmt.Errorf("sha256 mismatch: got %s, want %s", sum, e.GetSha256())
+		}
+	}
+	if writeErr == nil {
+		writeErr = finishPart(f, part, target, e, sy.start.GetChown())
+	}
+	if writeErr != nil {
+		_ = os.Remove(part)
+		res = syncFailed(e, writeErr)
+	}
+	return res, nil
+}
+
+// deleteExtraneous 删除目标目录中不在清单里的路径
+func (sy *syncer) deleteExtraneous(names map[string]bool) error {
+	err := filepath.WalkDir(sy.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sy.root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || names[rel] {
+			return nil
+		}
+		res := &core.SyncResult{Path: filepath.ToSlash(rel), Action: core.SyncAction_SYNC_ACTION_DELETED}
+		err = os.RemoveAll(path)
+		if err != nil {
+			res.Action, res.Error = core.SyncAction_SYNC_ACTION_FAILED, err.Error()
+		}
+		err = sy.sendResult(res)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if _, ok := status.FromError(err); !ok {
+		return fileError(err)
+	}
+	return err
+}
+
+// fileSum 计算文件内容的 SHA-256
+func fileSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SyncOptions SyncDir 的选项
+type SyncOptions struct {
+	// Delete 删除 agent 目录中本地不存在的文件和目录
+	Delete bool
+	// Owner 保留文件属主, 通常需要 root 权限
+	Owner bool
+}
+
+// SyncDir 将本地目录 src 同步到 agent 上的目录 dst, 只同步普通文件和目录, 忽略符号链接等其他类型.
+// 返回 agent 对每个路径的处理结果, 单个路径失败时不返回 error, 包括生成清单后读取本地文件失败
+func SyncDir(ctx context.Context, cli core.FileTransferClient, src, dst string, opt SyncOptions) ([]*core.SyncResult, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", src)
+	}
+	var entries []*core.FileInfo
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == src || !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var sum string
+		if !d.IsDir() {
+			sum, err = fileSum(path)
+			if err != nil {
+				return err
+			}
+		}
+		e := fileInfo(filepath.ToSlash(rel), fi, sum)
+		e.Dir = d.IsDir()
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := cli.Sync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&core.SyncMsg{
+		Type: core.SyncMsgType_SYNC_MSG_TYPE_START,
+		Data: &core.SyncMsg_Start{Start: &core.SyncStart{Path: dst, Delete: opt.Delete, Chown: opt.Owner}},
+	})
+	for _, e := range entries {
+		if err != nil {
+			break
+		}
+		err = stream.Send(&core.SyncMsg{
+			Type: core.SyncMsgType_SYNC_MSG_TYPE_ENTRY,
+			Data: &core.SyncMsg_Entry{Entry: e},
+		})
+	}
+	if err == nil {
+		err = stream.Send(&core.SyncMsg{Type: core.SyncMsgType_SYNC_MSG_TYPE_MANIFEST_END})
+	}
+	// io.EOF 表示 agent 已结束 stream, 错误由 Recv 返回
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var results []*core.SyncResult
+	// 读取失败的本地文件, agent 报告失败时使用本地的错误
+	localErrs := make(map[string]error)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch msg.GetType() {
+		case core.SyncMsgType_SYNC_MSG_TYPE_RESULT:
+			res := msg.GetResult()
+			if localErr := localErrs[res.GetPath()]; localErr != nil && res.GetAction() == core.SyncAction_SYNC_ACTION_FAILED {
+				res.Error = localErr.Error()
+			}
+			results = append(results, res)
+		case core.SyncMsgType_SYNC_MSG_TYPE_REQUEST:
+			req := msg.GetRequest()
+			localErr, err := sendSyncFile(stream.Send, filepath.Join(src, filepath.FromSlash(req.GetPath())), req)
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if localErr != nil {
+				localErrs[req.GetPath()] = localErr
+			}
+		default:
+			return nil, status.Errorf(codes.Internal, "unexpected message type: %v", msg.GetType())
+		}
+	}
+}
+
+// sendSyncFile 按 agent 的请求发送文件差异, 以 FILE_END 结束. 读取本地文件失败时同样以 FILE_END 结束,
+// agent 校验内容失败后报告该路径失败. localErr 为读取本地文件的错误, err 为发送的错误
+func sendSyncFile(send func(*core.SyncMsg) error, path string, req *core.SyncRequest) (localErr, err error) {
+	var sendErr error
+	trackSend := func(msg *core.SyncMsg) error {
+		sendErr = send(msg)
+		return sendErr
+	}
+	f, localErr := os.Open(path)
+	if localErr == nil {
+		defer f.Close()
+		localErr = newDeltaSender(trackSend, req).Send(f)
+	}
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	return localErr, send(&core.SyncMsg{Type: core.SyncMsgType_SYNC_MSG_TYPE_FILE_END})
+}
@@ -0,0 +1,346 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lyp256/tianmen/pkg/rpc/api/core"
+)
+
+func TestRollsum(t *testing.T) {
+	data := make([]byte, 4096)
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range data {
+		data[i] = byte(r.Uint32())
+	}
+	const n = 1000
+	var rs rollsum
+	rs.init(data[:n])
+	for i := 1; i+n <= len(data); i++ {
+		rs.roll(data[i-1], data[i+n-1])
+		require.Equal(t, weakSum(data[i:i+n]), rs.sum(), i)
+	}
+}
+
+// applyDelta 按 deltaSender 发送的消息由 old 重建文件, 返回复用的字节数
+func applyDelta(t *testing.T, old []byte, bs int64, msgs []*core.SyncMsg) ([]byte, int64) {
+	out := &bytes.Buffer{}
+	var matched int64
+	for _, msg := range msgs {
+		switch msg.GetType() {
+		case core.SyncMsgType_SYNC_MSG_TYPE_DATA:
+			out.Write(msg.GetPayload())
+		case core.SyncMsgType_SYNC_MSG_TYPE_COPY:
+			c := msg.GetCopy()
+			n, err := io.Copy(out, io.NewSectionReader(bytes.NewReader(old), c.GetBlock()*bs, c.GetCount()*bs))
+			require.NoError(t, err)
+			matched += n
+		default:
+			t.Fatalf("unexpected message type: %v", msg.GetType())
+		}
+	}
+	return out.Bytes(), matched
+}
+
+func TestDeltaSender(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(r.Uint32())
+		}
+		return b
+	}
+	old := random(300<<10 + 123)
+	concat := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	for name, c := range map[string]struct {
+		old, new []byte
+		// literal 最多传输的新数据
+		literal int
+	}{
+		"identical": {old, old, 0},
+		"insert":    {old, concat(old[:1000], random(10), old[1000:]), 10 + 2*1024},
+		"delete":    {old, concat(old[:100<<10], old[100<<10+500:]), 2 * 1024},
+		"prepend":   {old, concat(random(77), old), 77},
+		"append":    {old, concat(old, random(5000)), 5000 + 1024},
+		"truncate":  {old, old[:150<<10+3], 1024},
+		"empty":     {old, nil, 0},
+		"small":     {old[:100], concat(old[:100], random(10)), 110},
+		"new":       {nil, random(100 << 10), 100 << 10},
+	} {
+		t.Run(name, func(t *testing.T) {
+			bs := syncBlockSize(int64(len(c.old)))
+			sums, err := blockSums(bytes.NewReader(c.old), bs)
+			require.NoError(t, err)
+			var msgs []*core.SyncMsg
+			send := func(msg *core.SyncMsg) error {
+				// 发送方复用缓冲区
+				if msg.GetType() == core.SyncMsgType_SYNC_MSG_TYPE_DATA {
+					msg.Data = &core.SyncMsg_Payload{Payload: bytes.Clone(msg.GetPayload())}
+				}
+				msgs = append(msgs, msg)
+				return nil
+			}
+			req := &core.SyncRequest{BlockSize: bs, Blocks: sums, Size: int64(len(c.old))}
+			err = newDeltaSender(send, req).Send(bytes.NewReader(c.new))
+			require.NoError(t, err)
+			got, matched := applyDelta(t, c.old, bs, msgs)
+			require.True(t, bytes.Equal(c.new, got))
+			require.LessOrEqual(t, int64(len(c.new))-matched, int64(c.literal))
+		})
+	}
+}
+
+func newSyncTree(t *testing.T, dir string, mtime time.Time) {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "conf.d", "empty"), 0o755))
+	writeTestFile(t, filepath.Join(dir, "app.conf"), 100, 0o640, mtime)
+	writeTestFile(t, filepath.Join(dir, "conf.d", "big.bin"), 200<<10, 0o600, mtime)
+	require.NoError(t, os.Symlink("app.conf", filepath.Join(dir, "link")))
+}
+
+// syncActions 按路径返回每个结果的动作
+func syncActions(t *testing.T, results []*core.SyncResult) map[string]core.SyncAction {
+	actions := make(map[string]core.SyncAction)
+	for _, res := range results {
+		require.Empty(t, res.GetError(), res.GetPath())
+		actions[res.GetPath()] = res.GetAction()
+	}
+	return actions
+}
+
+// requireSameTree 检查 got 中的普通文件和目录与 want 相同
+func requireSameTree(t *testing.T, want, got string) {
+	n := 0
+	err := filepath.WalkDir(want, func(path string, d os.DirEntry, err error) error {
+		require.NoError(t, err)
+		if d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(want, path)
+		require.NoError(t, err)
+		n++
+		wantInfo, err := d.Info()
+		require.NoError(t, err)
+		gotInfo, err := os.Lstat(filepath.Join(got, rel))
+		require.NoError(t, err)
+		require.Equal(t, wantInfo.Mode(), gotInfo.Mode(), rel)
+		if !d.IsDir() {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			requireFile(t, filepath.Join(got, rel), data, wantInfo.Mode().Perm(), wantInfo.ModTime())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Greater(t, n, 1)
+}
+
+func TestSyncDir(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	dst := filepath.Join(remote, "app")
+	cli := newFileClient(t, &FileServer{Allow: []string{remote}})
+	mtime := time.Unix(1700000000, 0)
+	newSyncTree(t, local, mtime)
+
+	results, err := SyncDir(ctx, cli, local, dst, SyncOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]core.SyncAction{
+		"app.conf":       core.SyncAction_SYNC_ACTION_CREATED,
+		"conf.d":         core.SyncAction_SYNC_ACTION_CREATED,
+		"conf.d/big.bin": core.SyncAction_SYNC_ACTION_CREATED,
+		"conf.d/empty":   core.SyncAction_SYNC_ACTION_CREATED,
+	}, syncActions(t, results))
+	requireSameTree(t, local, dst)
+	_, err = os.Lstat(filepath.Join(dst, "link"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	results, err = SyncDir(ctx, cli, local, dst, SyncOptions{})
+	require.NoError(t, err)
+	for path, action := range syncActions(t, results) {
+		require.Equal(t, core.SyncAction_SYNC_ACTION_UNCHANGED, action, path)
+	}
+
+	// 修改大文件中间的数据, 只传输变化的块
+	big := filepath.Join(local, "conf.d", "big.bin")
+	data, err := os.ReadFile(big)
+	require.NoError(t, err)
+	copy(data[100<<10:], "changed")
+	require.NoError(t, os.WriteFile(big, data, 0o600))
+	require.NoError(t, os.Chmod(filepath.Join(local, "app.conf"), 0o600))
+	// 内容相同, 只有修改时间不同
+	require.NoError(t, os.Chtimes(filepath.Join(dst, "conf.d", "empty"), mtime, mtime))
+	newMtime := mtime.Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dst, "app.conf"), newMtime, newMtime))
+	// agent 上多余的文件
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "stale.conf"), nil, 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dst, "old", "sub"), 0o755))
+
+	results, err = SyncDir(ctx, cli, local, dst, SyncOptions{})
+	require.NoError(t, err)
+	actions := syncActions(t, results)
+	require.Equal(t, core.SyncAction_SYNC_ACTION_UPDATED, actions["conf.d/big.bin"])
+	require.Equal(t, core.SyncAction_SYNC_ACTION_ATTRS, actions["app.conf"])
+	require.Equal(t, core.SyncAction_SYNC_ACTION_UNCHANGED, actions["conf.d"])
+	require.NotContains(t, actions, "stale.conf")
+	for _, res := range results {
+		if res.GetPath() == "conf.d/big.bin" {
+			require.Less(t, res.GetLiteral(), int64(2*syncBlockSize(200<<10)))
+			require.EqualValues(t, len(data), res.GetLiteral()+res.GetMatched())
+		}
+	}
+	requireSameTree(t, local, dst)
+	require.FileExists(t, filepath.Join(dst, "stale.conf"))
+
+	results, err = SyncDir(ctx, cli, local, dst, SyncOptions{Delete: true})
+	require.NoError(t, err)
+	actions = syncActions(t, results)
+	require.Equal(t, core.SyncAction_SYNC_ACTION_DELETED, actions["stale.conf"])
+	require.Equal(t, core.SyncAction_SYNC_ACTION_DELETED, actions["old"])
+	require.NotContains(t, actions, "old/sub")
+	require.NoFileExists(t, filepath.Join(dst, "stale.conf"))
+	require.NoDirExists(t, filepath.Join(dst, "old"))
+	requireSameTree(t, local, dst)
+}
+
+func TestSyncDirConflict(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	cli := newFileClient(t, &FileServer{Allow: []string{remote}})
+	newSyncTree(t, local, time.Unix(1700000000, 0))
+	// agent 上同名路径的类型不同
+	require.NoError(t, os.MkdirAll(filepath.Join(remote, "app.conf"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(remote, "conf.d"), nil, 0o600))
+
+	results, err := SyncDir(ctx, cli, local, remote, SyncOptions{})
+	require.NoError(t, err)
+	failed := 0
+	for _, res := range results {
+		if res.GetAction() == core.SyncAction_SYNC_ACTION_FAILED {
+			failed++
+		}
+	}
+	// app.conf、conf.d 及其下的路径
+	require.Equal(t, 4, failed)
+
+	results, err = SyncDir(ctx, cli, local, remote, SyncOptions{Delete: true})
+	require.NoError(t, err)
+	syncActions(t, results)
+	requireSameTree(t, local, remote)
+}
+
+func TestSyncDirDenied(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	cli := newFileClient(t, &FileServer{Allow: []string{filepath.Join(remote, "app")}})
+	newSyncTree(t, local, time.Now())
+	_, err := SyncDir(ctx, cli, local, filepath.Join(remote, "other"), SyncOptions{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = SyncDir(ctx, cli, local, filepath.Join(remote, "app"), SyncOptions{})
+	require.NoError(t, err)
+}
+
+func TestSyncDirSymlink(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	allowed, outside := filepath.Join(remote, "app"), filepath.Join(remote, "outside")
+	require.NoError(t, os.MkdirAll(allowed, 0o755))
+	require.NoError(t, os.MkdirAll(outside, 0o755))
+	cli := newFileClient(t, &FileServer{Allow: []string{allowed}})
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "a", "sub"), 0o755))
+	writeTestFile(t, filepath.Join(local, "a", "pwned"), 10, 0o600, time.Now())
+	writeTestFile(t, filepath.Join(local, "a", "sub", "pwned"), 10, 0o600, time.Now())
+	// agent 上允许的目录中指向其外的符号链接
+	require.NoError(t, os.Symlink("../outside", filepath.Join(allowed, "a")))
+
+	results, err := SyncDir(ctx, cli, local, allowed, SyncOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for _, res := range results {
+		require.Equal(t, core.SyncAction_SYNC_ACTION_FAILED, res.GetAction(), res.GetPath())
+	}
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	_, err = SyncDir(ctx, cli, local, filepath.Join(allowed, "a"), SyncOptions{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Delete 时替换符号链接
+	results, err = SyncDir(ctx, cli, local, allowed, SyncOptions{Delete: true})
+	require.NoError(t, err)
+	syncActions(t, results)
+	requireSameTree(t, local, allowed)
+	entries, err = os.ReadDir(outside)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+// requestHookClient 在收到 agent 的 REQUEST 后调用 onRequest
+type requestHookClient struct {
+	core.FileTransferClient
+	onRequest func(req *core.SyncRequest)
+}
+
+func (c requestHookClient) Sync(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[core.SyncMsg, core.SyncMsg], error) {
+	stream, err := c.FileTransferClient.Sync(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return requestHookStream{BidiStreamingClient: stream, onRequest: c.onRequest}, nil
+}
+
+type requestHookStream struct {
+	grpc.BidiStreamingClient[core.SyncMsg, core.SyncMsg]
+	onRequest func(req *core.SyncRequest)
+}
+
+func (s requestHookStream) Recv() (*core.SyncMsg, error) {
+	msg, err := s.BidiStreamingClient.Recv()
+	if msg.GetType() == core.SyncMsgType_SYNC_MSG_TYPE_REQUEST {
+		s.onRequest(msg.GetRequest())
+	}
+	return msg, err
+}
+
+func TestSyncDirLocalError(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	newSyncTree(t, local, time.Unix(1700000000, 0))
+	// 生成清单后删除本地文件
+	cli := requestHookClient{
+		FileTransferClient: newFileClient(t, &FileServer{Allow: []string{remote}}),
+		onRequest: func(req *core.SyncRequest) {
+			if req.GetPath() == "conf.d/big.bin" {
+				require.NoError(t, os.Remove(filepath.Join(local, "conf.d", "big.bin")))
+			}
+		},
+	}
+
+	results, err := SyncDir(ctx, cli, local, remote, SyncOptions{})
+	require.NoError(t, err)
+	actions := make(map[string]core.SyncAction)
+	for _, res := range results {
+		actions[res.GetPath()] = res.GetAction()
+		if res.GetPath() == "conf.d/big.bin" {
+			require.Contains(t, res.GetError(), "no such file or directory")
+		}
+	}
+	require.Equal(t, map[string]core.SyncAction{
+		"app.conf":       core.SyncAction_SYNC_ACTION_CREATED,
+		"conf.d":         core.SyncAction_SYNC_ACTION_CREATED,
+		"conf.d/big.bin": core.SyncAction_SYNC_ACTION_FAILED,
+		"conf.d/empty":   core.SyncAction_SYNC_ACTION_CREATED,
+	}, actions)
+	require.NoFileExists(t, filepath.Join(remote, "conf.d", "big.bin"))
+	require.NoFileExists(t, filepath.Join(remote, "conf.d", "big.bin"+partSuffix))
+	requireSameTree(t, local, remote)
+}
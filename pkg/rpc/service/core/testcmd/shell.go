@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"crypto/tls"
-	"io"
 	"os"
 	"os/signal"
 	"syscall"
@@ -54,33 +53,23 @@ func main() {
 	cli := core.NewShellClient(cliConn)
 	stream, err := cli.Shell(context.Background())
 	noError(err)
+	sender := serverCore.SyncStream(stream)
 
-	err = stream.Send(&core.ShellMsg{
+	err = sender.Send(&core.ShellMsg{
 		Type: core.ShellMsgType_SHELL_MSG_TYPE_COMMAND,
 		Data: &core.ShellMsg_Cmd{
 			Cmd: &core.Cmd{
 				Path: "zsh",
+				Envs: serverCore.LocalEnvs("TERM", "LANG"),
 			},
 		},
 	})
 	noError(err)
+	exitCh := make(chan *core.ExitStatus, 1)
 	go func() {
-		for {
-			msg, err := stream.Recv()
-			noError(err)
-			data := msg.GetIO()
-			if data == nil {
-				continue
-			}
-			switch data.GetType() {
-			case core.IODataType_Stdout:
-				_, err = os.Stdout.Write(data.GetData())
-				noError(err)
-			case core.IODataType_Stderr:
-				_, err = os.Stderr.Write(data.GetData())
-				noError(err)
-			}
-		}
+		status, err := serverCore.StreamOutput(stream, os.Stdout, os.Stderr)
+		noError(err)
+		exitCh <- status
 	}()
 	go func() {
 		// 4. 处理终端大小变化
@@ -90,15 +79,24 @@ func main() {
 		debounced := debounce.New(100 * time.Millisecond)
 		for range resizeTty {
 			debounced(func() {
-				err = serverCore.ReflushWindowsSize(stream, os.Stdin)
+				err = serverCore.ReflushWindowsSize(sender, os.Stdin)
 				noError(err)
 			})
 		}
 	}()
-	err = serverCore.ReflushWindowsSize(stream, os.Stdin)
+	err = serverCore.ReflushWindowsSize(sender, os.Stdin)
 	noError(err)
-	rpcin := serverCore.StreamWriter(stream, core.IODataType_Stdin)
+	go func() {
+		err := serverCore.SendStdin(sender, os.Stdin)
+		noError(err)
+	}()
+	// raw 模式下 Ctrl+C 由远端 pty 处理, 本进程收到的 SIGTERM、SIGHUP 等信号转发到远端进程组
+	go func() {
+		err := serverCore.ForwardSignals(ctx, sender)
+		noError(err)
+	}()
 
-	_, err = io.Copy(rpcin, os.Stdin)
-	noError(err)
+	status := <-exitCh
+	_ = term.Restore(int(os.Stdin.Fd()), oldState)
+	os.Exit(serverCore.ExitCode(status))
 }
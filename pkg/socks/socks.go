@@ -0,0 +1,415 @@
+// Package socks 实现 SOCKS5 服务端 (RFC 1928), 支持 CONNECT 和 UDP ASSOCIATE,
+// 目标连接通过 Dialer 建立, 可以经 agent 访问其所在的网络
+package socks
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lyp256/tianmen/pkg/rpc/mux"
+)
+
+const (
+	version5 = 5
+
+	methodNoAuth       = 0x00
+	methodUserPassword = 0x02
+	methodNoAcceptable = 0xff
+
+	cmdConnect      = 0x01
+	cmdUDPAssociate = 0x03
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+
+	repSucceeded          = 0x00
+	repGeneralFailure     = 0x01
+	repHostUnreachable    = 0x04
+	repCommandUnsupported = 0x07
+	repAddressUnsupported = 0x08
+
+	// handshakeTimeout 客户端完成协商和请求的最长时间
+	handshakeTimeout = 30 * time.Second
+	// maxUDPSize UDP 数据报的最大长度
+	maxUDPSize = 64 << 10
+)
+
+// Server SOCKS5 服务端
+type Server struct {
+	// Dialer 为 CONNECT 建立 TCP 连接
+	Dialer mux.ContextDialer
+	// UDPDialer 为 UDP ASSOCIATE 建立 UDP 连接, 为空时不支持 UDP ASSOCIATE
+	UDPDialer mux.ContextDialer
+	// Auth 不为空时要求客户端使用用户名密码认证 (RFC 1929)
+	Auth func(user, password string) bool
+	// ErrorLog 不为空时接收单个连接处理失败的错误
+	ErrorLog func(remote net.Addr, err error)
+}
+
+// Serve 接收 l 上的连接, 直到 l 关闭
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			err := s.ServeConn(conn)
+			if err != nil && s.ErrorLog != nil {
+				s.ErrorLog(conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeConn 处理一个客户端连接, 返回时连接未关闭
+func (s *Server) ServeConn(conn net.Conn) error {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	err := s.negotiate(conn)
+	if err != nil {
+		return err
+	}
+	// VER CMD RSV ATYP
+	head := make([]byte, 3)
+	_, err = io.ReadFull(conn, head)
+	if err != nil {
+		return err
+	}
+	if head[0] != version5 {
+		return fmt.Errorf("unsupported version %d", head[0])
+	}
+	addr, err := readAddr(conn)
+	if err != nil {
+		_ = writeReply(conn, repAddressUnsupported, nil)
+		return err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	switch head[1] {
+	case cmdConnect:
+		return s.connect(conn, addr)
+	case cmdUDPAssociate:
+		if s.UDPDialer == nil {
+			_ = writeReply(conn, repCommandUnsupported, nil)
+			return errors.New("udp associate is not supported")
+		}
+		return s.udpAssociate(conn)
+	default:
+		_ = writeReply(conn, repCommandUnsupported, nil)
+		return fmt.Errorf("unsupported command %d", head[1])
+	}
+}
+
+// negotiate 协商认证方法, 需要时进行用户名密码认证
+func (s *Server) negotiate(conn net.Conn) error {
+	head := make([]byte, 2)
+	_, err := io.ReadFull(conn, head)
+	if err != nil {
+		return err
+	}
+	if head[0] != version5 {
+		return fmt.Errorf("unsupported version %d", head[0])
+	}
+	methods := make([]byte, head[1])
+	_, err = io.ReadFull(conn, methods)
+	if err != nil {
+		return err
+	}
+	method := byte(methodNoAuth)
+	if s.Auth != nil {
+		method = methodUserPassword
+	}
+	if !bytes.Contains(methods, []byte{method}) {
+		_, _ = conn.Write([]byte{version5, methodNoAcceptable})
+		return errors.New("no acceptable authentication method")
+	}
+	_, err = conn.Write([]byte{version5, method})
+	if err != nil {
+		return err
+	}
+	if s.Auth == nil {
+		return nil
+	}
+	// VER ULEN UNAME PLEN PASSWD
+	_, err = io.ReadFull(conn, head)
+	if err != nil {
+		return err
+	}
+	user := make([]byte, head[1])
+	_, err = io.ReadFull(conn, user)
+	if err != nil {
+		return err
+	}
+	_, err = io.ReadFull(conn, head[:1])
+	if err != nil {
+		return err
+	}
+	password := make([]byte, head[0])
+	_, err = io.ReadFull(conn, password)
+	if err != nil {
+		return err
+	}
+	if !s.Auth(string(user), string(password)) {
+		_, _ = conn.Write([]byte{1, 1})
+		return fmt.Errorf("authentication failed for user %q", user)
+	}
+	_, err = conn.Write([]byte{1, 0})
+	return err
+}
+
+func (s *Server) connect(conn net.Conn, addr string) error {
+	target, err := s.Dialer.DialContext(context.Background(), addr)
+	if err != nil {
+		_ = writeReply(conn, repHostUnreachable, nil)
+		return fmt.Errorf("connect %s: %w", addr, err)
+	}
+	defer target.Close()
+	err = writeReply(conn, repSucceeded, nil)
+	if err != nil {
+		return err
+	}
+	mux.Pipe(conn, target)
+	return nil
+}
+
+// udpAssociate 在本地 UDP 端口上中继数据报, 控制连接关闭时结束
+func (s *Server) udpAssociate(conn net.Conn) error {
+	host, _, err := net.SplitHostPort(conn.LocalAddr().String())
+	if err != nil {
+		_ = writeReply(conn, repGeneralFailure, nil)
+		return err
+	}
+	pc, err := net.ListenPacket("udp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		_ = writeReply(conn, repGeneralFailure, nil)
+		return err
+	}
+	defer pc.Close()
+	err = writeReply(conn, repSucceeded, pc.LocalAddr())
+	if err != nil {
+		return err
+	}
+	clientHost, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	r := &udpRelay{
+		server:   s,
+		pc:       pc,
+		clientIP: net.ParseIP(clientHost),
+		targets:  make(map[string]net.Conn),
+	}
+	go func() {
+		// 控制连接关闭时结束中继
+		_, _ = io.Copy(io.Discard, conn)
+		_ = pc.Close()
+	}()
+	r.serve()
+	r.close()
+	return nil
+}
+
+// udpRelay UDP ASSOCIATE 的中继, 每个目标使用一个 UDPDialer 建立的连接
+type udpRelay struct {
+	server   *Server
+	pc       net.PacketConn
+	clientIP net.IP
+
+	mu      sync.Mutex
+	client  net.Addr
+	targets map[string]net.Conn
+}
+
+func (r *udpRelay) serve() {
+	buf := make([]byte, maxUDPSize)
+	for {
+		n, from, err := r.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		// 只接收控制连接所在主机发送的数据报
+		udpFrom, ok := from.(*net.UDPAddr)
+		if !ok || !udpFrom.IP.Equal(r.clientIP) {
+			continue
+		}
+		addr, data, err := parseUDPHeader(buf[:n])
+		if err != nil {
+			continue
+		}
+		r.mu.Lock()
+		r.client = from
+		r.mu.Unlock()
+		target, err := r.target(addr)
+		if err != nil {
+			if r.server.ErrorLog != nil {
+				r.server.ErrorLog(from, err)
+			}
+			continue
+		}
+		_, _ = target.Write(data)
+	}
+}
+
+// target 返回到 addr 的连接, 第一次使用时建立并开始接收回复
+func (r *udpRelay) target(addr string) (net.Conn, error) {
+	r.mu.Lock()
+	conn, ok := r.targets[addr]
+	r.mu.Unlock()
+	if ok {
+		return conn, nil
+	}
+	conn, err := r.server.UDPDialer.DialContext(context.Background(), addr)
+	if err != nil {
+		return nil, fmt.Errorf("udp %s: %w", addr, err)
+	}
+	r.mu.Lock()
+	if r.targets == nil {
+		// 中继已结束
+		r.mu.Unlock()
+		_ = conn.Close()
+		return nil, net.ErrClosed
+	}
+	r.targets[addr] = conn
+	r.mu.Unlock()
+	go r.reply(addr, conn)
+	return conn, nil
+}
+
+// reply 将目标的回复加上 SOCKS5 UDP 头发送给客户端
+func (r *udpRelay) reply(addr string, conn net.Conn) {
+	header, err := udpHeader(addr)
+	if err != nil {
+		return
+	}
+	buf := make([]byte, maxUDPSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		client := r.client
+		r.mu.Unlock()
+		_, _ = r.pc.WriteTo(append(header[:len(header):len(header)], buf[:n]...), client)
+	}
+}
+
+func (r *udpRelay) close() {
+	r.mu.Lock()
+	targets := r.targets
+	r.targets = nil
+	r.mu.Unlock()
+	for _, conn := range targets {
+		_ = conn.Close()
+	}
+}
+
+// readAddr 读取 ATYP DST.ADDR DST.PORT, 返回 host:port
+func readAddr(r io.Reader) (string, error) {
+	b := make([]byte, 1)
+	_, err := io.ReadFull(r, b)
+	if err != nil {
+		return "", err
+	}
+	var host string
+	switch b[0] {
+	case atypIPv4, atypIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if b[0] == atypIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		_, err = io.ReadFull(r, ip)
+		if err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case atypDomain:
+		_, err = io.ReadFull(r, b)
+		if err != nil {
+			return "", err
+		}
+		domain := make([]byte, b[0])
+		_, err = io.ReadFull(r, domain)
+		if err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", fmt.Errorf("unsupported address type %d", b[0])
+	}
+	port := make([]byte, 2)
+	_, err = io.ReadFull(r, port)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// appendAddr 追加 ATYP DST.ADDR DST.PORT
+func appendAddr(b []byte, addr string) ([]byte, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			b = append(append(b, atypIPv4), ip4...)
+		} else {
+			b = append(append(b, atypIPv6), ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("domain too long: %s", host)
+		}
+		b = append(append(b, atypDomain, byte(len(host))), host...)
+	}
+	return binary.BigEndian.AppendUint16(b, uint16(port)), nil
+}
+
+// writeReply 发送请求的回复, bind 为空时使用 0.0.0.0:0
+func writeReply(w io.Writer, rep byte, bind net.Addr) error {
+	addr := "0.0.0.0:0"
+	if bind != nil {
+		addr = bind.String()
+	}
+	b, err := appendAddr([]byte{version5, rep, 0}, addr)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// parseUDPHeader 解析 RSV FRAG ATYP DST.ADDR DST.PORT DATA, 不支持分片
+func parseUDPHeader(b []byte) (string, []byte, error) {
+	if len(b) < 4 {
+		return "", nil, errors.New("short udp datagram")
+	}
+	if b[2] != 0 {
+		return "", nil, errors.New("udp fragmentation not supported")
+	}
+	r := bytes.NewReader(b[3:])
+	addr, err := readAddr(r)
+	if err != nil {
+		return "", nil, err
+	}
+	return addr, b[len(b)-r.Len():], nil
+}
+
+// udpHeader 返回发送给客户端的数据报头
+func udpHeader(addr string) ([]byte, error) {
+	return appendAddr([]byte{0, 0, 0}, addr)
+}
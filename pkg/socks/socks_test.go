@@ -0,0 +1,128 @@
+package socks
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+
+	"github.com/lyp256/tianmen/pkg/testutil"
+)
+
+// netDialer 直接连接目标, 值为网络类型
+type netDialer string
+
+func (network netDialer) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, string(network), addr)
+}
+
+// startServer 启动直接连接目标的 SOCKS5 服务
+func startServer(t *testing.T, s *Server) string {
+	if s.Dialer == nil {
+		s.Dialer = netDialer("tcp")
+	}
+	if s.UDPDialer == nil {
+		s.UDPDialer = netDialer("udp")
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() { _ = s.Serve(l) }()
+	return l.Addr().String()
+}
+
+func TestConnect(t *testing.T) {
+	echo := testutil.StartTCPEcho(t)
+	addr := startServer(t, &Server{
+		Auth: func(user, password string) bool { return user == "user" && password == "secret" },
+	})
+	d, err := proxy.SOCKS5("tcp", addr, &proxy.Auth{User: "user", Password: "secret"}, proxy.Direct)
+	require.NoError(t, err)
+	conn, err := d.Dial("tcp", echo)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(buf))
+
+	d, err = proxy.SOCKS5("tcp", addr, &proxy.Auth{User: "user", Password: "wrong"}, proxy.Direct)
+	require.NoError(t, err)
+	_, err = d.Dial("tcp", echo)
+	require.Error(t, err)
+	// 没有认证信息
+	d, err = proxy.SOCKS5("tcp", addr, nil, proxy.Direct)
+	require.NoError(t, err)
+	_, err = d.Dial("tcp", echo)
+	require.Error(t, err)
+}
+
+func TestConnectUnreachable(t *testing.T) {
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+	addr := startServer(t, &Server{})
+	d, err := proxy.SOCKS5("tcp", addr, nil, proxy.Direct)
+	require.NoError(t, err)
+	_, err = d.Dial("tcp", closed.Addr().String())
+	require.ErrorContains(t, err, "host unreachable")
+}
+
+func TestUDPAssociate(t *testing.T) {
+	echo := testutil.StartUDPEcho(t)
+	addr := startServer(t, &Server{})
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte{version5, 1, methodNoAuth})
+	require.NoError(t, err)
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{version5, methodNoAuth}, buf)
+	req, err := appendAddr([]byte{version5, cmdUDPAssociate, 0}, "0.0.0.0:0")
+	require.NoError(t, err)
+	_, err = conn.Write(req)
+	require.NoError(t, err)
+	head := make([]byte, 3)
+	_, err = io.ReadFull(conn, head)
+	require.NoError(t, err)
+	require.EqualValues(t, repSucceeded, head[1])
+	relay, err := readAddr(conn)
+	require.NoError(t, err)
+
+	uc, err := net.Dial("udp", relay)
+	require.NoError(t, err)
+	defer uc.Close()
+	header, err := udpHeader(echo)
+	require.NoError(t, err)
+	for _, msg := range []string{"ping", "pong"} {
+		_, err = uc.Write(append(header, msg...))
+		require.NoError(t, err)
+		_ = uc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		reply := make([]byte, maxUDPSize)
+		n, err := uc.Read(reply)
+		require.NoError(t, err)
+		from, data, err := parseUDPHeader(reply[:n])
+		require.NoError(t, err)
+		require.Equal(t, echo, from)
+		require.Equal(t, msg, string(data))
+	}
+
+	// 控制连接关闭后停止中继
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool {
+		_, _ = uc.Write(append(header, "x"...))
+		_ = uc.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+		_, err := uc.Read(make([]byte, 16))
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
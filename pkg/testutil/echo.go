@@ -0,0 +1,59 @@
+package testutil
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// StartTCPEcho 启动 TCP echo 服务, 对端关闭写方向后关闭连接, 返回监听地址
+func StartTCPEcho(t testing.TB) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serveEcho(t, l)
+	return l.Addr().String()
+}
+
+// StartUnixEcho 在 path 上启动 unix socket echo 服务
+func StartUnixEcho(t testing.TB, path string) {
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	serveEcho(t, l)
+}
+
+// serveEcho 将 l 接收的连接原样返回, 测试结束时关闭 l
+func serveEcho(t testing.TB, l net.Listener) {
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+}
+
+// StartUDPEcho 启动 UDP echo 服务, 将每个数据报发回发送方, 返回监听地址
+func StartUDPEcho(t testing.TB) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pc.Close() })
+	go func() {
+		buf := make([]byte, 64<<10)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(buf[:n], addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}